@@ -12,7 +12,13 @@ const usageApp = `Usage:
     %s <command> [options]
 
 Available Commands:
+    daemon         Run the scheduled retrieval of the quotes
+    db             Query the quotes saved in the database
+    export (e)     Export the quotes saved in the database
     get (g)        Get the quotes of the specified isins
+    history (h)    Get the price history of the specified isins
+    portfolio (p)  Valuation of the holdings of the portfolio
+    report (r)     Reports from the quotes saved in the database
     server (se)    Start an http server to show json files
     sources (so)   Show available sources
     tor (t)        Check if Tor network will be used
@@ -28,9 +34,37 @@ func initApp() *flagx.Command {
 		ParseExec: parseExecApp,
 
 		SubCmd: map[string]*flagx.Command{
+			"daemon": {
+				ParseExec: parseExecDaemon,
+			},
+			"db": {
+				ParseExec: parseExecDB,
+				SubCmd: map[string]*flagx.Command{
+					"migrate": {
+						ParseExec: parseExecDBMigrate,
+					},
+				},
+			},
+			"export,e": {
+				ParseExec: parseExecExport,
+			},
 			"get,g": {
 				ParseExec: parseExecGet,
 			},
+			"history,h": {
+				ParseExec: parseExecHistory,
+			},
+			"portfolio,p": {
+				ParseExec: parseExecPortfolio,
+			},
+			"report,r": {
+				ParseExec: parseExecReport,
+				SubCmd: map[string]*flagx.Command{
+					"sources": {
+						ParseExec: parseExecReportSources,
+					},
+				},
+			},
 			"server,se": {
 				ParseExec: parseExecServer,
 			},
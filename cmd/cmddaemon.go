@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mmbros/quotes/internal/daemon"
+	"github.com/mmbros/quotes/internal/export"
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/quotes/internal/server"
+)
+
+const usageDaemon = `Usage:
+    %[1]s [options]
+
+Run the scheduled jobs defined in the daemon section of the config file:
+each job retrieves the quotes of its isins at the times of a cron expression,
+saves them in the database, if defined, and in a json file of the folder,
+and checks the alert rules of the isins.
+
+The http server shows the page with the graphs of the json files of the folder
+(see the server command), the health of the daemon in /health and the status
+of the last run of each job in /status.
+SIGINT (Ctrl-C) or SIGTERM stops the daemon: the running job is canceled,
+its partial results are saved and the http server is shut down.
+
+Options:
+    -a, --address     string   server address and port (default %[2]q)
+        --cache-ttl   duration time to live of the cached http responses (0 disables the cache)
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+    -d, --database    dns      database used to save the quotes (sqlite3 path or postgres url)
+        --deadline    duration max duration of each run; the results retrieved so far are saved
+    -n, --dry-run              show the jobs and their next run, without starting the daemon
+    -f, --folder      path     folder of the json files of the runs (default %[3]q)
+        --no-cache             do not use the cache of the http responses
+    -p, --proxy       url      default proxy
+
+Examples:
+    # runs the jobs of the config file and saves the quotes in the database
+    %[1]s -c quotes.yaml -d quotes.sqlite3 --folder ./data
+`
+
+// shutdownTimeout is the max duration of the shutdown of the http server.
+const shutdownTimeout = 10 * time.Second
+
+func parseExecDaemon(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppDaemon)
+	flags.SetUsage(usageDaemon, fullname, defaultAddress, defaultFolder)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	// the daemon is stopped by SIGINT or SIGTERM;
+	// after the first signal, the default behavior is restored
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	defer stop()
+
+	return execDaemon(ctx, os.Stdout, flags, cfg)
+}
+
+// daemonAddress returns the address of the http server and the folder
+// of the json files of the daemon, or their default values.
+func (cfg *Config) daemonAddress() (address, folder string) {
+	address, folder = defaultAddress, defaultFolder
+	if di := cfg.Daemon; di != nil {
+		if di.Address != "" {
+			address = di.Address
+		}
+		if di.Folder != "" {
+			folder = di.Folder
+		}
+	}
+	return
+}
+
+// checkJobs checks the isins of the jobs are defined and enabled.
+func (cfg *Config) checkJobs() error {
+	if len(cfg.jobs) == 0 {
+		return errors.New("daemon schedules not defined")
+	}
+	for _, job := range cfg.jobs {
+		for _, i := range job.Isins {
+			if _, ok := cfg.Isins[i]; !ok {
+				return fmt.Errorf("daemon schedule %q: isin %q not defined or disabled", job.Name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// execDaemon runs the jobs of the daemon and the http server, until ctx is canceled.
+func execDaemon(ctx context.Context, stdout io.Writer, flags *Flags, cfg *Config) error {
+	if err := cfg.checkJobs(); err != nil {
+		return err
+	}
+	address, folder := cfg.daemonAddress()
+
+	if flags.dryrun {
+		return printDaemonDryRunInfo(flags.Output(), flags, cfg)
+	}
+
+	// check the schema of the database before the first run
+	if cfg.Database != "" {
+		db, err := quotegetterdb.Create(cfg.Database)
+		if err != nil {
+			return err
+		}
+		db.Close()
+	}
+
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return err
+	}
+
+	// prints config file info
+	fmt.Fprintln(stdout, cfg.cfi)
+
+	d := daemon.New(cfg.jobs, func(ctx context.Context, job *daemon.Job) ([]*quotes.Result, error) {
+		return cfg.runJob(ctx, stdout, folder, job)
+	}, stdout)
+
+	mux := server.NewHandler(folder, false)
+	mux.HandleFunc("/health", d.HandleHealth)
+	mux.HandleFunc("/status", d.HandleStatus)
+	srv := &http.Server{Handler: mux}
+
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "server listening to %s\n", ln.Addr())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		err := srv.Serve(ln)
+		if err == http.ErrServerClosed {
+			err = nil
+		}
+		errc <- err
+		// a failure of the server stops the daemon
+		cancel()
+	}()
+
+	// wait for the running job, if any
+	d.Run(ctx)
+	fmt.Fprintln(stdout, "daemon stopped")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return <-errc
+}
+
+// sourceIsinsOf returns the source-isins of the isins.
+// If isins is empty, all the source-isins are returned.
+func sourceIsinsOf(sis []*quotes.SourceIsins, isins []string) []*quotes.SourceIsins {
+	if len(isins) == 0 {
+		return sis
+	}
+	set := newSet(isins)
+	var res []*quotes.SourceIsins
+	for _, si := range sis {
+		var a []string
+		for _, i := range si.Isins {
+			if set.has(i) {
+				a = append(a, i)
+			}
+		}
+		if len(a) > 0 {
+			c := *si
+			c.Isins = a
+			res = append(res, &c)
+		}
+	}
+	return res
+}
+
+// runJob retrieves the quotes of the isins of the job and saves them
+// in a json file of the folder and in the database, if defined.
+// Then it checks the alert rules of the isins.
+func (cfg *Config) runJob(ctx context.Context, w io.Writer, folder string, job *daemon.Job) ([]*quotes.Result, error) {
+	sis := sourceIsinsOf(cfg.SourceIsinsList(), job.Isins)
+
+	// use the info url of the last success saved in the database
+	if err := quotegetterdb.DBSetLastURLs(cfg.Database, sis); err != nil {
+		return nil, err
+	}
+
+	pruneCache(w, sis)
+
+	if cfg.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.deadline)
+		defer cancel()
+	}
+
+	timeStart := time.Now()
+	results, err := quotes.Get(ctx, cfg.availableSources(mAvailableSources), sis, cfg.taskengMode, nil)
+	if err != nil {
+		return nil, err
+	}
+	printInterrupted(ctx, w)
+	quotes.NewStats(results).Fprintln(w)
+
+	// compare the prices of the sources
+	quotes.Consensus(results, cfg.consensus)
+
+	// convert the prices to the reporting currency
+	if cfg.Currency != "" {
+		table, err := cfg.fxTable(ctx, w)
+		if err != nil {
+			return results, err
+		}
+		quotes.ConvertPrices(results, cfg.Currency, table)
+	}
+
+	// save the json file shown by the http server
+	name := "quotes-" + timeStart.Format("20060102-150405") + ".json"
+	if err := writeJSONFile(filepath.Join(folder, name), results, cfg); err != nil {
+		return results, err
+	}
+	fmt.Fprintf(w, "saved output file %q\n", filepath.Join(folder, name))
+
+	// save to database
+	if cfg.Database != "" {
+		run := cfg.newRun("daemon", cfg.Mode, timeStart)
+		if err := quotegetterdb.DBInsert(cfg.Database, run, results); err != nil {
+			return results, err
+		}
+		fmt.Fprintf(w, "saved database %q\n", quotegetterdb.Redacted(cfg.Database))
+	}
+
+	// check the alert rules of the isins
+	return results, cfg.notifyAlerts(w, job.Isins, results)
+}
+
+// writeJSONFile writes the results in the json output format.
+func writeJSONFile(path string, results []*quotes.Result, cfg *Config) error {
+	fout, err := createOutputFile(path, false)
+	if err != nil {
+		return err
+	}
+	err = export.Write(fout, results, export.JSON, cfg.exportOptions(export.JSON, nil))
+	if errClose := fout.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+func printDaemonDryRunInfo(w io.Writer, flags *Flags, cfg *Config) error {
+
+	fmt.Fprintf(w, "%s: Dry Run\n", flags.fullname)
+
+	// prints config file info
+	fmt.Fprintln(w, cfg.cfi)
+
+	if cfg.Database != "" {
+		fmt.Fprintf(w, "Database: %q\n", quotegetterdb.Redacted(cfg.Database))
+	}
+	address, folder := cfg.daemonAddress()
+	fmt.Fprintf(w, "Address: %q\n", address)
+	fmt.Fprintf(w, "Folder: %q\n", folder)
+
+	now := time.Now()
+	for _, job := range cfg.jobs {
+		isins := "all"
+		if len(job.Isins) > 0 {
+			isins = strings.Join(job.Isins, ",")
+		}
+		next := "never"
+		if t := job.Schedule.Next(now); !t.IsZero() {
+			next = t.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "Job %q: cron %q, timezone %s, isins %s, next run %s\n",
+			job.Name, job.Schedule, job.Schedule.Location(), isins, next)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemon(t *testing.T) {
+	folder := filepath.Join(t.TempDir(), "data")
+
+	cfgtxt := `
+isins:
+  isin1:
+  isin2:
+daemon:
+  schedules:
+    - name: close
+      cron: "30 18 * * 1-5"
+      timezone: Europe/Rome
+      isins: [isin1]
+    - cron: "0 0 30 2 *"
+`
+
+	run := func(ctx context.Context, argtxt string) (string, string, error) {
+		flags := NewFlags("app daemon", fgAppDaemon)
+		require.NoError(t, flags.Parse(strings.Fields(argtxt)))
+
+		var info strings.Builder
+		flags.flagSet.SetOutput(&info)
+
+		cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1"})
+		require.NoError(t, err)
+
+		var out strings.Builder
+		err = execDaemon(ctx, &out, flags, cfg)
+		return out.String(), info.String(), err
+	}
+
+	_, info, err := run(context.Background(), "-n --address 127.0.0.1:0 --folder "+folder)
+	require.NoError(t, err)
+	assert.Contains(t, info, `Address: "127.0.0.1:0"`)
+	assert.Contains(t, info, `Folder: "`+folder+`"`)
+	assert.Contains(t, info, `Job "close": cron "30 18 * * 1-5", timezone Europe/Rome, isins isin1, next run `)
+	assert.Contains(t, info, `Job "#2": cron "0 0 30 2 *", timezone Local, isins all, next run never`)
+
+	// the daemon is stopped by the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out, _, err := run(ctx, "--address 127.0.0.1:0 --folder "+folder)
+	require.NoError(t, err)
+	assert.Contains(t, out, "server listening to 127.0.0.1:")
+	assert.Contains(t, out, "daemon stopped")
+	assert.DirExists(t, folder)
+
+	cfgtxt = `
+isins:
+  isin1:
+daemon:
+  schedules:
+    - cron: "@daily"
+      isins: [isin1, isin9]
+`
+	_, _, err = run(context.Background(), "-n")
+	assert.EqualError(t, err, `daemon schedule "#1": isin "isin9" not defined or disabled`)
+
+	cfgtxt = `
+isins:
+  isin1:
+`
+	_, _, err = run(context.Background(), "-n")
+	assert.EqualError(t, err, "daemon schedules not defined")
+}
+
+func TestSourceIsinsOf(t *testing.T) {
+	sis := []*quotes.SourceIsins{
+		{Source: "source1", Workers: 2, Isins: []string{"isin1", "isin2"}},
+		{Source: "source2", Isins: []string{"isin2", "isin3"}},
+		{Source: "source3", Isins: []string{"isin3"}},
+	}
+
+	assert.Equal(t, sis, sourceIsinsOf(sis, nil))
+
+	got := sourceIsinsOf(sis, []string{"isin1", "isin3"})
+	assert.Equal(t, []*quotes.SourceIsins{
+		{Source: "source1", Workers: 2, Isins: []string{"isin1"}},
+		{Source: "source2", Isins: []string{"isin3"}},
+		{Source: "source3", Isins: []string{"isin3"}},
+	}, got)
+
+	// the source-isins are not modified
+	assert.Equal(t, []string{"isin1", "isin2"}, sis[0].Isins)
+
+	assert.Empty(t, sourceIsinsOf(sis, []string{"isin9"}))
+}
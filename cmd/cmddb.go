@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mmbros/quotes/internal/export"
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+const usageDB = `Usage:
+    %[1]s [options]
+    %[1]s <command> [options]
+
+Query the quotes saved in the database.
+The quotes are ordered by date and isin, then by timestamp descending.
+The errors, without date, are selected by the day of the request.
+
+Available Commands:
+    migrate        Migrate the database schema to the latest version
+
+Options:
+        --best                 select only the best quotes of the consensus of the sources
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+        --columns     strings  columns of the csv, tsv and table output formats
+                               (default isin,source,price,currency,date,status,error)
+    -d, --database    dns      database of the quotes (sqlite3 path or postgres url)
+    -f, --force       bool     overwrite already existing output file
+        --format      string   output format: table, json, jsonl, csv, tsv, ledger, beancount or gnucash
+                               (default guessed by the output file extension, or table)
+        --from        date     first date of the quotes (format %[2]s)
+    -i, --isins       strings  list of isins to select (default all)
+        --last                 select only the last quote of each isin and source
+    -o, --output      path     pathname of the output file (default stdout)
+    -s, --sources     strings  list of sources to select (default all)
+        --status      string   select only the quotes with status success or error (default all)
+        --to          date     last date of the quotes
+
+Examples:
+    # shows the last quote of each isin and source
+    %[1]s -d quotes.sqlite3 --last
+
+    # shows the errors of a source in october 2026
+    %[1]s -d quotes.sqlite3 -s morningstarit --status error --from 2026-10-01 --to 2026-10-31
+`
+
+func parseExecDB(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppDB)
+	flags.SetUsage(usageDB, fullname, layoutDate)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	return execDB(os.Stdout, flags, cfg)
+}
+
+// parseQuoteStatus parses the status of the quotes to select.
+func parseQuoteStatus(s string) (quotegetterdb.QuoteStatus, error) {
+	switch strings.ToLower(s) {
+	case "", "all":
+		return quotegetterdb.AnyStatus, nil
+	case "success":
+		return quotegetterdb.SuccessStatus, nil
+	case "error":
+		return quotegetterdb.ErrorStatus, nil
+	}
+	return quotegetterdb.AnyStatus, fmt.Errorf("invalid status %q: accepted values are success and error", s)
+}
+
+// execDB writes the quotes of the database selected by the flags
+// to the output file, or to stdout if the output file is not defined.
+func execDB(stdout io.Writer, flags *Flags, cfg *Config) error {
+
+	format, err := outputFormat(flags, export.Table)
+	if err != nil {
+		return err
+	}
+	if err := export.CheckColumns(flags.columns); err != nil {
+		return err
+	}
+	status, err := parseQuoteStatus(flags.status)
+	if err != nil {
+		return err
+	}
+	from, to, err := parseExportInterval(flags.from, flags.to)
+	if err != nil {
+		return err
+	}
+	if cfg.Database == "" {
+		return errors.New("database not defined")
+	}
+
+	// read the quotes
+	db, err := quotegetterdb.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	records, err := db.SelectQuotes(&quotegetterdb.QuoteFilter{
+		Isins:   flags.isins,
+		Sources: flags.sources,
+		From:    from,
+		To:      to,
+		Status:  status,
+		Last:    flags.last,
+		Best:    flags.best,
+	})
+	if err != nil {
+		return err
+	}
+	results := make([]*quotes.Result, 0, len(records))
+	for _, r := range records {
+		results = append(results, r.Result())
+	}
+
+	// handle the output
+	wOutput := stdout
+	if flags.output != "" {
+		fout, err := createOutputFile(flags.output, flags.force)
+		if err != nil {
+			return err
+		}
+		defer fout.Close()
+		wOutput = fout
+	}
+
+	if err := export.Write(wOutput, results, format, cfg.exportOptions(format, flags.columns)); err != nil {
+		return err
+	}
+
+	if flags.output != "" {
+		fmt.Fprintf(stdout, "saved output file %q (%d quotes)\n", flags.output, len(results))
+	}
+	return nil
+}
+
+const usageDBMigrate = `Usage:
+    %[1]s [options]
+
+Migrate the schema of the database to the latest version.
+A copy of the sqlite3 database is saved before the migrations.
+The other commands do not migrate the database: if there are pending
+migrations, they fail asking to run this command.
+
+Options:
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+    -d, --database    dns      database of the quotes (sqlite3 path or postgres url)
+    -n, --dry-run     bool     only show the pending migrations
+`
+
+func parseExecDBMigrate(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppDBMigrate)
+	flags.SetUsage(usageDBMigrate, fullname)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	return execDBMigrate(os.Stdout, flags, cfg)
+}
+
+// execDBMigrate shows and applies the pending migrations of the database.
+func execDBMigrate(stdout io.Writer, flags *Flags, cfg *Config) error {
+	if cfg.Database == "" {
+		return errors.New("database not defined")
+	}
+
+	db, err := quotegetterdb.OpenNoMigrate(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := db.Version()
+	if err != nil {
+		return err
+	}
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "database %q: schema version %d, latest version %d\n",
+		quotegetterdb.Redacted(cfg.Database), version, quotegetterdb.LatestVersion())
+	if len(pending) == 0 {
+		fmt.Fprintln(stdout, "no pending migrations")
+		return nil
+	}
+	fmt.Fprintln(stdout, "pending migrations:")
+	for _, m := range pending {
+		fmt.Fprintf(stdout, "  %3d  %s\n", m.Version, m.Description)
+	}
+	if flags.dryrun {
+		return nil
+	}
+
+	applied, backup, err := db.Migrate(true)
+	if backup != "" {
+		fmt.Fprintf(stdout, "saved backup %q\n", backup)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "migrated to version %d\n", applied[len(applied)-1].Version)
+	return nil
+}
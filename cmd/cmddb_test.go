@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	date := func(d, h int) time.Time { return time.Date(2026, time.October, d, h, 0, 0, 0, time.Local) }
+
+	db, err := quotegetterdb.Create(dbpath)
+	require.NoError(t, err)
+	err = db.InsertQuotesRecords(
+		&quotegetterdb.QuoteRecord{Isin: "IE00B4L5Y983", Source: "source1", Date: date(15, 0), Price: 77.5, Currency: "EUR", Timestamp: date(15, 18)},
+		&quotegetterdb.QuoteRecord{Isin: "IE00B4L5Y983", Source: "source1", Date: date(16, 0), Price: 78.12, Currency: "EUR", Timestamp: date(16, 18)},
+		&quotegetterdb.QuoteRecord{Isin: "IE00BK5BQT80", Source: "source1", Date: date(16, 0), Price: 101.5, Currency: "EUR", Timestamp: date(16, 18)},
+		&quotegetterdb.QuoteRecord{Isin: "IE00BK5BQT80", Source: "source2", Timestamp: date(16, 19), ErrMsg: "isin not found"},
+	)
+	db.Close()
+	require.NoError(t, err)
+
+	cfgtxt := `database: ` + dbpath
+
+	tests := map[string]struct {
+		argtxt string
+		want   string
+		errmsg string
+	}{
+		"all": {
+			argtxt: "--format csv --columns date,isin,source,price,status",
+			want: `date,isin,source,price,status
+2026-10-15,IE00B4L5Y983,source1,77.5,success
+2026-10-16,IE00B4L5Y983,source1,78.12,success
+,IE00BK5BQT80,source2,,error
+2026-10-16,IE00BK5BQT80,source1,101.5,success
+`,
+		},
+		"errors": {
+			argtxt: "--format csv --columns isin,source,error --status error",
+			want: `isin,source,error
+IE00BK5BQT80,source2,isin not found
+`,
+		},
+		"source last": {
+			argtxt: "--format csv --columns date,isin,price -s source1 --last",
+			want: `date,isin,price
+2026-10-16,IE00B4L5Y983,78.12
+2026-10-16,IE00BK5BQT80,101.5
+`,
+		},
+		"table": {
+			argtxt: "--columns isin,price --to 2026-10-15",
+			want: `┌──────────────┬───────┐
+│ ISIN         │ PRICE │
+├──────────────┼───────┤
+│ IE00B4L5Y983 │  77.5 │
+└──────────────┴───────┘
+`,
+		},
+		"invalid status": {
+			argtxt: "--status canceled",
+			errmsg: `invalid status "canceled"`,
+		},
+	}
+	for title, tt := range tests {
+		t.Run(title, func(t *testing.T) {
+			flags := NewFlags("app db", fgAppDB)
+			require.NoError(t, flags.Parse(strings.Split(tt.argtxt, " ")))
+
+			cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1", "source2"})
+			require.NoError(t, err)
+
+			var out strings.Builder
+			err = execDB(&out, flags, cfg)
+			if tt.errmsg != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.errmsg)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestDBMigrate(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+	cfgtxt := `database: ` + dbpath
+
+	run := func(argtxt string) string {
+		flags := NewFlags("app db migrate", fgAppDBMigrate)
+		require.NoError(t, flags.Parse(strings.Fields(argtxt)))
+
+		cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1"})
+		require.NoError(t, err)
+
+		var out strings.Builder
+		require.NoError(t, execDBMigrate(&out, flags, cfg))
+		return out.String()
+	}
+
+	latest := quotegetterdb.LatestVersion()
+
+	out := run("--dry-run")
+	assert.Contains(t, out, "schema version 0, latest version")
+	assert.Contains(t, out, "pending migrations:")
+	assert.NotContains(t, out, "migrated")
+
+	// the dry run does not modify the database
+	_, err := quotegetterdb.Open(dbpath)
+	assert.ErrorIs(t, err, quotegetterdb.ErrPendingMigrations)
+	out = run("--dry-run")
+	assert.Contains(t, out, "schema version 0, latest version")
+
+	out = run("")
+	assert.Contains(t, out, "pending migrations:")
+	// a new database is not saved
+	assert.NotContains(t, out, "saved backup")
+	assert.Contains(t, out, "migrated to version")
+
+	out = run("")
+	assert.Contains(t, out, "no pending migrations")
+	assert.Contains(t, out, fmt.Sprintf("schema version %d", latest))
+}
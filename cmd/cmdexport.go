@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mmbros/quotes/internal/export"
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+const usageExport = `Usage:
+    %[1]s [options]
+
+Export the successful quotes saved in the database.
+The ledger and beancount formats write a price directive for each isin and date,
+using the commodity symbol defined in the commodities section of the config file.
+The gnucash format writes a csv file for the GnuCash price importer,
+using the commodities defined in the gnucash section of the config file.
+
+Options:
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+        --columns     strings  columns of the csv, tsv and table output formats
+                               (default isin,source,price,currency,date,status,error)
+    -d, --database    dns      database of the quotes (sqlite3 path or postgres url)
+    -f, --force       bool     overwrite already existing output file
+        --format      string   output format: json, jsonl, csv, tsv, table, ledger, beancount or gnucash
+                               (default guessed by the output file extension, or ledger)
+        --from        date     first date of the quotes (format %[2]s)
+    -i, --isins       strings  list of isins to export (default all)
+        --last                 export only the last quote of each isin
+    -o, --output      path     pathname of the output file (default stdout)
+        --to          date     last date of the quotes
+
+Examples:
+    # appends the prices of october 2026 to the prices file of ledger
+    %[1]s -d quotes.sqlite3 --from 2026-10-01 --to 2026-10-31 >> prices.ledger
+
+    # exports all the prices in beancount format
+    %[1]s -d quotes.sqlite3 -o prices.beancount
+
+    # exports the last price of each isin for the GnuCash price importer
+    %[1]s -d quotes.sqlite3 --last --format gnucash -o prices.csv
+`
+
+func parseExecExport(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppExport)
+	flags.SetUsage(usageExport, fullname, layoutDate)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	return execExport(os.Stdout, flags, cfg)
+}
+
+// parseExportInterval parses the optional from and to dates of the export.
+func parseExportInterval(sFrom, sTo string) (from, to time.Time, err error) {
+	if sFrom != "" {
+		if from, err = time.ParseInLocation(layoutDate, sFrom, time.Local); err != nil {
+			err = fmt.Errorf("invalid --from date: %w", err)
+			return
+		}
+	}
+	if sTo != "" {
+		if to, err = time.ParseInLocation(layoutDate, sTo, time.Local); err != nil {
+			err = fmt.Errorf("invalid --to date: %w", err)
+			return
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		err = fmt.Errorf("--to date %s is before --from date %s", to.Format(layoutDate), from.Format(layoutDate))
+	}
+	return
+}
+
+// execExport writes the quotes of the database to the output file,
+// or to stdout if the output file is not defined.
+func execExport(stdout io.Writer, flags *Flags, cfg *Config) error {
+
+	format, err := outputFormat(flags, export.Ledger)
+	if err != nil {
+		return err
+	}
+	if err := export.CheckColumns(flags.columns); err != nil {
+		return err
+	}
+	from, to, err := parseExportInterval(flags.from, flags.to)
+	if err != nil {
+		return err
+	}
+	if cfg.Database == "" {
+		return errors.New("database not defined")
+	}
+
+	// read the quotes
+	db, err := quotegetterdb.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	records, err := db.SelectSuccessQuotes(flags.isins, from, to)
+	if err != nil {
+		return err
+	}
+	if flags.last {
+		records = quotegetterdb.LastQuotes(records)
+	}
+	results := make([]*quotes.Result, 0, len(records))
+	for _, r := range records {
+		results = append(results, r.Result())
+	}
+
+	// handle the output
+	wOutput := stdout
+	if flags.output != "" {
+		fout, err := createOutputFile(flags.output, flags.force)
+		if err != nil {
+			return err
+		}
+		defer fout.Close()
+		wOutput = fout
+	}
+
+	if err := export.Write(wOutput, results, format, cfg.exportOptions(format, flags.columns)); err != nil {
+		return err
+	}
+
+	if flags.output != "" {
+		fmt.Fprintf(stdout, "saved output file %q (%d quotes)\n", flags.output, len(results))
+	}
+	return nil
+}
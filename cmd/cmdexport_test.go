@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	date := func(d int) time.Time { return time.Date(2026, time.October, d, 0, 0, 0, 0, time.Local) }
+
+	db, err := quotegetterdb.Create(dbpath)
+	require.NoError(t, err)
+	err = db.InsertQuotesRecords(
+		&quotegetterdb.QuoteRecord{Isin: "IE00B4L5Y983", Source: "source1", Date: date(15), Price: 77.5, Currency: "EUR", Timestamp: date(15)},
+		&quotegetterdb.QuoteRecord{Isin: "IE00B4L5Y983", Source: "source1", Date: date(16), Price: 78.12, Currency: "EUR", Timestamp: date(16)},
+		&quotegetterdb.QuoteRecord{Isin: "IE00BK5BQT80", Source: "source1", Date: date(16), Price: 101.5, Currency: "EUR", Timestamp: date(16), Best: true},
+		// the last quote, but not the best one of the consensus
+		&quotegetterdb.QuoteRecord{Isin: "IE00BK5BQT80", Source: "source3", Date: date(16), Price: 109, Currency: "EUR", Timestamp: date(17)},
+		&quotegetterdb.QuoteRecord{Isin: "IE00BK5BQT80", Source: "source2", Timestamp: date(16), ErrMsg: "isin not found"},
+	)
+	db.Close()
+	require.NoError(t, err)
+
+	cfgtxt := `
+database: ` + dbpath + `
+commodities:
+  IE00BK5BQT80: VWCE
+gnucash:
+  namespace: ETF
+  commodities:
+    IE00B4L5Y983: XETRA:SWDA
+`
+
+	tests := map[string]struct {
+		argtxt string
+		want   string
+		errmsg string
+	}{
+		"ledger": {
+			argtxt: "--from 2026-10-16",
+			want: `P 2026-10-16 "IE00B4L5Y983" 78.12 EUR
+P 2026-10-16 VWCE 101.5 EUR
+`,
+		},
+		"beancount": {
+			argtxt: "--format beancount -i IE00B4L5Y983",
+			want: `2026-10-15 price IE00B4L5Y983 77.5 EUR
+2026-10-16 price IE00B4L5Y983 78.12 EUR
+`,
+		},
+		"csv": {
+			argtxt: "--format csv --columns date,isin,price --to 2026-10-15",
+			want: `date,isin,price
+2026-10-15,IE00B4L5Y983,77.5
+`,
+		},
+		"gnucash last": {
+			argtxt: "--format gnucash --last",
+			want: `date,namespace,symbol,currency,price
+2026-10-16,XETRA,SWDA,EUR,78.12
+2026-10-16,ETF,VWCE,EUR,101.5
+`,
+		},
+		"last to": {
+			argtxt: "--last --to 2026-10-15 --format beancount",
+			want: `2026-10-15 price IE00B4L5Y983 77.5 EUR
+`,
+		},
+		"invalid interval": {
+			argtxt: "--from 2026-10-16 --to 2026-10-15",
+			errmsg: "is before --from",
+		},
+		"invalid format": {
+			argtxt: "--format xml",
+			errmsg: `invalid format "xml"`,
+		},
+	}
+	for title, tt := range tests {
+		t.Run(title, func(t *testing.T) {
+			flags := NewFlags("app export", fgAppExport)
+			require.NoError(t, flags.Parse(strings.Split(tt.argtxt, " ")))
+
+			cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1", "source2"})
+			require.NoError(t, err)
+
+			var out strings.Builder
+			err = execExport(&out, flags, cfg)
+			if tt.errmsg != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.errmsg)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, out.String())
+			}
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/mmbros/quotes/internal/alert"
+	"github.com/mmbros/quotes/internal/export"
+	"github.com/mmbros/quotes/internal/fx"
+	"github.com/mmbros/quotes/internal/quotegetter"
 	"github.com/mmbros/quotes/internal/quotegetterdb"
 	"github.com/mmbros/quotes/internal/quotes"
 )
@@ -15,17 +23,26 @@ const usageGet = `Usage:
     %[1]s [options]
 
 Options:
+        --best                 output only the best quote of each isin (see consensus)
+        --cache-ttl   duration time to live of the cached http responses (0 disables the cache)
+        --columns     strings  columns of the csv, tsv and table output formats
+                               (default isin,source,price,currency,date,status,error)
     -c, --config      path     config file
         --config-type string   used if config file does not have the extension in the name;
                                accepted values are: YAML, TOML and JSON 
-    -d, --database    dns      sqlite3 database used to save the quotes
+        --currency    string   reporting currency of the converted prices (es. EUR)
+    -d, --database    dns      database used to save the quotes (sqlite3 path or postgres url)
+        --deadline    duration max duration of the whole run; the results retrieved so far are saved
     -f, --force       bool     overwrite already existing output file
+        --format      string   output format: json, jsonl, csv, tsv, table, ledger, beancount or gnucash
+                               (default guessed by the output file extension, or json)
     -i, --isins       strings  list of isins to get the quotes
     -m, --mode        char     result mode (default %[3]q): 
                                   "1" first success or last error
                                   "U" all errors until first success 
                                   "A" all 
     -n, --dry-run              perform a trial run with no request/updates made
+        --no-cache             do not use the cache of the http responses
     -o, --output      path     pathname of the output file (default stdout)
     -p, --proxy       url      default proxy
     -s, --sources     strings  list of sources to get the quotes from
@@ -35,6 +52,12 @@ Examples:
     # retrieves 2 isins from 3 sources: A with 4 workers, B and C with 2 workers each.
     quote get -i isin1,isin2 -s sourceA/4,sourceB, -s sourceC --workers 2
 
+    # saves the quotes in a csv file with the selected columns
+    quote get -o quotes.csv --columns isin,date,price,currency
+
+    # shows the best quote of each isin, and the consensus of the sources
+    quote get --best --format table --columns isin,source,price,date,consensus
+
 `
 
 func parseExecGet(fullname string, arguments []string) error {
@@ -67,6 +90,14 @@ func parseExecGet(fullname string, arguments []string) error {
 
 func execGet(flags *Flags, cfg *Config) error {
 
+	format, err := outputFormat(flags, export.JSON)
+	if err != nil {
+		return err
+	}
+	if err := export.CheckColumns(flags.columns); err != nil {
+		return err
+	}
+
 	if flags.dryrun {
 		return printDryRunInfo(flags.Output(), flags, cfg)
 	}
@@ -75,14 +106,7 @@ func execGet(flags *Flags, cfg *Config) error {
 	wInfo := os.Stdout
 	wOutput := os.Stdout // default
 	if flags.output != "" {
-		// overwrite existing file only if --force is specified
-		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
-		if !flags.force {
-			flag |= os.O_EXCL // file must not exists
-		}
-
-		// create the file
-		fout, err := os.OpenFile(flags.output, flag, 0666)
+		fout, err := createOutputFile(flags.output, flags.force)
 		if err != nil {
 			return err
 		}
@@ -95,10 +119,24 @@ func execGet(flags *Flags, cfg *Config) error {
 
 	// do retrieves the quotes
 	sis := cfg.SourceIsinsList()
-	results, err := quotes.Get(mAvailableSources, sis, cfg.taskengMode, wInfo)
+
+	// use the info url of the last success saved in the database,
+	// in order to skip the search step of the sources
+	if err := quotegetterdb.DBSetLastURLs(cfg.Database, sis); err != nil {
+		return err
+	}
+
+	pruneCache(wInfo, sis)
+
+	ctx, cancel := cfg.runContext()
+	defer cancel()
+
+	timeStart := time.Now()
+	results, err := quotes.Get(ctx, cfg.availableSources(mAvailableSources), sis, cfg.taskengMode, wInfo)
 	if err != nil {
 		return err
 	}
+	printInterrupted(ctx, wInfo)
 
 	// fmt.Fprintf(wInfo, "\n%d task completed (%d success, %d error) in %v\n",
 	// 	stats.TaskCompleted(),
@@ -112,12 +150,27 @@ func execGet(flags *Flags, cfg *Config) error {
 	stats := quotes.NewStats(results)
 	stats.Fprintln(wInfo)
 
-	// prints the results in json format
-	bytes, _ := json.MarshalIndent(results, "", "  ")
-	if err != nil {
+	// compare the prices of the sources
+	quotes.Consensus(results, cfg.consensus)
+
+	// convert the prices to the reporting currency
+	if cfg.Currency != "" {
+		table, err := cfg.fxTable(ctx, wInfo)
+		if err != nil {
+			return err
+		}
+		quotes.ConvertPrices(results, cfg.Currency, table)
+	}
+
+	output := results
+	if flags.best {
+		output = quotes.Best(results)
+	}
+
+	// prints the results in the output format
+	if err := export.Write(wOutput, output, format, cfg.exportOptions(format, flags.columns)); err != nil {
 		return err
 	}
-	wOutput.Write(bytes)
 
 	// print the output file path
 	if flags.output != "" {
@@ -126,15 +179,254 @@ func execGet(flags *Flags, cfg *Config) error {
 
 	// save to database
 	if cfg.Database != "" {
-		if err := quotegetterdb.DBInsert(cfg.Database, results); err != nil {
+		run := cfg.newRun("get", cfg.Mode, timeStart)
+		if err := quotegetterdb.DBInsert(cfg.Database, run, results); err != nil {
+			return err
+		}
+		fmt.Fprintf(wInfo, "saved database %q\n", quotegetterdb.Redacted(cfg.Database))
+	}
+
+	// check the alert rules of the isins
+	return cfg.notifyAlerts(wInfo, nil, results)
+}
+
+// notifyTimeout is the max duration of the notification of the alerts.
+const notifyTimeout = time.Minute
+
+// notifyAlerts checks the alert rules of the isins on their last quotes:
+// the ones saved in the database, if defined, otherwise the results of the run.
+// Without the database, only the rules of the isins of the run are checked,
+// if isins is not empty (es. the isins of a daemon job).
+// Only the alerts of the rules not triggered at the previous check are notified
+// (see alert.Changed): the triggered rules are saved in the database, if defined,
+// otherwise they are kept in memory for the next runs of the daemon.
+// The alerts are sent by all the notifiers, or printed if no notifier
+// is defined. The error of a notifier does not stop the other ones,
+// but the triggered rules are not saved, so the alerts are notified again.
+func (cfg *Config) notifyAlerts(w io.Writer, isins []string, results []*quotes.Result) error {
+	if cfg.Database != "" {
+		isins = nil
+	}
+	rules := cfg.alertRules(isins)
+	if len(rules) == 0 {
+		return nil
+	}
+	names := map[string]string{}
+	isins = make([]string, 0, len(rules))
+	for i := range rules {
+		isins = append(isins, i)
+		names[i] = cfg.Isins[i].Name
+	}
+	sort.Strings(isins)
+
+	var db *quotegetterdb.QuoteDatabase
+	if cfg.Database != "" {
+		var err error
+		if db, err = quotegetterdb.Open(cfg.Database); err != nil {
 			return err
 		}
-		fmt.Fprintf(wInfo, "saved database %q\n", cfg.Database)
+		defer db.Close()
+
+		records, err := db.SelectQuotes(&quotegetterdb.QuoteFilter{
+			Isins:  isins,
+			Status: quotegetterdb.SuccessStatus,
+		})
+		if err != nil {
+			return err
+		}
+		results = make([]*quotes.Result, len(records))
+		for j, r := range records {
+			results[j] = r.Result()
+		}
 	}
 
+	// the rules triggered at the previous check
+	previous := cfg.alertState
+	if db != nil {
+		records, err := db.SelectAlerts(isins...)
+		if err != nil {
+			return err
+		}
+		previous = map[alert.Key]bool{}
+		for _, r := range records {
+			previous[alert.Key{Isin: r.Isin, Rule: r.Rule}] = true
+		}
+	}
+
+	now := time.Now()
+	alerts := alert.Evaluate(rules, alert.NewStates(results), names, now)
+
+	if changed := alert.Changed(alerts, previous); len(changed) > 0 {
+		notifiers := cfg.notifiers
+		if len(notifiers) == 0 {
+			notifiers = []alert.Notifier{alert.NewWriter(w)}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+
+		var errs []string
+		for j, n := range notifiers {
+			if err := n.Notify(ctx, changed); err != nil {
+				errs = append(errs, fmt.Sprintf("notifier #%d: %v", j+1, err))
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("alerts not notified: %s", strings.Join(errs, "; "))
+		}
+		fmt.Fprintf(w, "alerts notified: %d\n", len(changed))
+	}
+
+	// save the triggered rules
+	if db != nil {
+		records := make([]*quotegetterdb.AlertRecord, len(alerts))
+		for j, a := range alerts {
+			records[j] = &quotegetterdb.AlertRecord{Isin: a.Isin, Rule: a.Rule, Notified: now}
+		}
+		return db.SetAlerts(isins, records)
+	}
+	if cfg.alertState == nil {
+		cfg.alertState = map[alert.Key]bool{}
+	}
+	for _, i := range isins {
+		for k := range cfg.alertState {
+			if k.Isin == i {
+				delete(cfg.alertState, k)
+			}
+		}
+	}
+	for _, a := range alerts {
+		cfg.alertState[a.Key()] = true
+	}
 	return nil
 }
 
+// outputFormat returns the format of the output:
+// the --format argument, if passed, otherwise the format
+// guessed by the extension of the output file, otherwise the default format.
+func outputFormat(flags *Flags, defaultFormat export.Format) (export.Format, error) {
+	if flags.format != "" {
+		return export.ParseFormat(flags.format)
+	}
+	if f, ok := export.FormatFromPath(flags.output); ok {
+		return f, nil
+	}
+	return defaultFormat, nil
+}
+
+// runContext returns the context of the run.
+// The context is canceled by SIGINT (Ctrl-C) or when the deadline of the
+// config, if any, is exceeded: the pending requests are canceled
+// and the results retrieved so far are saved.
+// After the first SIGINT, the default behavior is restored,
+// so that a second Ctrl-C terminates the program.
+func (cfg *Config) runContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if cfg.deadline <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.deadline)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// newRun returns the run of the command, saved in the database
+// with its results. The end time of the run is the end time
+// of the last result.
+func (cfg *Config) newRun(command, mode string, timeStart time.Time) *quotegetterdb.Run {
+	run := &quotegetterdb.Run{
+		TimeStart: timeStart,
+		Command:   command,
+		Mode:      mode,
+	}
+	if cfg.cfi != nil {
+		run.Config = cfg.cfi.Path()
+	}
+	run.Host, _ = os.Hostname()
+	return run
+}
+
+// fxTable returns the exchange rates used to convert the prices to the
+// reporting currency: the rates retrieved by the fx source, together with
+// the rates saved in the database, if defined. The retrieved rates are saved
+// in the database. If the rates can't be retrieved, a message is printed
+// and only the saved rates are used. The rates are not retrieved if the
+// saved ones already include the rates of today.
+// The request of the rates is canceled by ctx.
+func (cfg *Config) fxTable(ctx context.Context, w io.Writer) (*fx.Table, error) {
+	table := fx.NewTable()
+
+	var db *quotegetterdb.QuoteDatabase
+	if cfg.Database != "" {
+		var err error
+		if db, err = quotegetterdb.Create(cfg.Database); err != nil {
+			return nil, err
+		}
+		defer db.Close()
+
+		saved, err := db.SelectRates()
+		if err != nil {
+			return nil, err
+		}
+		table.Add(saved...)
+
+		today := time.Now().Format("2006-01-02")
+		for _, r := range saved {
+			if r.Date.Format("2006-01-02") == today {
+				return table, nil
+			}
+		}
+	}
+
+	client, err := quotegetter.DefaultClient(cfg.FX.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	src, err := fx.NewSource(cfg.FX.Source, cfg.FX.URL, client)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := src.GetRates(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "exchange rates not retrieved: %v\n", err)
+		return table, nil
+	}
+	table.Add(rates...)
+
+	if db != nil {
+		if err := db.InsertRates(src.Name(), rates...); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// printInterrupted prints a message if the run was interrupted.
+func printInterrupted(ctx context.Context, w io.Writer) {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		fmt.Fprintln(w, "deadline exceeded: saving partial results")
+	case context.Canceled:
+		fmt.Fprintln(w, "interrupted: saving partial results")
+	}
+}
+
+// createOutputFile creates the output file.
+// An already existing file is overwritten only if force is true.
+func createOutputFile(path string, force bool) (*os.File, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flag |= os.O_EXCL // file must not exists
+	}
+	return os.OpenFile(path, flag, 0666)
+}
+
 func printDryRunInfo(w io.Writer, flags *Flags, cfg *Config) error {
 
 	fmt.Fprintf(w, "%s: Dry Run\n", flags.fullname)
@@ -143,7 +435,7 @@ func printDryRunInfo(w io.Writer, flags *Flags, cfg *Config) error {
 	fmt.Fprintln(w, cfg.cfi)
 
 	if cfg.Database != "" {
-		fmt.Fprintf(w, "Database: %q\n", cfg.Database)
+		fmt.Fprintf(w, "Database: %q\n", quotegetterdb.Redacted(cfg.Database))
 	}
 	fmt.Fprintf(w, "Mode: %q\n", cfg.Mode)
 	sis := cfg.SourceIsinsList()
@@ -55,3 +55,37 @@ func Test_GetDryRun(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetFormat(t *testing.T) {
+
+	tests := map[string]struct {
+		cmdline string
+		want    string
+	}{
+		"invalid format": {
+			cmdline: "app get -n --format xml",
+			want:    `invalid format "xml"`,
+		},
+		"invalid column": {
+			cmdline: "app get -n --format csv --columns isin,foo",
+			want:    `invalid column "foo"`,
+		},
+		"valid format": {
+			cmdline: "app get -n --format tsv --columns isin,price",
+			want:    `Mode: "A"`,
+		},
+	}
+	for title, tt := range tests {
+
+		t.Run(title, func(t *testing.T) {
+
+			var out strings.Builder
+			flag.CommandLine.SetOutput(&out)
+
+			os.Args = strings.Split(tt.cmdline, " ")
+			cmd.Execute(&out)
+
+			assert.Contains(t, out.String(), tt.want)
+		})
+	}
+}
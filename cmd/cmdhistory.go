@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+const usageHistory = `Usage:
+    %[1]s [options]
+
+Get the price history of the specified isins.
+Only the sources that expose the price history are used.
+For each isin, the sources are tried in the order of the config file,
+with at most workers requests at a time for each source.
+
+Options:
+        --cache-ttl   duration time to live of the cached http responses (0 disables the cache)
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+    -d, --database    dns      database used to save the quotes (sqlite3 path or postgres url)
+        --deadline    duration max duration of the whole run; the results retrieved so far are saved
+    -f, --force       bool     overwrite already existing output file
+        --from        date     first date of the history (format %[2]s)
+    -i, --isins       strings  list of isins to get the history
+    -n, --dry-run              perform a trial run with no request/updates made
+        --no-cache             do not use the cache of the http responses
+    -o, --output      path     pathname of the output file (default stdout)
+    -p, --proxy       url      default proxy
+    -s, --sources     strings  list of sources to get the history from
+        --to          date     last date of the history (default today)
+
+Examples:
+    # retrieves the quotes of january 2021 and saves them to the database
+    %[1]s -i isin1 --from 2021-01-01 --to 2021-01-31 -d quotes.sqlite3
+`
+
+// layout of the from and to dates
+const layoutDate = "2006-01-02"
+
+func parseExecHistory(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppHistory)
+	flags.SetUsage(usageHistory, fullname, layoutDate)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	return execHistory(flags, cfg)
+}
+
+// parseHistoryInterval parses the from and to dates of the history.
+// from is mandatory, to defaults to today.
+func parseHistoryInterval(sFrom, sTo string, now time.Time) (from, to time.Time, err error) {
+	if sFrom == "" {
+		err = errors.New("missing --from date")
+		return
+	}
+	from, err = time.ParseInLocation(layoutDate, sFrom, time.Local)
+	if err != nil {
+		err = fmt.Errorf("invalid --from date: %w", err)
+		return
+	}
+	if sTo == "" {
+		y, m, d := now.Date()
+		to = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	} else {
+		to, err = time.ParseInLocation(layoutDate, sTo, time.Local)
+		if err != nil {
+			err = fmt.Errorf("invalid --to date: %w", err)
+			return
+		}
+	}
+	if to.Before(from) {
+		err = fmt.Errorf("--to date %s is before --from date %s", to.Format(layoutDate), from.Format(layoutDate))
+	}
+	return
+}
+
+func execHistory(flags *Flags, cfg *Config) error {
+
+	from, to, err := parseHistoryInterval(flags.from, flags.to, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if flags.dryrun {
+		return printDryRunHistoryInfo(flags.Output(), flags, cfg, from, to)
+	}
+
+	// handle the output
+	wInfo := os.Stdout
+	wOutput := os.Stdout // default
+	if flags.output != "" {
+		fout, err := createOutputFile(flags.output, flags.force)
+		if err != nil {
+			return err
+		}
+		defer fout.Close()
+		wOutput = fout
+	}
+
+	// prints config file info
+	fmt.Fprintln(wInfo, cfg.cfi)
+
+	// do retrieves the history
+	sis := cfg.SourceIsinsList()
+
+	// use the info url of the last success saved in the database
+	if err := quotegetterdb.DBSetLastURLs(cfg.Database, sis); err != nil {
+		return err
+	}
+
+	pruneCache(wInfo, sis)
+	ctx, cancel := cfg.runContext()
+	defer cancel()
+
+	timeStart := time.Now()
+	results, err := quotes.GetHistory(ctx, cfg.availableSources(mAvailableSources), sis, cfg.isinSources(), from, to)
+	if err != nil {
+		return err
+	}
+	printInterrupted(ctx, wInfo)
+
+	stats := quotes.NewStats(results)
+	stats.Fprintln(wInfo)
+
+	// prints the results in json format
+	bytes, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	wOutput.Write(bytes)
+
+	// print the output file path
+	if flags.output != "" {
+		fmt.Fprintf(wInfo, "saved output file %q\n", flags.output)
+	}
+
+	// save to database
+	if cfg.Database != "" {
+		run := cfg.newRun("history", "", timeStart)
+		if err := quotegetterdb.DBInsert(cfg.Database, run, results); err != nil {
+			return err
+		}
+		fmt.Fprintf(wInfo, "saved database %q\n", quotegetterdb.Redacted(cfg.Database))
+	}
+
+	return nil
+}
+
+func printDryRunHistoryInfo(w io.Writer, flags *Flags, cfg *Config, from, to time.Time) error {
+	fmt.Fprintf(w, "%s: Dry Run\n", flags.fullname)
+
+	// prints config file info
+	fmt.Fprintln(w, cfg.cfi)
+
+	if cfg.Database != "" {
+		fmt.Fprintf(w, "Database: %q\n", quotegetterdb.Redacted(cfg.Database))
+	}
+	fmt.Fprintf(w, "From: %s\n", from.Format(layoutDate))
+	fmt.Fprintf(w, "To: %s\n", to.Format(layoutDate))
+	sis := cfg.SourceIsinsList()
+	fmt.Fprint(w, "Tasks: ", jsonString(sis))
+	return nil
+}
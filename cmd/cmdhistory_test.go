@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseHistoryInterval(t *testing.T) {
+	now := time.Date(2021, 2, 15, 18, 30, 0, 0, time.Local)
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+
+	tests := map[string]struct {
+		from     string
+		to       string
+		wantFrom time.Time
+		wantTo   time.Time
+		errmsg   string
+	}{
+		"ok": {
+			from:     "2021-01-01",
+			to:       "2021-01-31",
+			wantFrom: date(2021, 1, 1),
+			wantTo:   date(2021, 1, 31),
+		},
+		"default to": {
+			from:     "2021-01-01",
+			wantFrom: date(2021, 1, 1),
+			wantTo:   date(2021, 2, 15),
+		},
+		"missing from": {
+			to:     "2021-01-31",
+			errmsg: "missing --from",
+		},
+		"invalid from": {
+			from:   "01/01/2021",
+			errmsg: "invalid --from",
+		},
+		"invalid to": {
+			from:   "2021-01-01",
+			to:     "2021-31-01",
+			errmsg: "invalid --to",
+		},
+		"to before from": {
+			from:   "2021-01-31",
+			to:     "2021-01-01",
+			errmsg: "is before --from",
+		},
+	}
+
+	for title, tt := range tests {
+		t.Run(title, func(t *testing.T) {
+			from, to, err := parseHistoryInterval(tt.from, tt.to, now)
+			if tt.errmsg != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.errmsg)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantFrom, from)
+				assert.Equal(t, tt.wantTo, to)
+			}
+		})
+	}
+}
+
+func Test_HistoryDryRun(t *testing.T) {
+	var out strings.Builder
+	flag.CommandLine.SetOutput(&out)
+
+	os.Args = strings.Split("app history -n -i isin1 -s morningstarit --from 2021-01-01 --to 2021-01-31", " ")
+	code := Execute(&out)
+
+	assert.Equal(t, 0, code, out.String())
+	assert.Contains(t, out.String(), "From: 2021-01-01")
+	assert.Contains(t, out.String(), "To: 2021-01-31")
+	assert.Contains(t, out.String(), "morningstarit")
+}
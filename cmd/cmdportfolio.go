@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mmbros/quotes/internal/fx"
+	"github.com/mmbros/quotes/internal/portfolio"
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+const usagePortfolio = `Usage:
+    %[1]s [options]
+
+Show the valuation of the holdings of the portfolio defined in the config file:
+for each holding, the market value, the unrealized gain or loss and the weight;
+the totals of each account and of the whole portfolio.
+The last quotes of the isins are loaded from the database or, with --fetch,
+retrieved from the sources (and saved in the database, if defined).
+
+Options:
+        --cache-ttl   duration time to live of the cached http responses (0 disables the cache)
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+        --currency    string   reporting currency of the valuation (es. EUR)
+    -d, --database    dns      database of the quotes (sqlite3 path or postgres url)
+        --deadline    duration max duration of the retrieval of the quotes
+        --fetch                retrieve the quotes of the isins of the holdings
+    -f, --force       bool     overwrite already existing output file
+        --format      string   output format: json, csv or table
+                               (default guessed by the output file extension, or table)
+        --no-cache             do not use the cache of the http responses
+    -o, --output      path     pathname of the output file (default stdout)
+    -p, --proxy       url      default proxy
+
+Examples:
+    # shows the valuation from the quotes saved in the database
+    %[1]s -d quotes.sqlite3
+
+    # retrieves the quotes and saves the valuation in euro in a csv file
+    %[1]s -d quotes.sqlite3 --fetch --currency EUR -o portfolio.csv
+`
+
+func parseExecPortfolio(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppPortfolio)
+	flags.SetUsage(usagePortfolio, fullname)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	return execPortfolio(os.Stdout, flags, cfg)
+}
+
+// portfolioFormat returns the format of the output:
+// the --format argument, if passed, otherwise the format
+// guessed by the extension of the output file, otherwise the table format.
+func portfolioFormat(flags *Flags) (portfolio.Format, error) {
+	if flags.format != "" {
+		return portfolio.ParseFormat(flags.format)
+	}
+	if f, ok := portfolio.FormatFromPath(flags.output); ok {
+		return f, nil
+	}
+	return portfolio.Table, nil
+}
+
+// execPortfolio writes the valuation of the holdings of the portfolio.
+func execPortfolio(stdout io.Writer, flags *Flags, cfg *Config) error {
+	format, err := portfolioFormat(flags)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Portfolio) == 0 {
+		return errors.New("portfolio not defined")
+	}
+	if cfg.Database == "" && !flags.fetch {
+		return errors.New("database not defined: use --fetch to retrieve the quotes")
+	}
+
+	// handle the output
+	wInfo := stdout
+	wOutput := stdout // default
+	if flags.output != "" {
+		fout, err := createOutputFile(flags.output, flags.force)
+		if err != nil {
+			return err
+		}
+		defer fout.Close()
+		wOutput = fout
+	}
+
+	var results []*quotes.Result
+	if flags.fetch {
+		if results, err = cfg.fetchPortfolio(wInfo); err != nil {
+			return err
+		}
+	}
+
+	// the last quotes saved in the database, fetched ones included
+	if cfg.Database != "" {
+		db, err := quotegetterdb.Open(cfg.Database)
+		if err != nil {
+			return err
+		}
+		records, err := db.SelectLastQuotes(cfg.portfolioIsins()...)
+		db.Close()
+		if err != nil {
+			return err
+		}
+		results = make([]*quotes.Result, len(records))
+		for j, r := range records {
+			results[j] = r.Result()
+		}
+	}
+
+	// convert the prices to the reporting currency
+	var table *fx.Table
+	if cfg.Currency != "" {
+		ctx, cancel := cfg.runContext()
+		table, err = cfg.fxTable(ctx, wInfo)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	p, err := portfolio.New(cfg.holdings(), results, cfg.Currency, table)
+	if err != nil {
+		return err
+	}
+	if err := p.Write(wOutput, format); err != nil {
+		return err
+	}
+
+	// print the output file path
+	if flags.output != "" {
+		fmt.Fprintf(wInfo, "saved output file %q\n", flags.output)
+	}
+	return nil
+}
+
+// fetchPortfolio retrieves the quotes of the isins of the holdings
+// and saves them in the database, if defined.
+func (cfg *Config) fetchPortfolio(wInfo io.Writer) ([]*quotes.Result, error) {
+	// prints config file info
+	fmt.Fprintln(wInfo, cfg.cfi)
+
+	sis := cfg.SourceIsinsList()
+	if err := quotegetterdb.DBSetLastURLs(cfg.Database, sis); err != nil {
+		return nil, err
+	}
+
+	pruneCache(wInfo, sis)
+
+	ctx, cancel := cfg.runContext()
+	defer cancel()
+
+	timeStart := time.Now()
+	results, err := quotes.Get(ctx, cfg.availableSources(mAvailableSources), sis, cfg.taskengMode, wInfo)
+	if err != nil {
+		return nil, err
+	}
+	printInterrupted(ctx, wInfo)
+	quotes.NewStats(results).Fprintln(wInfo)
+
+	// compare the prices of the sources
+	quotes.Consensus(results, cfg.consensus)
+
+	// save to database
+	if cfg.Database != "" {
+		run := cfg.newRun("portfolio", cfg.Mode, timeStart)
+		if err := quotegetterdb.DBInsert(cfg.Database, run, results); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(wInfo, "saved database %q\n", quotegetterdb.Redacted(cfg.Database))
+	}
+	return results, nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortfolio(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	result := func(isin, source string, day int, price float64) *quotes.Result {
+		date := time.Date(2026, time.October, day, 0, 0, 0, 0, time.Local)
+		return &quotes.Result{
+			Isin:     isin,
+			Source:   source,
+			Date:     &date,
+			Price:    price,
+			Currency: "EUR",
+		}
+	}
+
+	db, err := quotegetterdb.Create(dbpath)
+	require.NoError(t, err)
+	err = db.InsertRun(&quotegetterdb.Run{Command: "get"}, []*quotes.Result{
+		result("IE00B4L5Y983", "source1", 15, 90),
+		result("IE00B4L5Y983", "source1", 16, 100),
+		result("IE00BK5BQT80", "source2", 16, 20),
+	})
+	db.Close()
+	require.NoError(t, err)
+
+	cfgtxt := `
+database: ` + dbpath + `
+isins:
+  IE00B4L5Y983:
+    name: World
+portfolio:
+  - isin: IE00B4L5Y983
+    quantity: 10
+    cost: 800
+    account: broker
+  - isin: IE00BK5BQT80
+    quantity: 10
+    account: pension
+`
+
+	run := func(argtxt string) (string, error) {
+		flags := NewFlags("app portfolio", fgAppPortfolio)
+		require.NoError(t, flags.Parse(strings.Fields(argtxt)))
+
+		cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1", "source2"})
+		require.NoError(t, err)
+
+		var out strings.Builder
+		err = execPortfolio(&out, flags, cfg)
+		return out.String(), err
+	}
+
+	out, err := run("--format csv")
+	require.NoError(t, err)
+	assert.Equal(t, `account,isin,name,quantity,price,price_currency,date,source,value,cost,gain,gain_pct,weight,currency,error
+broker,IE00B4L5Y983,World,10,100,EUR,2026-10-16,source1,1000.00,800.00,200.00,0.2500,0.8333,EUR,
+pension,IE00BK5BQT80,,10,20,EUR,2026-10-16,source2,200.00,,,,0.1667,EUR,
+broker,,,,,,,,1000.00,800.00,200.00,0.2500,0.8333,EUR,
+pension,,,,,,,,200.00,,,,0.1667,EUR,
+,,,,,,,,1200.00,800.00,200.00,0.2500,1.0000,EUR,
+`, out)
+
+	out, err = run("")
+	require.NoError(t, err)
+	assert.Contains(t, out, "TOTAL EUR")
+
+	_, err = run("--format ledger")
+	assert.Error(t, err)
+}
+
+func TestPortfolioConfig(t *testing.T) {
+	cases := map[string]struct {
+		argtxt string
+		cfgtxt string
+		isins  []string
+		errmsg string
+	}{
+		"fetch": {
+			argtxt: "--fetch",
+			cfgtxt: `
+isins:
+  isin1:
+  isin2:
+    disabled: true
+  isin3:
+portfolio:
+  - isin: isin2
+    quantity: 1
+  - isin: isin4
+    quantity: 1
+`,
+			isins: []string{"isin2", "isin4"},
+		},
+		"no fetch": {
+			cfgtxt: `
+isins:
+  isin1:
+portfolio:
+  - isin: isin4
+    quantity: 1
+`,
+			isins: []string{"isin1"},
+		},
+		"no isin": {
+			cfgtxt: `
+portfolio:
+  - quantity: 1
+`,
+			errmsg: "portfolio holding #1: isin not defined",
+		},
+		"quantity": {
+			cfgtxt: `
+portfolio:
+  - isin: isin1
+`,
+			errmsg: `portfolio holding "isin1": quantity must be greater than zero (quantity=0)`,
+		},
+		"cost": {
+			cfgtxt: `
+portfolio:
+  - isin: isin1
+    quantity: 1
+    cost: -1
+`,
+			errmsg: `portfolio holding "isin1": cost must not be negative (cost=-1)`,
+		},
+	}
+
+	for title, c := range cases {
+		flags := NewFlags("app portfolio", fgAppPortfolio)
+		require.NoError(t, flags.Parse(strings.Fields(c.argtxt)), title)
+
+		cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, []string{"source1"})
+		if c.errmsg != "" {
+			assert.EqualError(t, err, c.errmsg, title)
+			continue
+		}
+		require.NoError(t, err, title)
+
+		isins := make([]string, 0, len(cfg.Isins))
+		for i := range cfg.Isins {
+			isins = append(isins, i)
+		}
+		assert.ElementsMatch(t, c.isins, isins, title)
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/report"
+)
+
+const usageReport = `Usage:
+    %[1]s <command> [options]
+
+Available Commands:
+    sources        Reliability of the sources from the quotes saved in the database
+
+Common options:
+    -h, --help     Help informations
+`
+
+const usageReportSources = `Usage:
+    %[1]s [options]
+
+Show the reliability of the sources from the quotes saved in the database
+by the get command: for each source, the success rate, the median and p95
+latency of the requests, the most common error type and the number of isins
+with at least a successful quote; for each isin and source, the number of
+successful and total requests.
+With --suggest, the isins section of the config file is printed instead,
+with the sources of each isin ordered by success rate and median latency.
+
+Options:
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON
+    -d, --database    dns      database of the quotes (sqlite3 path or postgres url)
+        --from        date     first date of the requests (format %[2]s)
+    -i, --isins       strings  list of isins to select (default all)
+    -s, --sources     strings  list of sources to select (default all)
+        --suggest              print the suggested sources of the isins
+        --to          date     last date of the requests
+
+Examples:
+    # shows the reliability of the sources in the last month
+    %[1]s -d quotes.sqlite3 --from 2026-09-17
+
+    # prints the suggested sources of the isins
+    %[1]s -d quotes.sqlite3 --suggest
+`
+
+func parseExecReport(fullname string, arguments []string) error {
+	// parse the arguments
+	flags := NewFlags(fullname, fgApp)
+	flags.SetUsage(usageReport, fullname)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == nil {
+		// show usage
+		flags.Usage()
+	}
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		err = nil
+	}
+
+	return err
+}
+
+func parseExecReportSources(fullname string, arguments []string) error {
+	var cfg *Config
+
+	// parse the arguments
+	flags := NewFlags(fullname, fgAppReport)
+	flags.SetUsage(usageReportSources, fullname, layoutDate)
+
+	err := flags.Parse(arguments)
+
+	// handle help
+	if err == flag.ErrHelp {
+		// clear error
+		// note: usage already showed internally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// get configuration
+	cfg, err = getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	return execReportSources(os.Stdout, flags, cfg)
+}
+
+// execReportSources writes the reliability report of the sources.
+// Only the quotes of the get command are used: the quotes of the history
+// command are many for each request.
+func execReportSources(stdout io.Writer, flags *Flags, cfg *Config) error {
+	from, to, err := parseExportInterval(flags.from, flags.to)
+	if err != nil {
+		return err
+	}
+	if cfg.Database == "" {
+		return errors.New("database not defined")
+	}
+
+	db, err := quotegetterdb.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	runs, err := db.SelectRuns(time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	historyRuns := map[int64]bool{}
+	for _, run := range runs {
+		if run.Command == "history" {
+			historyRuns[run.ID] = true
+		}
+	}
+
+	// NOTE: the errors are selected by the day of the request
+	records, err := db.SelectQuotes(&quotegetterdb.QuoteFilter{
+		Isins:   flags.isins,
+		Sources: flags.sources,
+		From:    from,
+		To:      to,
+	})
+	if err != nil {
+		return err
+	}
+	filtered := records[:0]
+	for _, r := range records {
+		if !historyRuns[r.RunID] {
+			filtered = append(filtered, r)
+		}
+	}
+
+	rep := report.New(filtered)
+	if flags.suggest {
+		return rep.WriteSuggestion(stdout)
+	}
+	if len(rep.Sources) == 0 {
+		fmt.Fprintln(stdout, "no quotes found")
+		return nil
+	}
+	if err := rep.WriteSources(stdout); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout)
+	return rep.WriteCoverage(stdout)
+}
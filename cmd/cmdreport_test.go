@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportSources(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	t0 := time.Date(2026, time.October, 16, 18, 0, 0, 0, time.Local)
+	result := func(isin, source string, ms int, fail bool) *quotes.Result {
+		r := &quotes.Result{
+			Isin:      isin,
+			Source:    source,
+			TimeStart: t0,
+			TimeEnd:   t0.Add(time.Duration(ms) * time.Millisecond),
+		}
+		if fail {
+			r.Err = errors.New("isin not found")
+		} else {
+			date := t0
+			r.Date = &date
+			r.Price = 10
+			r.Currency = "EUR"
+		}
+		return r
+	}
+
+	db, err := quotegetterdb.Create(dbpath)
+	require.NoError(t, err)
+	err = db.InsertRun(&quotegetterdb.Run{Command: "get"}, []*quotes.Result{
+		result("IE00B4L5Y983", "source1", 2000, false),
+		result("IE00B4L5Y983", "source2", 500, false),
+		result("IE00BK5BQT80", "source1", 1000, false),
+		result("IE00BK5BQT80", "source2", 500, true),
+	})
+	require.NoError(t, err)
+	// the quotes of the history runs are not used
+	err = db.InsertRun(&quotegetterdb.Run{Command: "history"}, []*quotes.Result{
+		result("LU0000000001", "source2", 100, false),
+	})
+	db.Close()
+	require.NoError(t, err)
+
+	cfgtxt := `database: ` + dbpath
+
+	run := func(argtxt string) string {
+		flags := NewFlags("app report sources", fgAppReport)
+		require.NoError(t, flags.Parse(strings.Fields(argtxt)))
+
+		cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1", "source2"})
+		require.NoError(t, err)
+
+		var out strings.Builder
+		require.NoError(t, execReportSources(&out, flags, cfg))
+		return out.String()
+	}
+
+	suggestion := run("--suggest")
+	assert.Equal(t, `isins:
+  IE00B4L5Y983:
+    sources: [source2, source1]
+  IE00BK5BQT80:
+    sources: [source1, source2]
+`, suggestion)
+
+	// the suggestion can be pasted in the config file
+	flags, err := initAppGetFlags("")
+	require.NoError(t, err)
+	cfg, err := auxNewConfig([]byte(suggestion), nil, flags, []string{"source1", "source2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"source2", "source1"}, cfg.Isins["IE00B4L5Y983"].Sources)
+
+	out := run("-s source2")
+	assert.Contains(t, out, "│ source2 │        2 │   50.0% │")
+	assert.NotContains(t, out, "source1")
+	assert.NotContains(t, out, "LU0000000001")
+
+	assert.Equal(t, "no quotes found\n", run("--to 2026-10-15"))
+}
@@ -5,12 +5,20 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 )
 
 const usageSources = `Usage:
-    %s
+    %s [options]
 
-Prints list of available sources.
+Prints list of available sources:
+the built-in sources and the ones defined in the scrapers section of the config file.
+
+Options:
+    -c, --config      path     config file
+        --config-type string   used if config file does not have the extension in the name;
+                               accepted values are: YAML, TOML and JSON 
 `
 
 func parseExecSources(fullname string, arguments []string) error {
@@ -31,10 +39,24 @@ func parseExecSources(fullname string, arguments []string) error {
 		return err
 	}
 
-	execSources(os.Stdout)
+	// get configuration
+	cfg, err := getConfig(flags, mAvailableSources.Names())
+	if err != nil {
+		return err
+	}
+
+	execSources(os.Stdout, cfg)
 	return nil
 }
 
-func execSources(w io.Writer) {
+// execSources prints the built-in sources and,
+// if any, the sources defined in the config.
+func execSources(w io.Writer, cfg *Config) {
 	fmt.Fprintln(w, mAvailableSources)
+
+	if cfg != nil && len(cfg.scraperSources) > 0 {
+		names := cfg.scraperSources.Names()
+		sort.Strings(names)
+		fmt.Fprintf(w, "defined in config file: %s\n", strings.Join(names, ", "))
+	}
 }
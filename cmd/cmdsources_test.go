@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
 )
 
 func Test_parseExecSources(t *testing.T) {
@@ -28,16 +30,21 @@ func Test_parseExecSources(t *testing.T) {
 }
 
 func Test_execSources(t *testing.T) {
+	cfgScrapers := &Config{
+		scraperSources: quotegetter.Sources{"myscraper2": nil, "myscraper1": nil},
+	}
 	tests := []struct {
 		name  string
+		cfg   *Config
 		wantW string
 	}{
-		{"contains", "morningstarit"},
+		{"contains", nil, "morningstarit"},
+		{"config scrapers", cfgScrapers, "defined in config file: myscraper1, myscraper2\n"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &bytes.Buffer{}
-			execSources(w)
+			execSources(w, tt.cfg)
 			if gotW := w.String(); !strings.Contains(gotW, tt.wantW) {
 				t.Errorf("execSources() =\n\t%vdoes not cointains:\n\t%v", gotW, tt.wantW)
 			}
@@ -4,13 +4,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/mmbros/quotes/internal/alert"
 	"github.com/mmbros/quotes/internal/configfile"
+	"github.com/mmbros/quotes/internal/daemon"
+	"github.com/mmbros/quotes/internal/export"
+	"github.com/mmbros/quotes/internal/fx"
+	"github.com/mmbros/quotes/internal/portfolio"
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetter/jsons"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers/configscraper"
 	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/quotes/internal/schedule"
 	"github.com/mmbros/taskengine"
 	toml "github.com/pelletier/go-toml"
 	"gopkg.in/yaml.v3"
@@ -27,15 +40,114 @@ const (
 )
 
 type sourceItem struct {
-	Workers  int    `json:"workers,omitempty"`
-	Proxy    string `json:"proxy,omitempty"`
-	Disabled bool   `json:"disabled,omitempty"`
+	Workers  int        `json:"workers,omitempty"`
+	Proxy    string     `json:"proxy,omitempty"`
+	Disabled bool       `json:"disabled,omitempty"`
+	Timeout  string     `json:"timeout,omitempty"`
+	Retry    *retryItem `json:"retry,omitempty"`
+	Rate     string     `json:"rate,omitempty"`
+	Burst    int        `json:"burst,omitempty"`
+	Delay    string     `json:"delay,omitempty"`
+	CacheTTL string     `json:"cachettl,omitempty"`
+	MaxAge   string     `json:"maxage,omitempty"`
+
+	timeout        time.Duration
+	retryPolicy    *quotegetter.RetryPolicy
+	rateLimit      *quotegetter.RateLimit
+	proxyRateLimit *quotegetter.RateLimit
+	cache          *quotegetter.Cache
+	maxAge         *quotes.MaxAge
 }
 
-type isinItem struct {
+// rateItem is the rate limit of the requests through a proxy.
+type rateItem struct {
+	Rate  string `json:"rate,omitempty"`
+	Burst int    `json:"burst,omitempty"`
+	Delay string `json:"delay,omitempty"`
+}
+
+// retryItem is the retry policy of the http requests of a source.
+// Delay and MaxDelay are durations in the time.ParseDuration format (es. "500ms").
+type retryItem struct {
+	Attempts    int    `json:"attempts,omitempty"`
+	Delay       string `json:"delay,omitempty"`
+	MaxDelay    string `json:"maxdelay,omitempty"`
+	StatusCodes []int  `json:"statuscodes,omitempty"`
+}
+
+// gnucashItem defines the GnuCash commodities of the isins.
+// The Commodities symbols, that can be prefixed by the namespace (es. "XETRA:VWCE"),
+// override the ones of the commodities section of the config.
+type gnucashItem struct {
+	Namespace   string            `json:"namespace,omitempty"`
+	Commodities map[string]string `json:"commodities,omitempty"`
+}
+
+// consensusItem defines the comparison of the prices of the sources.
+// Tolerance is the max relative deviation from the median price (es. 0.02 is 2%),
+// MaxAge is the max age of the date of the prices, in days (es. "3d")
+// or business days (es. "2bd"), as the maxage of the isins and sources.
+type consensusItem struct {
+	Tolerance float64 `json:"tolerance,omitempty"`
+	MaxAge    string  `json:"maxage,omitempty"`
+}
+
+// fxItem defines the source of the exchange rates used to convert
+// the prices to the reporting currency. The default source is "ecb",
+// with its default url.
+type fxItem struct {
+	Source string `json:"source,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Proxy  string `json:"proxy,omitempty"`
+}
+
+// holdingItem is a holding of the portfolio: the quantity of the isin held
+// in the account. Cost is the total cost basis, in the reporting currency.
+type holdingItem struct {
+	Isin     string  `json:"isin,omitempty"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Cost     float64 `json:"cost,omitempty"`
+	Account  string  `json:"account,omitempty"`
+}
+
+// alertItem defines the alert rules of an isin, checked after the get command
+// and the runs of the daemon.
+// Above and Below are the thresholds of the price, Change is the max percent
+// change from the previous close (es. 5 is ±5%) and Missing is the max number
+// of days without a quote. A zero value disables the rule.
+type alertItem struct {
+	Above   float64 `json:"above,omitempty"`
+	Below   float64 `json:"below,omitempty"`
+	Change  float64 `json:"change,omitempty"`
+	Missing int     `json:"missing,omitempty"`
+}
+
+// scheduleItem is a scheduled job of the daemon command: the quotes
+// of the isins (default all the isins) are retrieved at the times of the
+// cron expression, in the time zone (default the local one).
+type scheduleItem struct {
 	Name     string   `json:"name,omitempty"`
-	Disabled bool     `json:"disabled,omitempty"`
-	Sources  []string `json:"sources,omitempty"`
+	Cron     string   `json:"cron,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+	Isins    []string `json:"isins,omitempty"`
+}
+
+// daemonItem defines the daemon command: the address of the http server,
+// the folder of the json files of the runs and the scheduled jobs.
+type daemonItem struct {
+	Address   string          `json:"address,omitempty"`
+	Folder    string          `json:"folder,omitempty"`
+	Schedules []*scheduleItem `json:"schedules,omitempty"`
+}
+
+type isinItem struct {
+	Name     string     `json:"name,omitempty"`
+	Disabled bool       `json:"disabled,omitempty"`
+	Sources  []string   `json:"sources,omitempty"`
+	MaxAge   string     `json:"maxage,omitempty"`
+	Alerts   *alertItem `json:"alerts,omitempty"`
+
+	maxAge *quotes.MaxAge
 }
 
 // Config is ...
@@ -47,9 +159,43 @@ type Config struct {
 	Sources  map[string]*sourceItem `json:"sources,omitempty"`
 	Isins    map[string]*isinItem   `json:"isins,omitempty"`
 	Mode     string                 `json:"mode,omitempty"`
+	Timeout  string                 `json:"timeout,omitempty"`
+	Deadline string                 `json:"deadline,omitempty"`
+	Retry    *retryItem             `json:"retry,omitempty"`
+	MaxAge   string                 `json:"maxage,omitempty"`
+
+	ProxyRates map[string]*rateItem `json:"proxyrates,omitempty"`
+
+	CacheDir string `json:"cachedir,omitempty"`
+	CacheTTL string `json:"cachettl,omitempty"`
+
+	Commodities map[string]string `json:"commodities,omitempty"`
+	GnuCash     *gnucashItem      `json:"gnucash,omitempty"`
+
+	Consensus *consensusItem `json:"consensus,omitempty"`
+
+	Currency string  `json:"currency,omitempty"`
+	FX       *fxItem `json:"fx,omitempty"`
+
+	Portfolio []*holdingItem `json:"portfolio,omitempty"`
+
+	Notifiers []*alert.NotifierConfig `json:"notifiers,omitempty"`
+
+	Daemon *daemonItem `json:"daemon,omitempty"`
+
+	Scrapers map[string]*configscraper.Definition `json:"scrapers,omitempty"`
+	APIs     map[string]*jsons.Definition         `json:"apis,omitempty"`
 
-	taskengMode taskengine.Mode
-	cfi         *configfile.SourceInfo
+	taskengMode    taskengine.Mode
+	cfi            *configfile.SourceInfo
+	scraperSources quotegetter.Sources
+	noCache        bool
+	deadline       time.Duration
+	consensus      *quotes.ConsensusOptions
+	maxAge         *quotes.MaxAge
+	notifiers      []alert.Notifier
+	alertState     map[alert.Key]bool // triggered alert rules, if the database is not defined
+	jobs           []*daemon.Job
 }
 
 // String returns a json string representation of the object.
@@ -92,6 +238,174 @@ labelReturnError:
 	return
 }
 
+// policy returns the quotegetter.RetryPolicy corrisponding to the retryItem.
+// It returns nil if the retryItem is nil.
+func (ri *retryItem) policy() (*quotegetter.RetryPolicy, error) {
+	if ri == nil {
+		return nil, nil
+	}
+	if ri.Attempts < 0 {
+		return nil, fmt.Errorf("retry attempts must be greater than zero (attempts=%d)", ri.Attempts)
+	}
+	p := &quotegetter.RetryPolicy{
+		MaxAttempts: ri.Attempts,
+		StatusCodes: ri.StatusCodes,
+	}
+
+	var err error
+	if ri.Delay != "" {
+		if p.BaseDelay, err = time.ParseDuration(ri.Delay); err != nil {
+			return nil, fmt.Errorf("invalid retry delay: %w", err)
+		}
+	}
+	if ri.MaxDelay != "" {
+		if p.MaxDelay, err = time.ParseDuration(ri.MaxDelay); err != nil {
+			return nil, fmt.Errorf("invalid retry maxdelay: %w", err)
+		}
+	}
+	for _, code := range p.StatusCodes {
+		if code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid retry status code %d", code)
+		}
+	}
+	return p, nil
+}
+
+// options returns the quotes.ConsensusOptions corrisponding to the consensusItem.
+// It returns the default options if the consensusItem is nil.
+func (ci *consensusItem) options() (*quotes.ConsensusOptions, error) {
+	opts := &quotes.ConsensusOptions{}
+	if ci == nil {
+		return opts, nil
+	}
+	if ci.Tolerance < 0 {
+		return nil, fmt.Errorf("consensus tolerance must not be negative (tolerance=%g)", ci.Tolerance)
+	}
+	opts.Tolerance = ci.Tolerance
+
+	maxAge, err := parseMaxAge(ci.MaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("consensus: %w", err)
+	}
+	opts.MaxAge = maxAge
+	return opts, nil
+}
+
+// parseRate parses the rate string and returns the number of requests per second.
+// The rate is a number followed by an optional unit: "/s", "/m" or "/h"
+// (es. "2", "2/s", "30/m"). The default unit is "/s".
+func parseRate(rate string) (float64, error) {
+	num, unit := rate, "s"
+	if idx := strings.IndexByte(rate, '/'); idx >= 0 {
+		num, unit = rate[:idx], rate[idx+1:]
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+	switch strings.TrimSpace(unit) {
+	case "s":
+		return n, nil
+	case "m":
+		return n / 60, nil
+	case "h":
+		return n / 3600, nil
+	}
+	return 0, fmt.Errorf("invalid rate %q", rate)
+}
+
+// newRateLimit returns the quotegetter.RateLimit
+// corrisponding to the rate, burst and delay params.
+// It returns nil if both rate and delay are not defined.
+func newRateLimit(rate string, burst int, delay string) (*quotegetter.RateLimit, error) {
+	if rate == "" && delay == "" {
+		return nil, nil
+	}
+	if burst < 0 {
+		return nil, fmt.Errorf("burst must be greater than zero (burst=%d)", burst)
+	}
+	rl := &quotegetter.RateLimit{Burst: burst}
+
+	var err error
+	if rate != "" {
+		if rl.Rate, err = parseRate(rate); err != nil {
+			return nil, err
+		}
+	}
+	if delay != "" {
+		if rl.MinDelay, err = time.ParseDuration(delay); err != nil {
+			return nil, fmt.Errorf("invalid delay: %w", err)
+		}
+	}
+	return rl, nil
+}
+
+// parsePositiveDuration parses the duration of the named param.
+// An empty string returns zero, i.e. not defined.
+func parsePositiveDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative (%s=%s)", name, name, value)
+	}
+	return d, nil
+}
+
+// parseMaxAge parses the max age of the date of the quotes:
+// a number of days followed by "d" for calendar days (es. "5d"),
+// or by "bd" for business days (es. "3bd").
+// An empty string returns nil, i.e. not defined.
+func parseMaxAge(value string) (*quotes.MaxAge, error) {
+	if value == "" {
+		return nil, nil
+	}
+	num, business := strings.TrimSuffix(value, "d"), false
+	if strings.HasSuffix(num, "b") {
+		num, business = strings.TrimSuffix(num, "b"), true
+	}
+	days, err := strconv.Atoi(num)
+	if err != nil || days < 0 || num == value {
+		return nil, fmt.Errorf("invalid maxage %q: accepted values are days (es. \"5d\") or business days (es. \"3bd\")", value)
+	}
+	return &quotes.MaxAge{Days: days, BusinessDays: business}, nil
+}
+
+// defaultCacheDir returns the default folder of the cached http responses:
+// the appname subfolder of the user cache folder.
+func defaultCacheDir(appname string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil || appname == "" {
+		return ""
+	}
+	return filepath.Join(dir, appname)
+}
+
+// exportOptions returns the options of the output format.
+func (cfg *Config) exportOptions(format export.Format, columns []string) *export.Options {
+	opts := &export.Options{
+		Columns:     columns,
+		Commodities: cfg.Commodities,
+	}
+	if format == export.GnuCash && cfg.GnuCash != nil {
+		opts.Namespace = cfg.GnuCash.Namespace
+		if len(cfg.GnuCash.Commodities) > 0 {
+			opts.Commodities = map[string]string{}
+			for k, v := range cfg.Commodities {
+				opts.Commodities[k] = v
+			}
+			for k, v := range cfg.GnuCash.Commodities {
+				opts.Commodities[k] = v
+			}
+		}
+	}
+	return opts
+}
+
 // String returns a json string representation of the Config.
 func (cfg *Config) String() string {
 	return jsonString(cfg)
@@ -171,6 +485,93 @@ func (cfg *Config) addAllSources(allSources []string, disabled bool) {
 	}
 }
 
+// initScrapers compiles the scrapers and the json apis defined in the config
+// and returns the list of all sources: the passed ones and the config defined ones.
+// A config defined source can't have the name of another source.
+func (cfg *Config) initScrapers(allSources []string) ([]string, error) {
+	if len(cfg.Scrapers) == 0 && len(cfg.APIs) == 0 {
+		return allSources, nil
+	}
+
+	setOfAllSources := newSet(allSources)
+	cfg.scraperSources = quotegetter.Sources{}
+	list := append([]string{}, allSources...)
+
+	// aux function
+	add := func(kind, name string, isNil bool, factory func() (quotegetter.NewQuoteGetterFunc, error)) error {
+		if setOfAllSources.has(name) {
+			return fmt.Errorf("%s %q: name already used by a built-in source", kind, name)
+		}
+		if cfg.scraperSources.Exists(name) {
+			return fmt.Errorf("%s %q: name already used by a scraper", kind, name)
+		}
+		if isNil {
+			return fmt.Errorf("%s %q: empty definition", kind, name)
+		}
+		fn, err := factory()
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", kind, name, err)
+		}
+		cfg.scraperSources[name] = fn
+		list = append(list, name)
+		return nil
+	}
+
+	for name, def := range cfg.Scrapers {
+		def := def
+		err := add("scraper", name, def == nil, func() (quotegetter.NewQuoteGetterFunc, error) {
+			return configscraper.NewQuoteGetterFactory(def)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, def := range cfg.APIs {
+		def := def
+		err := add("api", name, def == nil, func() (quotegetter.NewQuoteGetterFunc, error) {
+			return jsons.NewQuoteGetterFactory(def)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// pruneCache removes the expired files of the cache folders of the source-isins:
+// the ones older than the max time to live of the sources sharing the folder.
+// The errors are printed to w, since the cache is not needed to retrieve the quotes.
+func pruneCache(w io.Writer, sis []*quotes.SourceIsins) {
+	ttls := map[string]time.Duration{}
+	for _, si := range sis {
+		if c := si.Cache; c != nil && c.TTL > ttls[c.Dir] {
+			ttls[c.Dir] = c.TTL
+		}
+	}
+	now := time.Now()
+	for dir, ttl := range ttls {
+		if _, err := quotegetter.PruneCache(dir, ttl, now); err != nil {
+			fmt.Fprintf(w, "prune cache %q: %v\n", dir, err)
+		}
+	}
+}
+
+// availableSources returns the built-in sources
+// together with the sources defined by the scrapers and the apis of the config.
+func (cfg *Config) availableSources(builtin quotegetter.Sources) quotegetter.Sources {
+	if len(cfg.scraperSources) == 0 {
+		return builtin
+	}
+	sources := make(quotegetter.Sources, len(builtin)+len(cfg.scraperSources))
+	for k, v := range builtin {
+		sources[k] = v
+	}
+	for k, v := range cfg.scraperSources {
+		sources[k] = v
+	}
+	return sources
+}
+
 // normalizeVars complete the initialization of config varables.
 // must be called after read and before merge.
 func (cfg *Config) normalizeVars() {
@@ -262,6 +663,38 @@ func (cfg *Config) merge(args *Flags, allAvailableSources []string) error {
 		cfg.Proxy = args.proxy
 	}
 
+	// Deadline
+	if args.IsPassed(namesDeadline) {
+		cfg.Deadline = args.deadline
+	}
+
+	// Cache
+	if args.IsPassed(namesCacheTTL) {
+		cfg.CacheTTL = args.cacheTTL
+	}
+	cfg.noCache = args.noCache
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = defaultCacheDir(args.Appname())
+	}
+
+	// Currency
+	if args.IsPassed(namesCurrency) {
+		cfg.Currency = args.currency
+	}
+
+	// Daemon
+	if args.IsPassed(namesAddress) || args.IsPassed(namesFolder) {
+		if cfg.Daemon == nil {
+			cfg.Daemon = &daemonItem{}
+		}
+		if args.IsPassed(namesAddress) {
+			cfg.Daemon.Address = args.address
+		}
+		if args.IsPassed(namesFolder) {
+			cfg.Daemon.Folder = args.folder
+		}
+	}
+
 	// Mode
 	if args.IsPassed(namesMode) {
 		cfg.Mode = args.mode
@@ -275,12 +708,18 @@ func (cfg *Config) merge(args *Flags, allAvailableSources []string) error {
 	// If passed, only isins in args are getted
 	// even if they are disabled in config!
 	// Other isins in config are disabled.
-	if len(args.isins) > 0 {
+	// The quotes fetched by the portfolio command are the ones
+	// of the isins of the holdings.
+	argsIsins := args.isins
+	if args.fetch {
+		argsIsins = cfg.portfolioIsins()
+	}
+	if len(argsIsins) > 0 {
 		// disable all the existing config isins
 		for _, i := range cfg.Isins {
 			i.Disabled = true
 		}
-		for _, i := range args.isins {
+		for _, i := range argsIsins {
 			item, ok := cfg.Isins[i]
 			if ok {
 				item.Disabled = false
@@ -428,6 +867,104 @@ func (cfg *Config) check(allSources []string) error {
 
 	setOfAllSources := newSet(allSources)
 
+	// rate limits of the proxies, by proxy url
+	proxyRateLimits := map[string]*quotegetter.RateLimit{}
+	for p, item := range cfg.ProxyRates {
+		if item == nil {
+			continue
+		}
+		rl, err := newRateLimit(item.Rate, item.Burst, item.Delay)
+		if err != nil {
+			return fmt.Errorf("proxy %q: %w", p, err)
+		}
+		proxyRateLimits[cfg.resolveProxy(p)] = rl
+	}
+
+	// deadline of the whole run
+	deadline, err := parsePositiveDuration("deadline", cfg.Deadline)
+	if err != nil {
+		return err
+	}
+	cfg.deadline = deadline
+
+	// consensus of the prices of the sources
+	if cfg.consensus, err = cfg.Consensus.options(); err != nil {
+		return err
+	}
+
+	// reporting currency
+	if cfg.Currency != "" {
+		cfg.Currency = strings.ToUpper(cfg.Currency)
+		if len(cfg.Currency) != 3 {
+			return fmt.Errorf("invalid currency %q", cfg.Currency)
+		}
+		if cfg.FX == nil {
+			cfg.FX = &fxItem{}
+		}
+		if _, err := fx.NewSource(cfg.FX.Source, cfg.FX.URL, nil); err != nil {
+			return err
+		}
+		cfg.FX.Proxy = cfg.resolveProxy(cfg.FX.Proxy)
+	}
+
+	// holdings of the portfolio
+	for j, h := range cfg.Portfolio {
+		if h == nil || h.Isin == "" {
+			return fmt.Errorf("portfolio holding #%d: isin not defined", j+1)
+		}
+		if h.Quantity <= 0 {
+			return fmt.Errorf("portfolio holding %q: quantity must be greater than zero (quantity=%g)", h.Isin, h.Quantity)
+		}
+		if h.Cost < 0 {
+			return fmt.Errorf("portfolio holding %q: cost must not be negative (cost=%g)", h.Isin, h.Cost)
+		}
+	}
+
+	// alert rules and notifiers
+	for i, isin := range cfg.Isins {
+		if a := isin.Alerts; a != nil && (a.Above < 0 || a.Below < 0 || a.Change < 0 || a.Missing < 0) {
+			return fmt.Errorf("isin %q: alerts must not be negative", i)
+		}
+	}
+	cfg.notifiers = nil
+	for j, nc := range cfg.Notifiers {
+		if nc != nil && nc.Proxy != "" {
+			nc.Proxy = cfg.resolveProxy(nc.Proxy)
+		}
+		n, err := alert.NewNotifier(nc)
+		if err != nil {
+			return fmt.Errorf("notifier #%d: %w", j+1, err)
+		}
+		cfg.notifiers = append(cfg.notifiers, n)
+	}
+
+	// scheduled jobs of the daemon
+	if cfg.jobs, err = cfg.Daemon.jobs(); err != nil {
+		return err
+	}
+
+	// max age of the quotes
+	if cfg.maxAge, err = parseMaxAge(cfg.MaxAge); err != nil {
+		return err
+	}
+	for i, isin := range cfg.Isins {
+		if isin.maxAge, err = parseMaxAge(isin.MaxAge); err != nil {
+			return fmt.Errorf("isin %q: %w", i, err)
+		}
+	}
+
+	// default timeout of the http requests
+	timeout, err := parsePositiveDuration("timeout", cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	// default time to live of the cached responses
+	cacheTTL, err := parsePositiveDuration("cachettl", cfg.CacheTTL)
+	if err != nil {
+		return err
+	}
+
 	// check proxy and workers of each referenced source
 	for s, source := range cfg.Sources {
 		// check source is available
@@ -451,11 +988,140 @@ func (cfg *Config) check(allSources []string) error {
 			}
 		}
 		source.Proxy = proxyURL
+
+		// timeout
+		source.timeout = timeout
+		if source.Timeout != "" {
+			if source.timeout, err = parsePositiveDuration("timeout", source.Timeout); err != nil {
+				return fmt.Errorf("source %q: %w", s, err)
+			}
+		}
+
+		// retry
+		if source.Retry == nil {
+			source.Retry = cfg.Retry
+		}
+		p, err := source.Retry.policy()
+		if err != nil {
+			return fmt.Errorf("source %q: %w", s, err)
+		}
+		source.retryPolicy = p
+
+		// rate limit
+		rl, err := newRateLimit(source.Rate, source.Burst, source.Delay)
+		if err != nil {
+			return fmt.Errorf("source %q: %w", s, err)
+		}
+		source.rateLimit = rl
+		source.proxyRateLimit = proxyRateLimits[proxyURL]
+
+		// cache
+		ttl := cacheTTL
+		if source.CacheTTL != "" {
+			if ttl, err = parsePositiveDuration("cachettl", source.CacheTTL); err != nil {
+				return fmt.Errorf("source %q: %w", s, err)
+			}
+		}
+		source.cache = nil
+		if !cfg.noCache && ttl > 0 {
+			if cfg.CacheDir == "" {
+				return fmt.Errorf("source %q: cachedir not defined", s)
+			}
+			source.cache = &quotegetter.Cache{Dir: cfg.CacheDir, TTL: ttl}
+		}
+
+		// max age
+		source.maxAge = cfg.maxAge
+		if source.MaxAge != "" {
+			if source.maxAge, err = parseMaxAge(source.MaxAge); err != nil {
+				return fmt.Errorf("source %q: %w", s, err)
+			}
+		}
 	}
 
 	return nil
 }
 
+// jobs returns the scheduled jobs of the daemon.
+// The name of a job is the name of the schedule, if defined,
+// otherwise "#n" where n is the position of the schedule.
+func (di *daemonItem) jobs() ([]*daemon.Job, error) {
+	if di == nil {
+		return nil, nil
+	}
+	jobs := make([]*daemon.Job, 0, len(di.Schedules))
+	for j, si := range di.Schedules {
+		if si == nil || si.Cron == "" {
+			return nil, fmt.Errorf("daemon schedule #%d: cron not defined", j+1)
+		}
+		name := si.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", j+1)
+		}
+		loc := time.Local
+		if si.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(si.Timezone); err != nil {
+				return nil, fmt.Errorf("daemon schedule %q: invalid timezone %q: %w", name, si.Timezone, err)
+			}
+		}
+		sched, err := schedule.Parse(si.Cron, loc)
+		if err != nil {
+			return nil, fmt.Errorf("daemon schedule %q: %w", name, err)
+		}
+		jobs = append(jobs, &daemon.Job{Name: name, Schedule: sched, Isins: si.Isins})
+	}
+	return jobs, nil
+}
+
+// alertRules returns the alert rules of the enabled isins.
+// If isins is not empty, only the rules of those isins are returned.
+func (cfg *Config) alertRules(isins []string) map[string]*alert.Rules {
+	only := newSet(isins)
+	rules := map[string]*alert.Rules{}
+	for i, isin := range cfg.Isins {
+		if isin.Disabled || (len(isins) > 0 && !only.has(i)) {
+			continue
+		}
+		if a := isin.Alerts; a != nil {
+			rules[i] = &alert.Rules{
+				Above:   a.Above,
+				Below:   a.Below,
+				Change:  a.Change,
+				Missing: a.Missing,
+			}
+		}
+	}
+	return rules
+}
+
+// portfolioIsins returns the isins of the holdings of the portfolio.
+func (cfg *Config) portfolioIsins() []string {
+	return portfolio.Isins(cfg.holdings())
+}
+
+// holdings returns the holdings of the portfolio,
+// with the name of the isins defined in the config.
+func (cfg *Config) holdings() []*portfolio.Holding {
+	holdings := make([]*portfolio.Holding, 0, len(cfg.Portfolio))
+	for _, h := range cfg.Portfolio {
+		if h == nil {
+			continue
+		}
+		ph := &portfolio.Holding{
+			Isin:     h.Isin,
+			Account:  h.Account,
+			Quantity: h.Quantity,
+			Cost:     h.Cost,
+		}
+		if isin := cfg.Isins[h.Isin]; isin != nil {
+			ph.Name = isin.Name
+		}
+		holdings = append(holdings, ph)
+	}
+	return holdings
+}
+
 // SourceIsinsList ...
 // If no sources, returns a list with zero items (it does not returns nil).
 // NOTE: it assumes all isins and sources are enabled
@@ -489,17 +1155,48 @@ func (cfg *Config) SourceIsinsList() []*quotes.SourceIsins {
 	for s, isins := range sources {
 		src := cfg.Sources[s]
 
+		// max age of the isins: the isin one, otherwise the source one
+		var maxAges map[string]*quotes.MaxAge
+		for _, i := range isins {
+			maxAge := cfg.Isins[i].maxAge
+			if maxAge == nil {
+				maxAge = src.maxAge
+			}
+			if maxAge != nil {
+				if maxAges == nil {
+					maxAges = map[string]*quotes.MaxAge{}
+				}
+				maxAges[i] = maxAge
+			}
+		}
+
 		si := &quotes.SourceIsins{
-			Source:  s,
-			Proxy:   src.Proxy,
-			Workers: src.Workers,
-			Isins:   isins,
+			Source:         s,
+			Proxy:          src.Proxy,
+			Workers:        src.Workers,
+			Timeout:        src.timeout,
+			Retry:          src.retryPolicy,
+			RateLimit:      src.rateLimit,
+			ProxyRateLimit: src.proxyRateLimit,
+			Cache:          src.cache,
+			Isins:          isins,
+			MaxAges:        maxAges,
 		}
 		sis = append(sis, si)
 	}
 	return sis
 }
 
+// isinSources returns the sources of each isin, in the order of preference
+// of the config file.
+func (cfg *Config) isinSources() map[string][]string {
+	order := make(map[string][]string, len(cfg.Isins))
+	for i, isin := range cfg.Isins {
+		order[i] = isin.Sources
+	}
+	return order
+}
+
 func NewConfig(cfi *configfile.SourceInfo, flags *Flags, allSources []string) (*Config, error) {
 	var err error
 	var data []byte
@@ -528,6 +1225,11 @@ func auxNewConfig(data []byte, cfi *configfile.SourceInfo, flags *Flags, allSour
 	// 2. normalize config variables
 	cfg.normalizeVars()
 
+	// 2a. add the sources defined by the scrapers of the config
+	if err == nil {
+		allSources, err = cfg.initScrapers(allSources)
+	}
+
 	// 3. merge command line arguments in config
 	if err == nil {
 		err = cfg.merge(flags, allSources)
@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mmbros/quotes/internal/configfile"
+	"github.com/mmbros/quotes/internal/fx"
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetterdb"
 	"github.com/mmbros/quotes/internal/quotes"
 	"github.com/mmbros/taskengine"
 	"github.com/stretchr/testify/assert"
@@ -808,3 +817,984 @@ isins:
 		})
 	}
 }
+
+func TestScrapers(t *testing.T) {
+
+	availableSources := []string{"source1"}
+
+	const cfgYaml = `
+isins:
+  isin1:
+    sources: [myscraper]
+scrapers:
+  myscraper:
+    infourl: "http://127.0.0.1/info/{{.Isin}}"
+    headers:
+      X-Isin: "{{.Isin}}"
+    price:
+      selector: "span.price"
+    currency:
+      value: EUR
+    date:
+      selector: "span.date"
+    datelayout: "02/01/2006"
+    priceformat: comma
+`
+	const cfgToml = `
+[isins.isin1]
+sources = ["myscraper"]
+
+[scrapers.myscraper]
+infourl = "http://127.0.0.1/info/{{.Isin}}"
+datelayout = "02/01/2006"
+priceformat = "comma"
+[scrapers.myscraper.price]
+selector = "span.price"
+[scrapers.myscraper.date]
+selector = "span.date"
+`
+
+	cases := map[string]struct {
+		argtxt string
+		cfgtxt string
+		errmsg string
+	}{
+		"yaml": {
+			cfgtxt: cfgYaml,
+		},
+		"toml": {
+			argtxt: "--config-type toml",
+			cfgtxt: cfgToml,
+		},
+		"args sources": {
+			argtxt: "-i isin2 -s myscraper",
+			cfgtxt: cfgYaml,
+		},
+		"built-in name": {
+			cfgtxt: `
+scrapers:
+  source1:
+    infourl: "http://127.0.0.1/info/{{.Isin}}"
+`,
+			errmsg: `scraper "source1": name already used by a built-in source`,
+		},
+		"empty definition": {
+			cfgtxt: `
+scrapers:
+  myscraper:
+`,
+			errmsg: `scraper "myscraper": empty definition`,
+		},
+		"invalid definition": {
+			cfgtxt: `
+scrapers:
+  myscraper:
+    infourl: "http://127.0.0.1/info/{{.Isin}}"
+`,
+			errmsg: `scraper "myscraper": price is required`,
+		},
+		"api": {
+			cfgtxt: `
+isins:
+  isin1:
+    sources: [myscraper]
+apis:
+  myscraper:
+    url: "http://127.0.0.1/api/{{lower .Isin}}"
+    price:
+      path: data.nav
+    date:
+      path: data.date
+    datelayout: "2006-01-02"
+`,
+		},
+		"api with scraper name": {
+			cfgtxt: cfgYaml + `
+apis:
+  myscraper:
+    url: "http://127.0.0.1/api/{{lower .Isin}}"
+`,
+			errmsg: `api "myscraper": name already used by a scraper`,
+		},
+		"invalid api": {
+			cfgtxt: `
+apis:
+  myapi:
+    price:
+      path: data.nav
+`,
+			errmsg: `api "myapi": url is empty`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+
+			flags, err := initAppGetFlags(c.argtxt)
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), getCFI(flags), flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			assert.Contains(t, cfg.Sources, "myscraper", title)
+
+			sources := cfg.availableSources(quotegetter.Sources{"source1": nil})
+			assert.True(t, sources.Exists("myscraper"), title)
+			assert.Len(t, sources, 2, title)
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+
+	availableSources := []string{"source1", "source2", "source3"}
+
+	yaml1 := `
+retry:
+  attempts: 2
+
+isins:
+  isin1:
+
+sources:
+  source1:
+    retry:
+      attempts: 4
+      delay: 200ms
+      maxdelay: 5s
+      statuscodes: [429, 503]
+  source2:
+    retry:
+      attempts: 1
+`
+
+	cases := map[string]struct {
+		cfgtxt string
+		want   map[string]*quotegetter.RetryPolicy
+		errmsg string
+	}{
+		"no retry": {
+			cfgtxt: "isins:\n  isin1:\n",
+			want:   map[string]*quotegetter.RetryPolicy{},
+		},
+		"cfg": {
+			cfgtxt: yaml1,
+			want: map[string]*quotegetter.RetryPolicy{
+				"source1": {MaxAttempts: 4, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second, StatusCodes: []int{429, 503}},
+				"source2": {MaxAttempts: 1},
+				"source3": {MaxAttempts: 2},
+			},
+		},
+		"invalid delay": {
+			cfgtxt: "isins:\n  isin1:\nretry:\n  delay: 2x\n",
+			errmsg: "invalid retry delay",
+		},
+		"invalid attempts": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    retry:\n      attempts: -1\n",
+			errmsg: `source "source1": retry attempts must be greater than zero`,
+		},
+		"invalid status code": {
+			cfgtxt: "isins:\n  isin1:\nretry:\n  statuscodes: [1503]\n",
+			errmsg: "invalid retry status code 1503",
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags("")
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			for _, si := range cfg.SourceIsinsList() {
+				assert.Equal(t, c.want[si.Source], si.Retry, "%s: %s", title, si.Source)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    float64
+		wantErr bool
+	}{
+		{"2", 2, false},
+		{"2/s", 2, false},
+		{"30/m", 0.5, false},
+		{"360/h", 0.1, false},
+		{"0.5 / s", 0.5, false},
+		{"2/d", 0, true},
+		{"x/s", 0, true},
+		{"-1", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRate(tt.rate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRate(%q): unexpected error %v", tt.rate, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseRate(%q): expected %v, found %v", tt.rate, tt.want, got)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+
+	availableSources := []string{"source1", "source2", "source3"}
+
+	yaml1 := `
+proxies:
+  tor: socks5://127.0.0.1:9050
+
+proxyrates:
+  tor:
+    rate: 30/m
+
+isins:
+  isin1:
+
+sources:
+  source1:
+    rate: 2/s
+    burst: 3
+    delay: 1s
+  source2:
+    proxy: tor
+    delay: 500ms
+`
+
+	cases := map[string]struct {
+		cfgtxt    string
+		want      map[string]*quotegetter.RateLimit
+		wantProxy map[string]*quotegetter.RateLimit
+		errmsg    string
+	}{
+		"no rate": {
+			cfgtxt: "isins:\n  isin1:\n",
+		},
+		"cfg": {
+			cfgtxt: yaml1,
+			want: map[string]*quotegetter.RateLimit{
+				"source1": {Rate: 2, Burst: 3, MinDelay: time.Second},
+				"source2": {MinDelay: 500 * time.Millisecond},
+			},
+			wantProxy: map[string]*quotegetter.RateLimit{
+				"source2": {Rate: 0.5},
+			},
+		},
+		"invalid rate": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    rate: 2/d\n",
+			errmsg: `source "source1": invalid rate "2/d"`,
+		},
+		"invalid delay": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    delay: 2\n",
+			errmsg: `source "source1": invalid delay`,
+		},
+		"invalid burst": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    rate: 1\n    burst: -1\n",
+			errmsg: "burst must be greater than zero",
+		},
+		"invalid proxy rate": {
+			cfgtxt: "isins:\n  isin1:\nproxyrates:\n  tor:\n    rate: fast\n",
+			errmsg: `proxy "tor": invalid rate "fast"`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags("")
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			for _, si := range cfg.SourceIsinsList() {
+				assert.Equal(t, c.want[si.Source], si.RateLimit, "%s: %s", title, si.Source)
+				assert.Equal(t, c.wantProxy[si.Source], si.ProxyRateLimit, "%s: %s proxy", title, si.Source)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+
+	availableSources := []string{"source1", "source2", "source3"}
+
+	yaml1 := `
+cachedir: /tmp/quotes-cache
+cachettl: 10m
+
+isins:
+  isin1:
+    sources: [source1, source2]
+
+sources:
+  source2:
+    cachettl: 1h
+`
+
+	cases := map[string]struct {
+		argtxt string
+		cfgtxt string
+		want   map[string]*quotegetter.Cache
+		errmsg string
+	}{
+		"no cache": {
+			cfgtxt: "isins:\n  isin1:\n",
+		},
+		"cfg": {
+			cfgtxt: yaml1,
+			want: map[string]*quotegetter.Cache{
+				"source1": {Dir: "/tmp/quotes-cache", TTL: 10 * time.Minute},
+				"source2": {Dir: "/tmp/quotes-cache", TTL: time.Hour},
+			},
+		},
+		"args cache-ttl": {
+			argtxt: "--cache-ttl 5m",
+			cfgtxt: yaml1,
+			want: map[string]*quotegetter.Cache{
+				"source1": {Dir: "/tmp/quotes-cache", TTL: 5 * time.Minute},
+				"source2": {Dir: "/tmp/quotes-cache", TTL: time.Hour},
+			},
+		},
+		"args cache-ttl zero": {
+			argtxt: "--cache-ttl 0",
+			cfgtxt: yaml1,
+			want: map[string]*quotegetter.Cache{
+				"source2": {Dir: "/tmp/quotes-cache", TTL: time.Hour},
+			},
+		},
+		"args no-cache": {
+			argtxt: "--no-cache",
+			cfgtxt: yaml1,
+		},
+		"invalid cachettl": {
+			cfgtxt: "isins:\n  isin1:\ncachettl: 10\n",
+			errmsg: "invalid cachettl",
+		},
+		"negative cachettl": {
+			argtxt: "--cache-ttl -1m",
+			cfgtxt: "isins:\n  isin1:\n",
+			errmsg: "cachettl must not be negative",
+		},
+		"invalid source cachettl": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    cachettl: 1y\n",
+			errmsg: `source "source1": invalid cachettl`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags(c.argtxt)
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			for _, si := range cfg.SourceIsinsList() {
+				assert.Equal(t, c.want[si.Source], si.Cache, "%s: %s", title, si.Source)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+
+	availableSources := []string{"source1", "source2", "source3"}
+
+	yaml1 := `
+timeout: 20s
+deadline: 5m
+
+isins:
+  isin1:
+    sources: [source1, source2]
+
+sources:
+  source2:
+    timeout: 1m
+`
+
+	cases := map[string]struct {
+		argtxt       string
+		cfgtxt       string
+		want         map[string]time.Duration
+		wantDeadline time.Duration
+		errmsg       string
+	}{
+		"no timeout": {
+			cfgtxt: "isins:\n  isin1:\n",
+		},
+		"cfg": {
+			cfgtxt: yaml1,
+			want: map[string]time.Duration{
+				"source1": 20 * time.Second,
+				"source2": time.Minute,
+			},
+			wantDeadline: 5 * time.Minute,
+		},
+		"args deadline": {
+			argtxt: "--deadline 30s",
+			cfgtxt: yaml1,
+			want: map[string]time.Duration{
+				"source1": 20 * time.Second,
+				"source2": time.Minute,
+			},
+			wantDeadline: 30 * time.Second,
+		},
+		"invalid deadline": {
+			argtxt: "--deadline soon",
+			cfgtxt: "isins:\n  isin1:\n",
+			errmsg: "invalid deadline",
+		},
+		"negative timeout": {
+			cfgtxt: "isins:\n  isin1:\ntimeout: -1s\n",
+			errmsg: "timeout must not be negative",
+		},
+		"invalid source timeout": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    timeout: 10\n",
+			errmsg: `source "source1": invalid timeout`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags(c.argtxt)
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			assert.Equal(t, c.wantDeadline, cfg.deadline, title)
+			for _, si := range cfg.SourceIsinsList() {
+				assert.Equal(t, c.want[si.Source], si.Timeout, "%s: %s", title, si.Source)
+			}
+		})
+	}
+}
+
+func TestConsensus(t *testing.T) {
+
+	availableSources := []string{"source1", "source2"}
+
+	cases := map[string]struct {
+		cfgtxt string
+		want   *quotes.ConsensusOptions
+		errmsg string
+	}{
+		"default": {
+			cfgtxt: "isins:\n  isin1:\n",
+			want:   &quotes.ConsensusOptions{},
+		},
+		"cfg": {
+			cfgtxt: "isins:\n  isin1:\nconsensus:\n  tolerance: 0.05\n  maxage: 3bd\n",
+			want:   &quotes.ConsensusOptions{Tolerance: 0.05, MaxAge: &quotes.MaxAge{Days: 3, BusinessDays: true}},
+		},
+		"negative tolerance": {
+			cfgtxt: "isins:\n  isin1:\nconsensus:\n  tolerance: -0.05\n",
+			errmsg: "consensus tolerance must not be negative",
+		},
+		"invalid maxage": {
+			cfgtxt: "isins:\n  isin1:\nconsensus:\n  maxage: 72h\n",
+			errmsg: `consensus: invalid maxage "72h"`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags("")
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			assert.Equal(t, c.want, cfg.consensus, title)
+		})
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+
+	availableSources := []string{"source1", "source2", "source3"}
+
+	yaml1 := `
+maxage: 5d
+
+isins:
+  isin1:
+    sources: [source1, source2, source3]
+  isin2:
+    sources: [source1, source2]
+    maxage: 1bd
+
+sources:
+  source2:
+    maxage: 2bd
+`
+	days := func(n int) *quotes.MaxAge { return &quotes.MaxAge{Days: n} }
+	bdays := func(n int) *quotes.MaxAge { return &quotes.MaxAge{Days: n, BusinessDays: true} }
+
+	cases := map[string]struct {
+		cfgtxt string
+		want   map[string]map[string]*quotes.MaxAge
+		errmsg string
+	}{
+		"no maxage": {
+			cfgtxt: "isins:\n  isin1:\n    sources: [source1]\n",
+			want:   map[string]map[string]*quotes.MaxAge{},
+		},
+		"cfg": {
+			cfgtxt: yaml1,
+			want: map[string]map[string]*quotes.MaxAge{
+				"source1": {"isin1": days(5), "isin2": bdays(1)},
+				"source2": {"isin1": bdays(2), "isin2": bdays(1)},
+				"source3": {"isin1": days(5)},
+			},
+		},
+		"invalid maxage": {
+			cfgtxt: "isins:\n  isin1:\nmaxage: 5\n",
+			errmsg: `invalid maxage "5"`,
+		},
+		"invalid isin maxage": {
+			cfgtxt: "isins:\n  isin1:\n    maxage: -1d\n",
+			errmsg: `isin "isin1": invalid maxage`,
+		},
+		"invalid source maxage": {
+			cfgtxt: "isins:\n  isin1:\nsources:\n  source1:\n    maxage: 1w\n",
+			errmsg: `source "source1": invalid maxage`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags("")
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			for _, si := range cfg.SourceIsinsList() {
+				want := c.want[si.Source]
+				if len(want) == 0 {
+					assert.Nil(t, si.MaxAges, "%s: %s", title, si.Source)
+					continue
+				}
+				assert.Equal(t, want, si.MaxAges, "%s: %s", title, si.Source)
+			}
+		})
+	}
+}
+
+func TestCurrency(t *testing.T) {
+
+	availableSources := []string{"source1"}
+
+	cases := map[string]struct {
+		argtxt string
+		cfgtxt string
+		want   string
+		wantFX *fxItem
+		errmsg string
+	}{
+		"no currency": {
+			cfgtxt: "isins:\n  isin1:\n",
+		},
+		"cfg": {
+			cfgtxt: "isins:\n  isin1:\ncurrency: eur\nfx:\n  url: http://localhost/rates.xml\n",
+			want:   "EUR",
+			wantFX: &fxItem{URL: "http://localhost/rates.xml"},
+		},
+		"args": {
+			argtxt: "--currency usd",
+			cfgtxt: "isins:\n  isin1:\ncurrency: eur\n",
+			want:   "USD",
+			wantFX: &fxItem{},
+		},
+		"invalid currency": {
+			cfgtxt: "isins:\n  isin1:\ncurrency: euro\n",
+			errmsg: `invalid currency "EURO"`,
+		},
+		"invalid fx source": {
+			cfgtxt: "isins:\n  isin1:\ncurrency: eur\nfx:\n  source: foo\n",
+			errmsg: `fx source "foo" not available`,
+		},
+	}
+	for title, c := range cases {
+		t.Run(title, func(t *testing.T) {
+			flags, err := initAppGetFlags(c.argtxt)
+			require.NoError(t, err)
+			cfg, err := auxNewConfig([]byte(c.cfgtxt), nil, flags, availableSources)
+
+			if c.errmsg != "" {
+				if assert.Error(t, err, title) {
+					assert.Contains(t, err.Error(), c.errmsg, title)
+				}
+				return
+			}
+			require.NoError(t, err, title)
+			assert.Equal(t, c.want, cfg.Currency, title)
+			assert.Equal(t, c.wantFX, cfg.FX, title)
+		})
+	}
+}
+
+func TestFXTable(t *testing.T) {
+	const rates = `<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time='2026-10-16'>
+			<Cube currency='USD' rate='1.2500'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, rates)
+	}))
+	defer server.Close()
+
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+	cfg := &Config{
+		Database: dbpath,
+		Currency: "EUR",
+		FX:       &fxItem{URL: server.URL},
+	}
+	date := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	results := []*quotes.Result{{Isin: "isin1", Price: 10, Currency: "USD", Date: &date}}
+
+	var out strings.Builder
+	table, err := cfg.fxTable(context.Background(), &out)
+	require.NoError(t, err)
+	quotes.ConvertPrices(results, cfg.Currency, table)
+	assert.Equal(t, 8.0, results[0].ConvertedPrice)
+	assert.Equal(t, "EUR", results[0].ConvertedCurrency)
+
+	// the rates saved in the database are used if the source is not available
+	available = false
+	out.Reset()
+	table, err = cfg.fxTable(context.Background(), &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "exchange rates not retrieved")
+	quotes.ConvertPrices(results, cfg.Currency, table)
+	assert.Equal(t, 8.0, results[0].ConvertedPrice)
+
+	// the request is canceled by the context
+	available = true
+	out.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cfg.fxTable(ctx, &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "context canceled")
+
+	// the rates are not retrieved if the rates of today are saved
+	db, err := quotegetterdb.Open(dbpath)
+	require.NoError(t, err)
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	err = db.InsertRates("test", &fx.Rates{Date: today, Base: "EUR", Rates: map[string]float64{"USD": 2}})
+	db.Close()
+	require.NoError(t, err)
+	available = false
+	out.Reset()
+	table, err = cfg.fxTable(context.Background(), &out)
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+	date = today
+	quotes.ConvertPrices(results, cfg.Currency, table)
+	assert.Equal(t, 5.0, results[0].ConvertedPrice)
+}
+
+func TestNotifyAlerts(t *testing.T) {
+	dir := t.TempDir()
+	dbpath := filepath.Join(dir, "quotes.sqlite3")
+	out := filepath.Join(dir, "alerts.txt")
+
+	result := func(isin string, days int, price float64) *quotes.Result {
+		date := time.Now().AddDate(0, 0, -days)
+		return &quotes.Result{
+			Isin:     isin,
+			Source:   "source1",
+			Date:     &date,
+			Price:    price,
+			Currency: "EUR",
+		}
+	}
+
+	db, err := quotegetterdb.Create(dbpath)
+	require.NoError(t, err)
+	err = db.InsertRun(&quotegetterdb.Run{Command: "get"}, []*quotes.Result{
+		result("isin1", 1, 100),
+		result("isin1", 0, 110),
+		result("isin2", 10, 20),
+	})
+	db.Close()
+	require.NoError(t, err)
+
+	cfgtxt := `
+database: ` + dbpath + `
+isins:
+  isin1:
+    name: World
+    alerts:
+      above: 120
+      change: 5
+  isin2:
+    alerts:
+      missing: 5
+  isin3:
+notifiers:
+  - type: command
+    command: cat > ` + out + `
+`
+	flags, err := initAppGetFlags("")
+	require.NoError(t, err)
+	cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1"})
+	require.NoError(t, err)
+
+	var w strings.Builder
+	require.NoError(t, cfg.notifyAlerts(&w, nil, nil))
+	assert.Equal(t, "alerts notified: 2\n", w.String())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "isin1 (World): price 110 EUR changed by +10.00% from the previous close 100")
+	assert.Contains(t, lines[1], "isin2: no quote since")
+
+	// the alerts already notified are not notified again
+	require.NoError(t, os.Remove(out))
+	w.Reset()
+	require.NoError(t, cfg.notifyAlerts(&w, nil, nil))
+	assert.Empty(t, w.String())
+	assert.NoFileExists(t, out)
+
+	// the resolved alert is removed from the notified ones
+	db, err = quotegetterdb.Open(dbpath)
+	require.NoError(t, err)
+	err = db.InsertRun(&quotegetterdb.Run{Command: "get"}, []*quotes.Result{result("isin2", 0, 21)})
+	require.NoError(t, err)
+	require.NoError(t, cfg.notifyAlerts(&w, nil, nil))
+	assert.Empty(t, w.String())
+	records, err := db.SelectAlerts()
+	db.Close()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "isin1", records[0].Isin)
+
+	// without database and notifiers, the alerts are printed and kept in memory
+	cfg.Database = ""
+	cfg.notifiers = nil
+	results := []*quotes.Result{result("isin1", 0, 130), result("isin2", 10, 20)}
+	require.NoError(t, cfg.notifyAlerts(&w, nil, results))
+	assert.Contains(t, w.String(), "isin1 (World): price 130 EUR above 120")
+	assert.Contains(t, w.String(), "isin2: no quote since")
+	assert.Contains(t, w.String(), "alerts notified: 2\n")
+
+	w.Reset()
+	require.NoError(t, cfg.notifyAlerts(&w, nil, results))
+	assert.Empty(t, w.String())
+}
+
+func TestNotifyAlertsIsins(t *testing.T) {
+	date := time.Now()
+	results := []*quotes.Result{
+		{Isin: "isin1", Source: "source1", Date: &date, Price: 10, Currency: "EUR"},
+	}
+	cfgtxt := `
+isins:
+  isin1:
+    alerts:
+      missing: 5
+  isin2:
+    alerts:
+      missing: 5
+`
+	// the isins not selected by -i are not checked
+	flags, err := initAppGetFlags("-i isin1")
+	require.NoError(t, err)
+	cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1"})
+	require.NoError(t, err)
+	assert.Len(t, cfg.alertRules(nil), 1)
+
+	var w strings.Builder
+	require.NoError(t, cfg.notifyAlerts(&w, nil, results))
+	assert.Empty(t, w.String())
+
+	// the disabled isins are not checked
+	flags, err = initAppGetFlags("")
+	require.NoError(t, err)
+	cfg, err = auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1"})
+	require.NoError(t, err)
+	cfg.Isins["isin2"].Disabled = true
+	assert.Len(t, cfg.alertRules(nil), 1)
+	cfg.Isins["isin2"].Disabled = false
+
+	// without database, only the isins of the job are checked
+	require.NoError(t, cfg.notifyAlerts(&w, []string{"isin1"}, results))
+	assert.Empty(t, w.String())
+
+	require.NoError(t, cfg.notifyAlerts(&w, nil, results))
+	assert.Contains(t, w.String(), "isin2: no quote")
+	assert.Contains(t, w.String(), "alerts notified: 1\n")
+}
+
+func TestAlertsConfig(t *testing.T) {
+	cases := map[string]struct {
+		cfgtxt string
+		errmsg string
+	}{
+		"negative": {
+			cfgtxt: `
+isins:
+  isin1:
+    alerts:
+      below: -1
+`,
+			errmsg: `isin "isin1": alerts must not be negative`,
+		},
+		"notifier type": {
+			cfgtxt: `
+notifiers:
+  - type: stdout
+  - type: pager
+`,
+			errmsg: `notifier #2: invalid notifier type "pager": accepted values are command, smtp, stdout, webhook`,
+		},
+		"webhook": {
+			cfgtxt: `
+notifiers:
+  - type: webhook
+`,
+			errmsg: "notifier #1: webhook url not defined",
+		},
+		"ok": {
+			cfgtxt: `
+isins:
+  isin1:
+    alerts:
+      above: 10
+notifiers:
+  - type: smtp
+    host: localhost
+    from: quotes@example.com
+    to: [user@example.com]
+`,
+		},
+	}
+	for title, c := range cases {
+		flags, err := initAppGetFlags("")
+		require.NoError(t, err)
+		_, err = auxNewConfig([]byte(c.cfgtxt), nil, flags, []string{"source1"})
+		if c.errmsg != "" {
+			assert.EqualError(t, err, c.errmsg, title)
+		} else {
+			assert.NoError(t, err, title)
+		}
+	}
+}
+
+func TestDaemonConfig(t *testing.T) {
+	cases := map[string]struct {
+		cfgtxt string
+		errmsg string
+	}{
+		"cron": {
+			cfgtxt: `
+daemon:
+  schedules:
+    - timezone: Europe/Rome
+`,
+			errmsg: "daemon schedule #1: cron not defined",
+		},
+		"invalid cron": {
+			cfgtxt: `
+daemon:
+  schedules:
+    - cron: "30 18 * * *"
+    - name: close
+      cron: "30 18 * * 1-8"
+`,
+			errmsg: `daemon schedule "close": invalid cron expression "30 18 * * 1-8": invalid day of week "8": accepted values are 0-7`,
+		},
+		"timezone": {
+			cfgtxt: `
+daemon:
+  schedules:
+    - cron: "30 18 * * 1-5"
+      timezone: Europe/Nowhere
+`,
+			errmsg: `daemon schedule "#1": invalid timezone "Europe/Nowhere": unknown time zone Europe/Nowhere`,
+		},
+	}
+	for title, c := range cases {
+		flags, err := initAppGetFlags("")
+		require.NoError(t, err)
+		_, err = auxNewConfig([]byte(c.cfgtxt), nil, flags, []string{"source1"})
+		assert.EqualError(t, err, c.errmsg, title)
+	}
+
+	cfgtxt := `
+daemon:
+  address: ":8080"
+  schedules:
+    - name: close
+      cron: "30 18 * * 1-5"
+      timezone: Europe/Rome
+      isins: [isin1]
+    - cron: "@hourly"
+`
+	flags, err := initAppGetFlags("")
+	require.NoError(t, err)
+	cfg, err := auxNewConfig([]byte(cfgtxt), nil, flags, []string{"source1"})
+	require.NoError(t, err)
+	require.Len(t, cfg.jobs, 2)
+
+	job := cfg.jobs[0]
+	assert.Equal(t, "close", job.Name)
+	assert.Equal(t, "30 18 * * 1-5", job.Schedule.String())
+	assert.Equal(t, "Europe/Rome", job.Schedule.Location().String())
+	assert.Equal(t, []string{"isin1"}, job.Isins)
+
+	job = cfg.jobs[1]
+	assert.Equal(t, "#2", job.Name)
+	assert.Equal(t, time.Local, job.Schedule.Location())
+
+	address, folder := cfg.daemonAddress()
+	assert.Equal(t, ":8080", address)
+	assert.Equal(t, defaultFolder, folder)
+}
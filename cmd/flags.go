@@ -19,6 +19,13 @@ const (
 	fgAppTor
 	fgAppSources
 	fgAppVersion
+	fgAppHistory
+	fgAppExport
+	fgAppDB
+	fgAppDBMigrate
+	fgAppReport
+	fgAppPortfolio
+	fgAppDaemon
 )
 
 // Names of the command line arguments (flagx names)
@@ -35,6 +42,19 @@ const (
 	namesBuildOptions = "build-options,b"
 	namesOutput       = "output,o"
 	namesForce        = "force,f"
+	namesFrom         = "from"
+	namesTo           = "to"
+	namesNoCache      = "no-cache"
+	namesCacheTTL     = "cache-ttl"
+	namesDeadline     = "deadline"
+	namesFormat       = "format"
+	namesColumns      = "columns"
+	namesLast         = "last"
+	namesStatus       = "status"
+	namesSuggest      = "suggest"
+	namesBest         = "best"
+	namesCurrency     = "currency"
+	namesFetch        = "fetch"
 )
 
 // Default args value
@@ -54,8 +74,27 @@ type Flags struct {
 	workers    int
 	mode       string
 
-	output string
-	force  bool
+	output  string
+	force   bool
+	format  string
+	columns []string
+	last    bool
+	status  string
+	suggest bool
+	best    bool
+
+	currency string
+	fetch    bool
+
+	address string
+	folder  string
+
+	from string
+	to   string
+
+	noCache  bool
+	cacheTTL string
+	deadline string
 
 	flagSet  *flag.FlagSet
 	fullname string
@@ -67,24 +106,120 @@ func NewFlags(fullname string, flagsgroup flagsGroup) *Flags {
 	   - help (implicit)
 
 	   GET
+	   - best
+	   - cache-ttl
+	   - columns
 	   - config
 	   - config-type
+	   - currency
 	   - database
+	   - deadline
 	   - dry-run
 	   - force
+	   - format
 	   - isins
 	   - mode
+	   - no-cache
 	   - output
 	   - proxy
 	   - sources
 	   - workers
 
+	   HISTORY
+	   - cache-ttl
+	   - config
+	   - config-type
+	   - database
+	   - deadline
+	   - dry-run
+	   - force
+	   - from
+	   - isins
+	   - no-cache
+	   - output
+	   - proxy
+	   - sources
+	   - to
+
+	   EXPORT
+	   - columns
+	   - config
+	   - config-type
+	   - database
+	   - force
+	   - format
+	   - from
+	   - isins
+	   - last
+	   - output
+	   - to
+
+	   DAEMON
+	   - address
+	   - cache-ttl
+	   - config
+	   - config-type
+	   - database
+	   - deadline
+	   - dry-run
+	   - folder
+	   - no-cache
+	   - proxy
+
+	   DB
+	   - best
+	   - columns
+	   - config
+	   - config-type
+	   - database
+	   - force
+	   - format
+	   - from
+	   - isins
+	   - last
+	   - output
+	   - sources
+	   - status
+	   - to
+
+	   DB MIGRATE
+	   - config
+	   - config-type
+	   - database
+	   - dry-run
+
+	   PORTFOLIO
+	   - cache-ttl
+	   - config
+	   - config-type
+	   - currency
+	   - database
+	   - deadline
+	   - fetch
+	   - force
+	   - format
+	   - no-cache
+	   - output
+	   - proxy
+
+	   REPORT SOURCES
+	   - config
+	   - config-type
+	   - database
+	   - from
+	   - isins
+	   - sources
+	   - suggest
+	   - to
+
 	   TOR
 	   - config
 	   - config-type
 	   - proxy
 
 	   SOURCES
+	   - config
+	   - config-type
 
 	   VERSION
 
@@ -101,14 +236,27 @@ func NewFlags(fullname string, flagsgroup flagsGroup) *Flags {
 
 	// flags common to all operation
 
-	// flags for Get or Tor operation
-	if flagsgroup == fgAppGet || flagsgroup == fgAppTor {
+	// flags for Get, History, Tor, Sources, Export, DB, DB Migrate, Report, Portfolio or Daemon operation
+	if flagsgroup == fgAppGet || flagsgroup == fgAppHistory || flagsgroup == fgAppTor || flagsgroup == fgAppSources ||
+		flagsgroup == fgAppExport || flagsgroup == fgAppDB || flagsgroup == fgAppDBMigrate || flagsgroup == fgAppReport ||
+		flagsgroup == fgAppPortfolio || flagsgroup == fgAppDaemon {
 		flagx.AliasedStringVar(fs, &flags.config, namesConfig, "", "")
 		flagx.AliasedStringVar(fs, &flags.configType, namesConfigType, "", "")
+	}
 
+	// flags for Get, History, Tor, Portfolio or Daemon operation
+	if flagsgroup == fgAppGet || flagsgroup == fgAppHistory || flagsgroup == fgAppTor || flagsgroup == fgAppPortfolio ||
+		flagsgroup == fgAppDaemon {
 		flagx.AliasedStringVar(fs, &flags.proxy, namesProxy, "", "")
 	}
 
+	// flags for Get, History, Portfolio or Daemon operation
+	if flagsgroup == fgAppGet || flagsgroup == fgAppHistory || flagsgroup == fgAppPortfolio || flagsgroup == fgAppDaemon {
+		flagx.AliasedBoolVar(fs, &flags.noCache, namesNoCache, false, "")
+		flagx.AliasedStringVar(fs, &flags.cacheTTL, namesCacheTTL, "", "")
+		flagx.AliasedStringVar(fs, &flags.deadline, namesDeadline, "", "")
+	}
+
 	// flags only for Get operation
 	if flagsgroup == fgAppGet {
 
@@ -121,7 +269,94 @@ func NewFlags(fullname string, flagsgroup flagsGroup) *Flags {
 
 		flagx.AliasedBoolVar(fs, &flags.force, namesForce, false, "")
 		flagx.AliasedStringVar(fs, &flags.output, namesOutput, "", "")
+		flagx.AliasedStringVar(fs, &flags.format, namesFormat, "", "")
+		flagx.AliasedStringsVar(fs, &flags.columns, namesColumns, "")
+		flagx.AliasedBoolVar(fs, &flags.best, namesBest, false, "")
+		flagx.AliasedStringVar(fs, &flags.currency, namesCurrency, "", "")
+
+	}
+
+	// flags only for History operation
+	if flagsgroup == fgAppHistory {
+		flagx.AliasedBoolVar(fs, &flags.dryrun, namesDryrun, false, "")
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedStringsVar(fs, &flags.isins, namesIsins, "")
+		flagx.AliasedStringsVar(fs, &flags.sources, namesSources, "")
+
+		flagx.AliasedBoolVar(fs, &flags.force, namesForce, false, "")
+		flagx.AliasedStringVar(fs, &flags.output, namesOutput, "", "")
+
+		flagx.AliasedStringVar(fs, &flags.from, namesFrom, "", "")
+		flagx.AliasedStringVar(fs, &flags.to, namesTo, "", "")
+	}
+
+	// flags only for Export operation
+	if flagsgroup == fgAppExport {
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedStringsVar(fs, &flags.isins, namesIsins, "")
+		flagx.AliasedBoolVar(fs, &flags.last, namesLast, false, "")
+
+		flagx.AliasedBoolVar(fs, &flags.force, namesForce, false, "")
+		flagx.AliasedStringVar(fs, &flags.output, namesOutput, "", "")
+		flagx.AliasedStringVar(fs, &flags.format, namesFormat, "", "")
+		flagx.AliasedStringsVar(fs, &flags.columns, namesColumns, "")
+
+		flagx.AliasedStringVar(fs, &flags.from, namesFrom, "", "")
+		flagx.AliasedStringVar(fs, &flags.to, namesTo, "", "")
+	}
+
+	// flags only for DB operation
+	if flagsgroup == fgAppDB {
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedStringsVar(fs, &flags.isins, namesIsins, "")
+		flagx.AliasedStringsVar(fs, &flags.sources, namesSources, "")
+		flagx.AliasedBoolVar(fs, &flags.last, namesLast, false, "")
+		flagx.AliasedStringVar(fs, &flags.status, namesStatus, "", "")
+		flagx.AliasedBoolVar(fs, &flags.best, namesBest, false, "")
+
+		flagx.AliasedBoolVar(fs, &flags.force, namesForce, false, "")
+		flagx.AliasedStringVar(fs, &flags.output, namesOutput, "", "")
+		flagx.AliasedStringVar(fs, &flags.format, namesFormat, "", "")
+		flagx.AliasedStringsVar(fs, &flags.columns, namesColumns, "")
+
+		flagx.AliasedStringVar(fs, &flags.from, namesFrom, "", "")
+		flagx.AliasedStringVar(fs, &flags.to, namesTo, "", "")
+	}
+
+	// flags only for DB Migrate operation
+	if flagsgroup == fgAppDBMigrate {
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedBoolVar(fs, &flags.dryrun, namesDryrun, false, "")
+	}
+
+	// flags only for Report operation
+	if flagsgroup == fgAppReport {
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedStringsVar(fs, &flags.isins, namesIsins, "")
+		flagx.AliasedStringsVar(fs, &flags.sources, namesSources, "")
+		flagx.AliasedBoolVar(fs, &flags.suggest, namesSuggest, false, "")
+
+		flagx.AliasedStringVar(fs, &flags.from, namesFrom, "", "")
+		flagx.AliasedStringVar(fs, &flags.to, namesTo, "", "")
+	}
+
+	// flags only for Portfolio operation
+	if flagsgroup == fgAppPortfolio {
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedBoolVar(fs, &flags.fetch, namesFetch, false, "")
+		flagx.AliasedStringVar(fs, &flags.currency, namesCurrency, "", "")
+
+		flagx.AliasedBoolVar(fs, &flags.force, namesForce, false, "")
+		flagx.AliasedStringVar(fs, &flags.output, namesOutput, "", "")
+		flagx.AliasedStringVar(fs, &flags.format, namesFormat, "", "")
+	}
 
+	// flags only for Daemon operation
+	if flagsgroup == fgAppDaemon {
+		flagx.AliasedStringVar(fs, &flags.database, namesDatabase, "", "")
+		flagx.AliasedBoolVar(fs, &flags.dryrun, namesDryrun, false, "")
+		flagx.AliasedStringVar(fs, &flags.address, namesAddress, "", "")
+		flagx.AliasedStringVar(fs, &flags.folder, namesFolder, "", "")
 	}
 
 	// flags only for Version operation
@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/PuerkitoBio/goquery v1.8.0
 	github.com/google/go-cmp v0.5.8
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.12
 	github.com/mmbros/flagx v0.1.1
 	github.com/mmbros/taskengine v0.4.0
@@ -0,0 +1,234 @@
+// Package alert evaluates the alert rules of the isins on their last quotes
+// and sends the triggered alerts by the notifiers.
+//
+// The rules of an isin are checked on the last quote of the isin
+// and on the previous close, i.e. the last quote of a previous day.
+// The available notifiers print the alerts on the standard output,
+// pass them to a shell command, post them to a webhook or send them by email.
+package alert
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+// Rule kinds
+const (
+	RuleAbove   = "above"
+	RuleBelow   = "below"
+	RuleChange  = "change"
+	RuleMissing = "missing"
+)
+
+// Rules are the alert rules of an isin. A zero value disables the rule.
+//
+// Above and Below are the thresholds of the price, in the currency of the quotes.
+// Change is the max percent change of the price from the previous close (es. 5 is ±5%).
+// Missing is the max number of days since the date of the last quote.
+type Rules struct {
+	Above   float64
+	Below   float64
+	Change  float64
+	Missing int
+}
+
+// State is the input of the rules of an isin:
+// the last quote and the previous close (nil if not known).
+type State struct {
+	Isin     string
+	Name     string
+	Last     *quotes.Result
+	Previous *quotes.Result
+}
+
+// Alert is a triggered rule.
+// Value is the threshold of the rule, and Change is the percent change
+// of the price from the previous close (only for the change rule).
+type Alert struct {
+	Isin     string     `json:"isin"`
+	Name     string     `json:"name,omitempty"`
+	Rule     string     `json:"rule"`
+	Value    float64    `json:"value"`
+	Price    float64    `json:"price,omitempty"`
+	Currency string     `json:"currency,omitempty"`
+	Date     *time.Time `json:"date,omitempty"`
+	Change   float64    `json:"change,omitempty"`
+	Message  string     `json:"message"`
+}
+
+func day(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// NewStates returns the state of each isin of the successful results:
+// the last quote (see quotes.Last) and the last quote of a previous day.
+func NewStates(results []*quotes.Result) map[string]*State {
+	last := quotes.Last(results)
+
+	var older []*quotes.Result
+	for _, r := range results {
+		if l := last[r.Isin]; l != nil && day(r.Date) < day(l.Date) {
+			older = append(older, r)
+		}
+	}
+	previous := quotes.Last(older)
+
+	states := make(map[string]*State, len(last))
+	for isin, r := range last {
+		states[isin] = &State{Isin: isin, Last: r, Previous: previous[isin]}
+	}
+	return states
+}
+
+// formatFloat formats the price without the trailing zeros.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// title returns the isin followed by its name, if defined.
+func (s *State) title() string {
+	if s.Name == "" {
+		return s.Isin
+	}
+	return s.Isin + " (" + s.Name + ")"
+}
+
+// Evaluate returns the alerts of the rules triggered by the state at the time now.
+// A nil state, or a state without the last quote, triggers only the missing rule.
+func (rules *Rules) Evaluate(s *State, now time.Time) []*Alert {
+	if rules == nil || s == nil {
+		return nil
+	}
+	var alerts []*Alert
+
+	r := s.Last
+	if r == nil {
+		if rules.Missing > 0 {
+			alerts = append(alerts, &Alert{
+				Isin:    s.Isin,
+				Name:    s.Name,
+				Rule:    RuleMissing,
+				Value:   float64(rules.Missing),
+				Message: fmt.Sprintf("%s: no quote found", s.title()),
+			})
+		}
+		return alerts
+	}
+
+	newAlert := func(rule string, value float64, format string, a ...any) *Alert {
+		return &Alert{
+			Isin:     s.Isin,
+			Name:     s.Name,
+			Rule:     rule,
+			Value:    value,
+			Price:    r.Price,
+			Currency: r.Currency,
+			Date:     r.Date,
+			Message:  s.title() + ": " + fmt.Sprintf(format, a...),
+		}
+	}
+	price := formatFloat(r.Price) + " " + r.Currency
+
+	if rules.Above > 0 && r.Price > rules.Above {
+		alerts = append(alerts, newAlert(RuleAbove, rules.Above,
+			"price %s above %s (%s)", price, formatFloat(rules.Above), day(r.Date)))
+	}
+	if rules.Below > 0 && r.Price < rules.Below {
+		alerts = append(alerts, newAlert(RuleBelow, rules.Below,
+			"price %s below %s (%s)", price, formatFloat(rules.Below), day(r.Date)))
+	}
+	if p := s.Previous; rules.Change > 0 && p != nil && p.Price != 0 && p.Currency == r.Currency {
+		change := 100 * (r.Price - p.Price) / p.Price
+		if math.Abs(change) >= rules.Change {
+			a := newAlert(RuleChange, rules.Change,
+				"price %s changed by %+.2f%% from the previous close %s (%s)",
+				price, change, formatFloat(p.Price), day(p.Date))
+			a.Change = change
+			alerts = append(alerts, a)
+		}
+	}
+	if rules.Missing > 0 && r.Date != nil {
+		y, m, d := r.Date.Date()
+		last := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		y, m, d = now.Date()
+		today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if days := int(today.Sub(last).Hours() / 24); days >= rules.Missing {
+			alerts = append(alerts, newAlert(RuleMissing, float64(rules.Missing),
+				"no quote since %s (%d days)", day(r.Date), days))
+		}
+	}
+	return alerts
+}
+
+// Evaluate returns the alerts of the rules of each isin, ordered by isin.
+// The isins without a state trigger only the missing rule.
+func Evaluate(rules map[string]*Rules, states map[string]*State, names map[string]string, now time.Time) []*Alert {
+	isins := make([]string, 0, len(rules))
+	for isin := range rules {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+
+	var alerts []*Alert
+	for _, isin := range isins {
+		s := State{Isin: isin}
+		if st := states[isin]; st != nil {
+			s = *st
+		}
+		s.Name = names[isin]
+		alerts = append(alerts, rules[isin].Evaluate(&s, now)...)
+	}
+	return alerts
+}
+
+// Key identifies an alert rule of an isin.
+type Key struct {
+	Isin string
+	Rule string
+}
+
+// Key returns the key of the rule of the alert.
+func (a *Alert) Key() Key {
+	return Key{a.Isin, a.Rule}
+}
+
+// Changed returns the alerts of the rules not triggered in the previous
+// evaluation, whose triggered rules are the keys of previous:
+// a rule is notified only when it becomes triggered, and again only after
+// a later evaluation in which it is not triggered.
+func Changed(alerts []*Alert, previous map[Key]bool) []*Alert {
+	var changed []*Alert
+	for _, a := range alerts {
+		if !previous[a.Key()] {
+			changed = append(changed, a)
+		}
+	}
+	return changed
+}
+
+// Text returns the messages of the alerts, one per line.
+func Text(alerts []*Alert) string {
+	var b strings.Builder
+	for _, a := range alerts {
+		b.WriteString(a.Message)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Subject returns the subject of the notification of the alerts.
+func Subject(alerts []*Alert) string {
+	if len(alerts) == 1 {
+		return "quotes: 1 alert"
+	}
+	return fmt.Sprintf("quotes: %d alerts", len(alerts))
+}
@@ -0,0 +1,122 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func result(isin string, day int, price float64, best bool) *quotes.Result {
+	date := time.Date(2026, time.October, day, 0, 0, 0, 0, time.UTC)
+	return &quotes.Result{Isin: isin, Date: &date, Price: price, Currency: "EUR", Best: best}
+}
+
+func TestNewStates(t *testing.T) {
+	states := NewStates([]*quotes.Result{
+		result("isin1", 14, 90, true),
+		result("isin1", 15, 100, false),
+		result("isin1", 15, 101, true),
+		result("isin1", 16, 110, true),
+		result("isin2", 16, 20, true),
+	})
+	require.Len(t, states, 2)
+
+	s := states["isin1"]
+	assert.Equal(t, 110.0, s.Last.Price)
+	assert.Equal(t, 101.0, s.Previous.Price)
+
+	s = states["isin2"]
+	assert.Equal(t, 20.0, s.Last.Price)
+	assert.Nil(t, s.Previous)
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rules    *Rules
+		state    *State
+		messages []string
+	}{
+		{
+			name:  "above and change",
+			rules: &Rules{Above: 105, Below: 50, Change: 5},
+			state: &State{Isin: "isin1", Name: "World", Last: result("isin1", 16, 110, true), Previous: result("isin1", 15, 100, true)},
+			messages: []string{
+				"isin1 (World): price 110 EUR above 105 (2026-10-16)",
+				"isin1 (World): price 110 EUR changed by +10.00% from the previous close 100 (2026-10-15)",
+			},
+		},
+		{
+			name:     "below",
+			rules:    &Rules{Below: 50, Change: 20},
+			state:    &State{Isin: "isin1", Last: result("isin1", 16, 45, true), Previous: result("isin1", 15, 50, true)},
+			messages: []string{"isin1: price 45 EUR below 50 (2026-10-16)"},
+		},
+		{
+			name:     "change without previous close",
+			rules:    &Rules{Change: 1},
+			state:    &State{Isin: "isin1", Last: result("isin1", 16, 45, true)},
+			messages: nil,
+		},
+		{
+			name:     "missing",
+			rules:    &Rules{Missing: 3},
+			state:    &State{Isin: "isin1", Last: result("isin1", 13, 45, true)},
+			messages: []string{"isin1: no quote since 2026-10-13 (4 days)"},
+		},
+		{
+			name:     "not missing",
+			rules:    &Rules{Missing: 3},
+			state:    &State{Isin: "isin1", Last: result("isin1", 15, 45, true)},
+			messages: nil,
+		},
+		{
+			name:     "no quote",
+			rules:    &Rules{Above: 1, Missing: 3},
+			state:    &State{Isin: "isin1"},
+			messages: []string{"isin1: no quote found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			for _, a := range tt.rules.Evaluate(tt.state, now) {
+				messages = append(messages, a.Message)
+			}
+			assert.Equal(t, tt.messages, messages)
+		})
+	}
+
+	// the isins without state
+	alerts := Evaluate(
+		map[string]*Rules{"isin2": {Missing: 1}, "isin1": {Above: 100}},
+		map[string]*State{"isin1": {Isin: "isin1", Last: result("isin1", 16, 110, true)}},
+		map[string]string{"isin2": "Bond"},
+		now)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, RuleAbove, alerts[0].Rule)
+	assert.Equal(t, "isin2 (Bond): no quote found", alerts[1].Message)
+	assert.Equal(t, "quotes: 2 alerts", Subject(alerts))
+}
+
+func TestChanged(t *testing.T) {
+	alerts := []*Alert{
+		{Isin: "isin1", Rule: RuleAbove},
+		{Isin: "isin1", Rule: RuleChange},
+		{Isin: "isin2", Rule: RuleMissing},
+	}
+	assert.Equal(t, alerts, Changed(alerts, nil))
+
+	previous := map[Key]bool{
+		{"isin1", RuleAbove}: true,
+		{"isin1", RuleBelow}: true,
+		{"isin2", RuleAbove}: true,
+	}
+	assert.Equal(t, []*Alert{alerts[1], alerts[2]}, Changed(alerts, previous))
+}
@@ -0,0 +1,287 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+)
+
+// Notifier sends the triggered alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alerts []*Alert) error
+}
+
+// NotifierConfig defines a notifier. Type is the name of the notifier,
+// the other params are used only by some types:
+//
+//   - stdout: no params;
+//   - command: Command is the shell command;
+//   - webhook: URL, Headers and Proxy of the http POST request;
+//   - smtp: Host, Port (default 25), Username and Password (optional),
+//     From and To of the email.
+type NotifierConfig struct {
+	Type     string            `json:"type,omitempty"`
+	Command  string            `json:"command,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Proxy    string            `json:"proxy,omitempty"`
+	Host     string            `json:"host,omitempty"`
+	Port     int               `json:"port,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	From     string            `json:"from,omitempty"`
+	To       []string          `json:"to,omitempty"`
+}
+
+// NewNotifierFunc creates a Notifier from its config.
+type NewNotifierFunc func(cfg *NotifierConfig) (Notifier, error)
+
+// Notifiers maps the type of the available notifiers to their NewNotifierFunc.
+var Notifiers = map[string]NewNotifierFunc{
+	"stdout":  func(*NotifierConfig) (Notifier, error) { return NewWriter(os.Stdout), nil },
+	"command": NewCommand,
+	"webhook": NewWebhook,
+	"smtp":    NewSMTP,
+}
+
+// NewNotifier returns the notifier of the config.
+func NewNotifier(cfg *NotifierConfig) (Notifier, error) {
+	if cfg == nil {
+		return nil, errors.New("empty notifier definition")
+	}
+	fn, ok := Notifiers[strings.ToLower(cfg.Type)]
+	if !ok {
+		types := make([]string, 0, len(Notifiers))
+		for t := range Notifiers {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return nil, fmt.Errorf("invalid notifier type %q: accepted values are %s", cfg.Type, strings.Join(types, ", "))
+	}
+	return fn(cfg)
+}
+
+// writer prints the alerts.
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter returns the notifier that prints the messages of the alerts to w.
+func NewWriter(w io.Writer) Notifier {
+	return &writer{w}
+}
+
+func (n *writer) Notify(ctx context.Context, alerts []*Alert) error {
+	_, err := io.WriteString(n.w, Text(alerts))
+	return err
+}
+
+// command passes the alerts to a shell command.
+type command struct {
+	command string
+}
+
+// NewCommand returns the notifier that executes the shell command (sh -c)
+// with the messages of the alerts in the standard input, and the subject
+// and the json of the alerts in the QUOTES_SUBJECT and QUOTES_ALERTS
+// environment variables.
+func NewCommand(cfg *NotifierConfig) (Notifier, error) {
+	if cfg.Command == "" {
+		return nil, errors.New("command not defined")
+	}
+	return &command{cfg.Command}, nil
+}
+
+func (n *command) Notify(ctx context.Context, alerts []*Alert) error {
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.command)
+	cmd.Stdin = strings.NewReader(Text(alerts))
+	cmd.Env = append(os.Environ(),
+		"QUOTES_SUBJECT="+Subject(alerts),
+		"QUOTES_ALERTS="+string(data))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// webhook posts the alerts to an url.
+type webhook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhook returns the notifier that posts the alerts to the url,
+// as a json object with the text of the messages and the list of the alerts:
+//
+//	{"text": "...", "alerts": [{"isin": "...", "rule": "above", ...}]}
+//
+// Any 2xx response status is a success.
+func NewWebhook(cfg *NotifierConfig) (Notifier, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook url not defined")
+	}
+	client, err := quotegetter.DefaultClient(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook{cfg.URL, cfg.Headers, client}, nil
+}
+
+func (n *webhook) Notify(ctx context.Context, alerts []*Alert) error {
+	body, err := json.Marshal(struct {
+		Text   string   `json:"text"`
+		Alerts []*Alert `json:"alerts"`
+	}{Text(alerts), alerts})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: POST response status = %v", resp.Status)
+	}
+	return nil
+}
+
+// smtpNotifier sends the alerts by email.
+type smtpNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewSMTP returns the notifier that sends the alerts by email
+// through the smtp server. The PLAIN authentication is used
+// if the username is defined.
+func NewSMTP(cfg *NotifierConfig) (Notifier, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host not defined")
+	}
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("smtp from and to must be defined")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 25
+	}
+	return &smtpNotifier{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     cfg.From,
+		to:       cfg.To,
+	}, nil
+}
+
+func (n *smtpNotifier) Notify(ctx context.Context, alerts []*Alert) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", Subject(alerts))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(Text(alerts), "\n", "\r\n"))
+
+	if err := n.send(ctx, msg.Bytes()); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+// send sends the message as smtp.SendMail, but the connection
+// is closed when ctx is done.
+func (n *smtpNotifier) send(ctx context.Context, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err = c.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(n.from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,212 @@
+package alert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAlerts() []*Alert {
+	return []*Alert{
+		{Isin: "isin1", Rule: RuleAbove, Value: 100, Price: 110, Currency: "EUR", Message: "isin1: price 110 EUR above 100"},
+		{Isin: "isin2", Rule: RuleMissing, Value: 3, Message: "isin2: no quote found"},
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		cfg    *NotifierConfig
+		errmsg string
+	}{
+		{&NotifierConfig{Type: "stdout"}, ""},
+		{&NotifierConfig{Type: "Command", Command: "true"}, ""},
+		{&NotifierConfig{Type: "command"}, "command not defined"},
+		{&NotifierConfig{Type: "webhook"}, "webhook url not defined"},
+		{&NotifierConfig{Type: "smtp", From: "a@example.com"}, "smtp host not defined"},
+		{&NotifierConfig{Type: "smtp", Host: "localhost", From: "a@example.com"}, "smtp from and to must be defined"},
+		{&NotifierConfig{Type: "sms"}, `invalid notifier type "sms": accepted values are command, smtp, stdout, webhook`},
+		{nil, "empty notifier definition"},
+	}
+	for _, tt := range tests {
+		_, err := NewNotifier(tt.cfg)
+		if tt.errmsg == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.errmsg)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).Notify(context.Background(), testAlerts()))
+	assert.Equal(t, "isin1: price 110 EUR above 100\nisin2: no quote found\n", buf.String())
+}
+
+func TestCommand(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.txt")
+
+	n, err := NewCommand(&NotifierConfig{Command: `cat > "` + out + `"; echo "$QUOTES_SUBJECT" >> "` + out + `"`})
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testAlerts()))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "isin1: price 110 EUR above 100\nisin2: no quote found\nquotes: 2 alerts\n", string(data))
+
+	n, _ = NewCommand(&NotifierConfig{Command: "echo failed; exit 3"})
+	err = n.Notify(context.Background(), testAlerts())
+	assert.EqualError(t, err, "command: exit status 3: failed")
+}
+
+func TestWebhook(t *testing.T) {
+	var (
+		body   []byte
+		header http.Header
+		status = http.StatusNoContent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n, err := NewWebhook(&NotifierConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testAlerts()))
+
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	var msg struct {
+		Text   string   `json:"text"`
+		Alerts []*Alert `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal(body, &msg))
+	assert.Equal(t, "isin1: price 110 EUR above 100\nisin2: no quote found\n", msg.Text)
+	require.Len(t, msg.Alerts, 2)
+	assert.Equal(t, RuleMissing, msg.Alerts[1].Rule)
+
+	status = http.StatusInternalServerError
+	err = n.Notify(context.Background(), testAlerts())
+	assert.EqualError(t, err, "webhook: POST response status = 500 Internal Server Error")
+}
+
+// smtpServer is a minimal smtp server that accepts a single message.
+// The data of the message is sent to the returned channel.
+func smtpServer(t *testing.T) (host string, port int, data <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.Fields(line + " ")[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				reply("354 end with <CRLF>.<CRLF>")
+				var msg strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					msg.WriteString(l)
+				}
+				ch <- msg.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func TestSMTP(t *testing.T) {
+	host, port, data := smtpServer(t)
+
+	n, err := NewSMTP(&NotifierConfig{
+		Host: host,
+		Port: port,
+		From: "quotes@example.com",
+		To:   []string{"user1@example.com", "user2@example.com"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), testAlerts()))
+
+	msg := <-data
+	assert.Contains(t, msg, "From: quotes@example.com\r\n")
+	assert.Contains(t, msg, "To: user1@example.com, user2@example.com\r\n")
+	assert.Contains(t, msg, "Subject: quotes: 2 alerts\r\n")
+	assert.Contains(t, msg, "\r\n\r\nisin1: price 110 EUR above 100\r\nisin2: no quote found\r\n")
+}
+
+func TestSMTPTimeout(t *testing.T) {
+	// the server accepts the connection, but never replies
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	n, err := NewSMTP(&NotifierConfig{
+		Host: addr.IP.String(),
+		Port: addr.Port,
+		From: "quotes@example.com",
+		To:   []string{"user@example.com"},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = n.Notify(ctx, testAlerts())
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,283 @@
+// Package daemon runs the jobs that retrieve the quotes at the activation
+// times of their schedules, and exposes by http the health of the daemon
+// and the status of the last run of each job.
+//
+// The jobs are run one at a time: the activation times elapsed while
+// another job is running are not recovered, and the job is run once
+// as soon as possible.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/quotes/internal/schedule"
+)
+
+// Job retrieves the quotes of the isins at the activation times of the schedule.
+// If Isins is empty, all the isins are retrieved.
+type Job struct {
+	Name     string
+	Schedule *schedule.Schedule
+	Isins    []string
+}
+
+// RunFunc runs the job and returns the retrieved results. The run is
+// canceled by ctx: in that case the results retrieved so far are returned.
+type RunFunc func(ctx context.Context, job *Job) ([]*quotes.Result, error)
+
+// Status is the status of the daemon.
+type Status struct {
+	TimeStart time.Time    `json:"time_start"`
+	Runs      int          `json:"runs"`
+	Jobs      []*JobStatus `json:"jobs"`
+}
+
+// JobStatus is the status of a job: the next activation time
+// and the last run, if any.
+type JobStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Timezone string     `json:"timezone"`
+	Isins    []string   `json:"isins,omitempty"`
+	Running  bool       `json:"running"`
+	Next     *time.Time `json:"next,omitempty"`
+	Last     *RunStatus `json:"last,omitempty"`
+}
+
+// RunStatus is the outcome of a run of a job.
+type RunStatus struct {
+	TimeStart time.Time `json:"time_start"`
+	TimeEnd   time.Time `json:"time_end"`
+	Results   int       `json:"results"`
+	Success   int       `json:"success"`
+	Errors    int       `json:"errors"`
+	Err       string    `json:"error,omitempty"`
+}
+
+// Daemon runs the jobs.
+type Daemon struct {
+	jobs []*Job
+	run  RunFunc
+	w    io.Writer
+
+	// now returns the current time (used by the tests)
+	now func() time.Time
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New returns the daemon of the jobs. The messages of the daemon
+// are printed to w, prefixed by the current time.
+func New(jobs []*Job, run RunFunc, w io.Writer) *Daemon {
+	d := &Daemon{
+		jobs: jobs,
+		run:  run,
+		w:    w,
+		now:  time.Now,
+	}
+	for _, job := range jobs {
+		d.status.Jobs = append(d.status.Jobs, &JobStatus{
+			Name:     job.Name,
+			Schedule: job.Schedule.String(),
+			Timezone: job.Schedule.Location().String(),
+			Isins:    job.Isins,
+		})
+	}
+	return d
+}
+
+func (d *Daemon) printf(format string, a ...any) {
+	fmt.Fprintf(d.w, "%s %s\n", d.now().Format(time.RFC3339), fmt.Sprintf(format, a...))
+}
+
+// setNext sets the next activation time of the job after t.
+// It returns false if the job will not be activated anymore.
+func (d *Daemon) setNext(j int, t time.Time) bool {
+	next := d.jobs[j].Schedule.Next(t)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if next.IsZero() {
+		d.status.Jobs[j].Next = nil
+		return false
+	}
+	d.status.Jobs[j].Next = &next
+	return true
+}
+
+// due returns the index of the job with the first activation time.
+// It returns -1 if no job will be activated.
+func (d *Daemon) due() (int, time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	idx, at := -1, time.Time{}
+	for j, js := range d.status.Jobs {
+		if js.Next != nil && (idx < 0 || js.Next.Before(at)) {
+			idx, at = j, *js.Next
+		}
+	}
+	return idx, at
+}
+
+// Run runs the jobs at their activation times, until ctx is canceled.
+// A running job is canceled by ctx too.
+func (d *Daemon) Run(ctx context.Context) error {
+	start := d.now()
+	d.mu.Lock()
+	d.status.TimeStart = start
+	d.mu.Unlock()
+
+	for j, job := range d.jobs {
+		if d.setNext(j, start) {
+			d.printf("job %q: next run at %s", job.Name, d.status.Jobs[j].Next.Format(time.RFC3339))
+		} else {
+			d.printf("job %q: no activation time", job.Name)
+		}
+	}
+
+	for {
+		j, at := d.due()
+		if j < 0 {
+			d.printf("no job to run")
+			<-ctx.Done()
+			return nil
+		}
+
+		timer := time.NewTimer(at.Sub(d.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		d.runJob(ctx, j)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if d.setNext(j, d.now()) {
+			d.printf("job %q: next run at %s", d.jobs[j].Name, d.status.Jobs[j].Next.Format(time.RFC3339))
+		}
+	}
+}
+
+// runJob runs the j-th job and updates its status.
+func (d *Daemon) runJob(ctx context.Context, j int) {
+	job := d.jobs[j]
+	rs := &RunStatus{TimeStart: d.now()}
+
+	d.mu.Lock()
+	d.status.Jobs[j].Running = true
+	d.mu.Unlock()
+
+	d.printf("job %q: run started", job.Name)
+	results, err := d.run(ctx, job)
+
+	rs.TimeEnd = d.now()
+	rs.Results = len(results)
+	for _, r := range results {
+		if r.Err == nil {
+			rs.Success++
+		} else {
+			rs.Errors++
+		}
+	}
+	if err != nil {
+		rs.Err = err.Error()
+		d.printf("job %q: run failed: %v", job.Name, err)
+	} else {
+		d.printf("job %q: run completed: %d results (%d success, %d errors)", job.Name, rs.Results, rs.Success, rs.Errors)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.Runs++
+	d.status.Jobs[j].Running = false
+	d.status.Jobs[j].Last = rs
+}
+
+// Status returns a copy of the current status of the daemon.
+func (d *Daemon) Status() *Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := d.status
+	s.Jobs = make([]*JobStatus, len(d.status.Jobs))
+	for j, js := range d.status.Jobs {
+		c := *js
+		if js.Next != nil {
+			next := *js.Next
+			c.Next = &next
+		}
+		if js.Last != nil {
+			last := *js.Last
+			c.Last = &last
+		}
+		s.Jobs[j] = &c
+	}
+	return &s
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// Health values of the daemon.
+const (
+	HealthOK       = "ok"       // the last run of each job succeeded
+	HealthDegraded = "degraded" // the last run of some job failed
+)
+
+// Health is the health of the daemon: degraded if the last run
+// of some job failed, with the errors of the jobs by name.
+type Health struct {
+	Status string            `json:"status"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Health returns the health of the daemon.
+func (d *Daemon) Health() *Health {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := &Health{Status: HealthOK}
+	for _, js := range d.status.Jobs {
+		if js.Last != nil && js.Last.Err != "" {
+			if h.Errors == nil {
+				h.Errors = map[string]string{}
+			}
+			h.Errors[js.Name] = js.Last.Err
+			h.Status = HealthDegraded
+		}
+	}
+	return h
+}
+
+// HandleHealth responds with the json of the health of the daemon.
+// The status code is 503 Service Unavailable if the health is degraded.
+func (d *Daemon) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	h := d.Health()
+	code := http.StatusOK
+	if h.Status != HealthOK {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, h)
+}
+
+// HandleStatus responds with the json of the status of the daemon.
+func (d *Daemon) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, d.Status())
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/quotes/internal/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	sched, err := schedule.Parse("30 18 * * 1-5", time.UTC)
+	require.NoError(t, err)
+	never, err := schedule.Parse("0 0 30 2 *", time.UTC)
+	require.NoError(t, err)
+
+	jobs := []*Job{
+		{Name: "close", Schedule: sched, Isins: []string{"isin1"}},
+		{Name: "never", Schedule: never},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var runs []string
+	run := func(ctx context.Context, job *Job) ([]*quotes.Result, error) {
+		runs = append(runs, job.Name)
+		if len(runs) == 2 {
+			cancel()
+			return nil, errors.New("interrupted")
+		}
+		return []*quotes.Result{
+			{Isin: "isin1", Source: "source1"},
+			{Isin: "isin1", Source: "source2", Err: errors.New("not found")},
+		}, nil
+	}
+
+	var out strings.Builder
+	d := New(jobs, run, &out)
+	// 50ms before the activation time of the job
+	now := time.Date(2026, time.October, 16, 18, 29, 59, 950e6, time.UTC)
+	d.now = func() time.Time { return now }
+
+	require.NoError(t, d.Run(ctx))
+	assert.Equal(t, []string{"close", "close"}, runs)
+
+	s := d.Status()
+	assert.Equal(t, now, s.TimeStart)
+	assert.Equal(t, 2, s.Runs)
+	require.Len(t, s.Jobs, 2)
+
+	js := s.Jobs[0]
+	assert.Equal(t, "30 18 * * 1-5", js.Schedule)
+	assert.Equal(t, "UTC", js.Timezone)
+	assert.False(t, js.Running)
+	require.NotNil(t, js.Next)
+	assert.Equal(t, time.Date(2026, time.October, 16, 18, 30, 0, 0, time.UTC), *js.Next)
+	require.NotNil(t, js.Last)
+	assert.Equal(t, "interrupted", js.Last.Err)
+
+	h := d.Health()
+	assert.Equal(t, HealthDegraded, h.Status)
+	assert.Equal(t, map[string]string{"close": "interrupted"}, h.Errors)
+
+	assert.Nil(t, s.Jobs[1].Next)
+	assert.Nil(t, s.Jobs[1].Last)
+
+	assert.Contains(t, out.String(), `job "close": run completed: 2 results (1 success, 1 errors)`)
+	assert.Contains(t, out.String(), `job "close": run failed: interrupted`)
+	assert.Contains(t, out.String(), `job "never": no activation time`)
+}
+
+func TestHandlers(t *testing.T) {
+	sched, err := schedule.Parse("@daily", time.UTC)
+	require.NoError(t, err)
+	d := New([]*Job{{Name: "daily", Schedule: sched}}, nil, &strings.Builder{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- d.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", d.HandleHealth)
+	mux.HandleFunc("/status", d.HandleStatus)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/health")
+	require.NoError(t, err)
+	var health Health
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, Health{Status: "ok"}, health)
+
+	require.Eventually(t, func() bool { return d.Status().Jobs[0].Next != nil }, time.Second, 10*time.Millisecond)
+
+	resp, err = http.Get(ts.URL + "/status")
+	require.NoError(t, err)
+	var s Status
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&s))
+	resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Len(t, s.Jobs, 1)
+	assert.Equal(t, "daily", s.Jobs[0].Name)
+	assert.Equal(t, "@daily", s.Jobs[0].Schedule)
+	require.NotNil(t, s.Jobs[0].Next)
+	assert.True(t, s.Jobs[0].Next.After(time.Now()))
+	assert.Nil(t, s.Jobs[0].Last)
+
+	// the failure of the last run of a job
+	d.mu.Lock()
+	d.status.Jobs[0].Last = &RunStatus{Err: "database locked"}
+	d.mu.Unlock()
+	resp, err = http.Get(ts.URL + "/health")
+	require.NoError(t, err)
+	health = Health{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, Health{Status: "degraded", Errors: map[string]string{"daily": "database locked"}}, health)
+}
@@ -0,0 +1,249 @@
+// Package export writes the results of the quotes in different formats.
+//
+// The json and jsonl formats write all the fields of the results,
+// while the csv and tsv formats write only the selected columns.
+// The ledger and beancount formats write the price directives
+// of the successful results, for plain text accounting,
+// while the gnucash format writes a csv file for the GnuCash price importer.
+// The table format writes the selected columns in a human readable table.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+// Format is the output format of the results.
+type Format string
+
+// Available formats
+const (
+	JSON      Format = "json"
+	JSONL     Format = "jsonl"
+	CSV       Format = "csv"
+	TSV       Format = "tsv"
+	Ledger    Format = "ledger"
+	Beancount Format = "beancount"
+	GnuCash   Format = "gnucash"
+	Table     Format = "table"
+)
+
+// Options of the output.
+//
+// Columns are used by the csv, tsv and table formats:
+// if empty, the DefaultColumns are used.
+// Commodities maps an isin to the commodity symbol of the price directives
+// of the ledger, beancount and gnucash formats: if not found, the isin is used.
+// In the gnucash format, the symbol can be prefixed by the namespace
+// (es. "FUND:VWCE"), otherwise the Namespace is used (DefaultNamespace if empty).
+type Options struct {
+	Columns     []string
+	Commodities map[string]string
+	Namespace   string
+}
+
+// writeFunc writes the results with the given options.
+type writeFunc func(w io.Writer, results []*quotes.Result, opts *Options) error
+
+// writers returns the write function of each format.
+var writers = map[Format]writeFunc{
+	JSON:      writeJSON,
+	JSONL:     writeJSONL,
+	CSV:       newWriteSeparated(','),
+	TSV:       newWriteSeparated('\t'),
+	Ledger:    writeLedger,
+	Beancount: writeBeancount,
+	GnuCash:   writeGnuCash,
+	Table:     writeTable,
+}
+
+// extensions returns the format of each file extension.
+var extensions = map[string]Format{
+	".json":      JSON,
+	".jsonl":     JSONL,
+	".ndjson":    JSONL,
+	".csv":       CSV,
+	".tsv":       TSV,
+	".tab":       TSV,
+	".ledger":    Ledger,
+	".journal":   Ledger,
+	".hledger":   Ledger,
+	".beancount": Beancount,
+	".bean":      Beancount,
+}
+
+// Formats returns the sorted names of the available formats.
+func Formats() []string {
+	names := make([]string, 0, len(writers))
+	for f := range writers {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFormat returns the format with the given name (case insensitive).
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(name))
+	if _, ok := writers[f]; !ok {
+		return "", fmt.Errorf("invalid format %q: accepted values are %s", name, strings.Join(Formats(), ", "))
+	}
+	return f, nil
+}
+
+// FormatFromPath returns the format corresponding to the extension of the path.
+// It returns false if the extension is unknown.
+func FormatFromPath(path string) (Format, bool) {
+	f, ok := extensions[strings.ToLower(filepath.Ext(path))]
+	return f, ok
+}
+
+// column returns the string value of a column of the result.
+type column func(r *quotes.Result) string
+
+// columns returns the column function by name.
+var columns = map[string]column{
+	"isin":     func(r *quotes.Result) string { return r.Isin },
+	"source":   func(r *quotes.Result) string { return r.Source },
+	"instance": func(r *quotes.Result) string { return strconv.Itoa(r.Instance) },
+	"url":      func(r *quotes.Result) string { return r.URL },
+	"price": func(r *quotes.Result) string {
+		if r.Price == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(r.Price, 'f', -1, 64)
+	},
+	"price_raw": func(r *quotes.Result) string { return r.PriceRaw },
+	"currency":  func(r *quotes.Result) string { return r.Currency },
+	"converted_price": func(r *quotes.Result) string {
+		if r.ConvertedPrice == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(r.ConvertedPrice, 'f', -1, 64)
+	},
+	"converted_currency": func(r *quotes.Result) string { return r.ConvertedCurrency },
+	"date": func(r *quotes.Result) string {
+		if r.Date == nil || r.Date.IsZero() {
+			return ""
+		}
+		return r.Date.Format("2006-01-02")
+	},
+	"status": func(r *quotes.Result) string { return r.Status.String() },
+	"error": func(r *quotes.Result) string {
+		if r.Err == nil {
+			return ""
+		}
+		return r.Err.Error()
+	},
+	"attempts":  func(r *quotes.Result) string { return strconv.Itoa(r.Attempts) },
+	"consensus": func(r *quotes.Result) string { return r.Consensus },
+	"best": func(r *quotes.Result) string {
+		if !r.Best {
+			return ""
+		}
+		return "*"
+	},
+	"time_start": func(r *quotes.Result) string { return r.TimeStart.Format(time.RFC3339) },
+	"time_end":   func(r *quotes.Result) string { return r.TimeEnd.Format(time.RFC3339) },
+	"elapsed": func(r *quotes.Result) string {
+		// seconds, with milliseconds precision
+		return strconv.FormatFloat(r.TimeEnd.Sub(r.TimeStart).Seconds(), 'f', 3, 64)
+	},
+}
+
+// DefaultColumns are the columns used if no column is selected.
+var DefaultColumns = []string{"isin", "source", "price", "currency", "date", "status", "error"}
+
+// Columns returns the sorted names of the available columns.
+func Columns() []string {
+	names := make([]string, 0, len(columns))
+	for c := range columns {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckColumns checks that all the columns are available.
+func CheckColumns(names []string) error {
+	for _, c := range names {
+		if _, ok := columns[c]; !ok {
+			return fmt.Errorf("invalid column %q: accepted values are %s", c, strings.Join(Columns(), ", "))
+		}
+	}
+	return nil
+}
+
+// Write writes the results to w in the given format.
+// opts can be nil.
+func Write(w io.Writer, results []*quotes.Result, format Format, opts *Options) error {
+	write, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("invalid format %q", format)
+	}
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Columns) == 0 {
+		o.Columns = DefaultColumns
+	}
+	if err := CheckColumns(o.Columns); err != nil {
+		return err
+	}
+	return write(w, results, &o)
+}
+
+func writeJSON(w io.Writer, results []*quotes.Result, _ *Options) error {
+	bytes, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes)
+	return err
+}
+
+// writeJSONL writes a json object for each line.
+func writeJSONL(w io.Writer, results []*quotes.Result, _ *Options) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newWriteSeparated returns the write function
+// of the values separated by the comma rune, with an header line.
+func newWriteSeparated(comma rune) writeFunc {
+	return func(w io.Writer, results []*quotes.Result, opts *Options) error {
+		names := opts.Columns
+		cw := csv.NewWriter(w)
+		cw.Comma = comma
+
+		if err := cw.Write(names); err != nil {
+			return err
+		}
+		record := make([]string, len(names))
+		for _, r := range results {
+			for j, c := range names {
+				record[j] = columns[c](r)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
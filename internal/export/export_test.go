@@ -0,0 +1,188 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/taskengine"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResults() []*quotes.Result {
+	t0 := time.Date(2022, time.May, 2, 10, 0, 0, 0, time.UTC)
+	date := time.Date(2022, time.April, 29, 0, 0, 0, 0, time.UTC)
+	return []*quotes.Result{
+		{
+			Isin:      "isin1",
+			Source:    "source1",
+			URL:       "http://example.com/isin1",
+			Price:     12.345,
+			PriceRaw:  "12,345",
+			Currency:  "EUR",
+			Date:      &date,
+			TimeStart: t0,
+			TimeEnd:   t0.Add(1500 * time.Millisecond),
+			Status:    taskengine.EventSuccess,
+			Attempts:  1,
+		},
+		{
+			Isin:      "isin2",
+			Source:    "source1",
+			TimeStart: t0,
+			TimeEnd:   t0.Add(250 * time.Millisecond),
+			Err:       errors.New("isin not found"),
+			Status:    taskengine.EventError,
+			Attempts:  2,
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"json", JSON, false},
+		{"JSONL", JSONL, false},
+		{"csv", CSV, false},
+		{"tsv", TSV, false},
+		{"Table", Table, false},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q): unexpected error %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q): expected %q, found %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Format
+		ok   bool
+	}{
+		{"out.json", JSON, true},
+		{"/tmp/out.ndjson", JSONL, true},
+		{"out.CSV", CSV, true},
+		{"out.tsv", TSV, true},
+		{"out.txt", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FormatFromPath(tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FormatFromPath(%q): expected (%q, %v), found (%q, %v)", tt.path, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	results := testResults()
+
+	tests := []struct {
+		name    string
+		format  Format
+		columns []string
+		want    string
+	}{
+		{
+			name:   "csv default columns",
+			format: CSV,
+			want: `isin,source,price,currency,date,status,error
+isin1,source1,12.345,EUR,2022-04-29,success,
+isin2,source1,,,,error,isin not found
+`,
+		},
+		{
+			name:    "tsv columns",
+			format:  TSV,
+			columns: []string{"isin", "price_raw", "attempts", "elapsed"},
+			want: "isin\tprice_raw\tattempts\telapsed\n" +
+				"isin1\t12,345\t1\t1.500\n" +
+				"isin2\t\t2\t0.250\n",
+		},
+		{
+			name:    "table",
+			format:  Table,
+			columns: []string{"isin", "price", "status"},
+			want: `┌───────┬────────┬─────────┐
+│ ISIN  │ PRICE  │ STATUS  │
+├───────┼────────┼─────────┤
+│ isin1 │ 12.345 │ success │
+│ isin2 │        │ error   │
+└───────┴────────┴─────────┘
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, results, tt.format, &Options{Columns: tt.columns})
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteConverted(t *testing.T) {
+	results := testResults()
+	results[0].ConvertedPrice = 13.3326
+	results[0].ConvertedCurrency = "USD"
+	columns := []string{"isin", "price", "currency", "converted_price", "converted_currency"}
+
+	var buf bytes.Buffer
+	err := Write(&buf, results, CSV, &Options{Columns: columns})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `isin,price,currency,converted_price,converted_currency
+isin1,12.345,EUR,13.3326,USD
+isin2,,,,
+`, buf.String())
+	}
+
+	buf.Reset()
+	err = Write(&buf, results, Table, &Options{Columns: columns})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `┌───────┬────────┬──────────┬─────────────────┬────────────────────┐
+│ ISIN  │ PRICE  │ CURRENCY │ CONVERTED_PRICE │ CONVERTED_CURRENCY │
+├───────┼────────┼──────────┼─────────────────┼────────────────────┤
+│ isin1 │ 12.345 │ EUR      │         13.3326 │ USD                │
+│ isin2 │        │          │                 │                    │
+└───────┴────────┴──────────┴─────────────────┴────────────────────┘
+`, buf.String())
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, testResults(), JSONL, nil)
+	if assert.NoError(t, err) {
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if assert.Len(t, lines, 2) {
+			assert.True(t, strings.HasPrefix(lines[0], `{"isin":"isin1"`), lines[0])
+			assert.True(t, strings.HasPrefix(lines[1], `{"isin":"isin2"`), lines[1])
+		}
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, testResults(), CSV, &Options{Columns: []string{"isin", "unknown"}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid column "unknown"`)
+	}
+
+	err = Write(&buf, testResults(), Format("xml"), nil)
+	assert.Error(t, err)
+}
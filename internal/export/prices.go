@@ -0,0 +1,133 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+// priceDirective is the price of a commodity at a date.
+type priceDirective struct {
+	date      string
+	commodity string
+	price     string
+	currency  string
+}
+
+// priceDirectives returns the price directives of the successful results
+// with date, price and currency, in the order of the results.
+// The outliers of the consensus are skipped.
+// In case of many results with the same commodity, date and currency,
+// the best one of the consensus is used, otherwise the first one.
+func priceDirectives(results []*quotes.Result, commodities map[string]string) []*priceDirective {
+	type key struct{ date, commodity, currency string }
+	seen := map[key]int{} // index of the price directive
+
+	var pds []*priceDirective
+	for _, r := range results {
+		if r.Err != nil || r.Price == 0 || r.Currency == "" || r.Date == nil || r.Date.IsZero() {
+			continue
+		}
+		if r.Consensus == quotes.ConsensusOutlier {
+			continue
+		}
+		commodity := commodities[r.Isin]
+		if commodity == "" {
+			commodity = r.Isin
+		}
+		pd := &priceDirective{
+			date:      r.Date.Format("2006-01-02"),
+			commodity: commodity,
+			price:     strconv.FormatFloat(r.Price, 'f', -1, 64),
+			currency:  r.Currency,
+		}
+		k := key{pd.date, pd.commodity, pd.currency}
+		if j, ok := seen[k]; ok {
+			if r.Best {
+				pds[j] = pd
+			}
+			continue
+		}
+		seen[k] = len(pds)
+		pds = append(pds, pd)
+	}
+	return pds
+}
+
+// ledgerCommodity returns the commodity symbol in ledger syntax:
+// a symbol with characters other than letters (es. digits) must be quoted.
+func ledgerCommodity(s string) string {
+	if strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) < 0 {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// writeLedger writes the price directives in ledger/hledger format:
+//
+//	P 2006-01-02 SYMBOL 78.12 EUR
+func writeLedger(w io.Writer, results []*quotes.Result, opts *Options) error {
+	for _, pd := range priceDirectives(results, opts.Commodities) {
+		_, err := fmt.Fprintf(w, "P %s %s %s %s\n",
+			pd.date, ledgerCommodity(pd.commodity), pd.price, ledgerCommodity(pd.currency))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBeancount writes the price directives in beancount format:
+//
+//	2006-01-02 price SYMBOL 78.12 EUR
+func writeBeancount(w io.Writer, results []*quotes.Result, opts *Options) error {
+	for _, pd := range priceDirectives(results, opts.Commodities) {
+		_, err := fmt.Fprintf(w, "%s price %s %s %s\n",
+			pd.date, pd.commodity, pd.price, pd.currency)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultNamespace is the default GnuCash namespace of the commodities.
+const DefaultNamespace = "FUND"
+
+// gnucashCommodity splits the commodity in namespace and symbol.
+func gnucashCommodity(commodity, defaultNamespace string) (namespace, symbol string) {
+	if idx := strings.IndexByte(commodity, ':'); idx >= 0 {
+		return commodity[:idx], commodity[idx+1:]
+	}
+	if defaultNamespace == "" {
+		defaultNamespace = DefaultNamespace
+	}
+	return defaultNamespace, commodity
+}
+
+// writeGnuCash writes the prices in a csv file
+// that can be imported by the GnuCash price importer
+// (File > Import > Import Prices from a CSV file):
+//
+//	date,namespace,symbol,currency,price
+//	2006-01-02,FUND,SYMBOL,EUR,78.12
+func writeGnuCash(w io.Writer, results []*quotes.Result, opts *Options) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"date", "namespace", "symbol", "currency", "price"}); err != nil {
+		return err
+	}
+	for _, pd := range priceDirectives(results, opts.Commodities) {
+		namespace, symbol := gnucashCommodity(pd.commodity, opts.Namespace)
+		if err := cw.Write([]string{pd.date, namespace, symbol, pd.currency, pd.price}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePrices(t *testing.T) {
+	date1 := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+	date2 := time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+	results := append(testResults(),
+		&quotes.Result{Isin: "IE00B4L5Y983", Source: "source1", Price: 78.12, Currency: "EUR", Date: &date1},
+		// duplicate of another source
+		&quotes.Result{Isin: "IE00B4L5Y983", Source: "source2", Price: 78.13, Currency: "EUR", Date: &date1},
+		&quotes.Result{Isin: "IE00B4L5Y983", Source: "source2", Price: 77.5, Currency: "EUR", Date: &date2},
+		&quotes.Result{Isin: "IE00BK5BQT80", Source: "source1", Price: 101, Currency: "EUR", Date: &date1},
+		// without currency
+		&quotes.Result{Isin: "IE00BK5BQT80", Source: "source2", Price: 101, Date: &date2},
+	)
+	opts := &Options{
+		Commodities: map[string]string{"IE00BK5BQT80": "VWCE"},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: Ledger,
+			want: `P 2022-04-29 "isin1" 12.345 EUR
+P 2026-10-16 "IE00B4L5Y983" 78.12 EUR
+P 2026-10-15 "IE00B4L5Y983" 77.5 EUR
+P 2026-10-16 VWCE 101 EUR
+`,
+		},
+		{
+			format: Beancount,
+			want: `2022-04-29 price isin1 12.345 EUR
+2026-10-16 price IE00B4L5Y983 78.12 EUR
+2026-10-15 price IE00B4L5Y983 77.5 EUR
+2026-10-16 price VWCE 101 EUR
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if assert.NoError(t, Write(&buf, results, tt.format, opts)) {
+				assert.Equal(t, tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestWritePricesConsensus(t *testing.T) {
+	date := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+	results := []*quotes.Result{
+		{Isin: "IE00B4L5Y983", Source: "source1", Price: 90, Currency: "EUR", Date: &date, Consensus: quotes.ConsensusOutlier},
+		{Isin: "IE00B4L5Y983", Source: "source2", Price: 78.13, Currency: "EUR", Date: &date, Consensus: quotes.ConsensusAgree},
+		{Isin: "IE00B4L5Y983", Source: "source3", Price: 78.12, Currency: "EUR", Date: &date, Consensus: quotes.ConsensusAgree, Best: true},
+	}
+
+	var buf bytes.Buffer
+	if assert.NoError(t, Write(&buf, results, Ledger, &Options{})) {
+		assert.Equal(t, "P 2026-10-16 \"IE00B4L5Y983\" 78.12 EUR\n", buf.String())
+	}
+}
+
+func TestWriteGnuCash(t *testing.T) {
+	date1 := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+	results := []*quotes.Result{
+		{Isin: "IE00B4L5Y983", Price: 78.12, Currency: "EUR", Date: &date1},
+		{Isin: "IE00BK5BQT80", Price: 101, Currency: "EUR", Date: &date1},
+		{Isin: "LU0000000001", Price: 9.5, Currency: "USD", Date: &date1},
+	}
+
+	tests := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{
+			name: "default namespace",
+			want: `date,namespace,symbol,currency,price
+2026-10-16,FUND,IE00B4L5Y983,EUR,78.12
+2026-10-16,FUND,IE00BK5BQT80,EUR,101
+2026-10-16,FUND,LU0000000001,USD,9.5
+`,
+		},
+		{
+			name: "commodities",
+			opts: &Options{
+				Namespace: "ETF",
+				Commodities: map[string]string{
+					"IE00B4L5Y983": "SWDA",
+					"IE00BK5BQT80": "XETRA:VWCE",
+				},
+			},
+			want: `date,namespace,symbol,currency,price
+2026-10-16,ETF,SWDA,EUR,78.12
+2026-10-16,XETRA,VWCE,EUR,101
+2026-10-16,ETF,LU0000000001,USD,9.5
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if assert.NoError(t, Write(&buf, results, GnuCash, tt.opts)) {
+				assert.Equal(t, tt.want, buf.String())
+			}
+		})
+	}
+}
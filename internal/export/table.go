@@ -0,0 +1,42 @@
+package export
+
+import (
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+// writeTable writes the selected columns of the results
+// in a human readable table.
+func writeTable(w io.Writer, results []*quotes.Result, opts *Options) error {
+	names := opts.Columns
+
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleLight)
+
+	header := make(table.Row, len(names))
+	configs := make([]table.ColumnConfig, 0, len(names))
+	for j, c := range names {
+		header[j] = strings.ToUpper(c)
+		switch c {
+		case "price", "instance", "attempts", "elapsed", "converted_price":
+			configs = append(configs, table.ColumnConfig{Name: header[j].(string), Align: text.AlignRight})
+		}
+	}
+	tw.AppendHeader(header)
+	tw.SetColumnConfigs(configs)
+
+	for _, r := range results {
+		row := make(table.Row, len(names))
+		for j, c := range names {
+			row[j] = columns[c](r)
+		}
+		tw.AppendRow(row)
+	}
+
+	_, err := io.WriteString(w, tw.Render()+"\n")
+	return err
+}
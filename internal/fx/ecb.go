@@ -0,0 +1,95 @@
+package fx
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+)
+
+// ECBDailyURL is the url of the daily reference rates of the European Central Bank.
+// The rates of the last 90 days are available at
+// https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ecb retrieves the euro foreign exchange reference rates
+// published by the European Central Bank.
+type ecb struct {
+	url    string
+	client *http.Client
+}
+
+// NewECB returns the Source of the ECB reference rates (the base currency is EUR).
+// If url is empty, ECBDailyURL is used.
+func NewECB(url string, client *http.Client) Source {
+	if url == "" {
+		url = ECBDailyURL
+	}
+	return &ecb{url, client}
+}
+
+// Name returns the name of the source.
+func (s *ecb) Name() string { return "ecb" }
+
+// ecbEnvelope is the xml document of the ECB reference rates:
+//
+//	<gesmes:Envelope ...>
+//	  <Cube>
+//	    <Cube time="2026-10-16">
+//	      <Cube currency="USD" rate="1.0867"/>
+//	      ...
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// GetRates retrieves and parses the xml document of the reference rates.
+func (s *ecb) GetRates(ctx context.Context) ([]*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := quotegetter.DoHTTPRequest(s.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("ecb: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var env ecbEnvelope
+	if err = xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, fmt.Errorf("ecb: %w", err)
+	}
+	return env.rates()
+}
+
+// rates returns the rates of the days of the document, sorted by date.
+func (env *ecbEnvelope) rates() ([]*Rates, error) {
+	var list []*Rates
+	for _, day := range env.Cube.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("ecb: invalid date %q", day.Time)
+		}
+		r := &Rates{Date: date, Base: "EUR", Rates: map[string]float64{}}
+		for _, cr := range day.Rates {
+			r.Rates[cr.Currency] = cr.Rate
+		}
+		list = append(list, r)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("ecb: no rates found")
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Date.Before(list[j].Date) })
+	return list, nil
+}
@@ -0,0 +1,67 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const ecbFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time='2026-10-16'>
+			<Cube currency='USD' rate='1.1000'/>
+			<Cube currency='GBP' rate='0.8000'/>
+		</Cube>
+		<Cube time='2026-10-15'>
+			<Cube currency='USD' rate='1.2000'/>
+			<Cube currency='GBP' rate='0.8500'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>
+`
+
+func TestECB(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ecbFixture)
+	}))
+	defer server.Close()
+
+	src, err := NewSource("ECB", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := src.GetRates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 days, found %d", len(rates))
+	}
+	if r := rates[0]; !r.Date.Equal(day(15)) || r.Base != "EUR" || r.Rates["USD"] != 1.2 || r.Rates["GBP"] != 0.85 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+	if r := rates[1]; !r.Date.Equal(day(16)) || len(r.Rates) != 2 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+}
+
+func TestECBError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewECB(server.URL, nil).GetRates(context.Background()); err == nil {
+		t.Error("expected error, found nil")
+	}
+	if _, err := NewSource("unknown", "", nil); err == nil {
+		t.Error("expected error for unknown source, found nil")
+	}
+}
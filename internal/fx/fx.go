@@ -0,0 +1,165 @@
+// Package fx retrieves the exchange rates of the currencies
+// and converts the prices to a reporting currency.
+//
+// The exchange rates are retrieved by a Source, es. the reference rates
+// published daily by the European Central Bank (see ECB).
+package fx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+)
+
+// Rates are the exchange rates of a day with respect to the base currency:
+// 1 Base is worth Rates[currency] units of the currency.
+type Rates struct {
+	Date  time.Time
+	Base  string
+	Rates map[string]float64
+}
+
+// Source retrieves the exchange rates.
+// GetRates returns the rates of one or more days, sorted by date.
+type Source interface {
+	Name() string
+	GetRates(ctx context.Context) ([]*Rates, error)
+}
+
+// NewSourceFunc creates a Source that retrieves the rates from the url
+// (the default url of the source if empty) with the http client.
+type NewSourceFunc func(url string, client *http.Client) Source
+
+// Sources maps the name of the available sources to their NewSourceFunc.
+var Sources = map[string]NewSourceFunc{
+	"ecb": NewECB,
+}
+
+// DefaultSource is the name of the source used if not defined.
+const DefaultSource = "ecb"
+
+// NewSource returns the source with the given name.
+func NewSource(name, url string, client *http.Client) (Source, error) {
+	if name == "" {
+		name = DefaultSource
+	}
+	fn, ok := Sources[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("fx source %q not available", name)
+	}
+	return fn(url, client), nil
+}
+
+// Table contains the exchange rates of many days, to convert the prices.
+type Table struct {
+	rates []*Rates // sorted by date
+}
+
+// NewTable returns the table of the rates.
+func NewTable(rates ...*Rates) *Table {
+	t := &Table{}
+	t.Add(rates...)
+	return t
+}
+
+// Add adds the rates to the table.
+// The rates of the same day and base currency replace the existing ones.
+func (t *Table) Add(rates ...*Rates) {
+	for _, r := range rates {
+		if r == nil {
+			continue
+		}
+		replaced := false
+		for j, old := range t.rates {
+			if old.Base == r.Base && sameDay(old.Date, r.Date) {
+				t.rates[j] = r
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			t.rates = append(t.rates, r)
+		}
+	}
+	sort.SliceStable(t.rates, func(i, j int) bool { return t.rates[i].Date.Before(t.rates[j].Date) })
+}
+
+// Len returns the number of days of the table.
+func (t *Table) Len() int {
+	return len(t.rates)
+}
+
+// Rates returns the rates of the table, sorted by date.
+func (t *Table) Rates() []*Rates {
+	return t.rates
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// rate returns the units of the currency worth 1 unit of the base currency.
+// The currency is normalized by quotegetter.ParseCurrency, handling the minor units:
+// es. the GBX prices are in pence, 100 GBX are 1 GBP.
+func (r *Rates) rate(currency string) (float64, bool) {
+	currency, factor, err := quotegetter.ParseCurrency(currency)
+	if err != nil || currency == "" {
+		return 0, false
+	}
+	if currency == r.Base {
+		return factor, true
+	}
+	v, ok := r.Rates[currency]
+	if !ok || v == 0 {
+		return 0, false
+	}
+	return v * factor, true
+}
+
+// Rate returns the exchange rate from the currency to the other one at the date:
+// a price in the from currency multiplied by the rate is the price in the to currency.
+// The rates of the most recent day not after the date, with both the currencies,
+// are used; otherwise the oldest rates after the date.
+// It returns false if the rate is not known.
+func (t *Table) Rate(from, to string, date time.Time) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	if len(t.rates) == 0 {
+		return 0, false
+	}
+	// index of the first rates after the date
+	day := date.Format("2006-01-02")
+	j := sort.Search(len(t.rates), func(i int) bool { return t.rates[i].Date.Format("2006-01-02") > day })
+
+	// the rates not after the date, from the most recent, then the ones after the date
+	candidates := make([]*Rates, 0, len(t.rates))
+	for i := j - 1; i >= 0; i-- {
+		candidates = append(candidates, t.rates[i])
+	}
+	candidates = append(candidates, t.rates[j:]...)
+
+	for _, r := range candidates {
+		rf, okf := r.rate(from)
+		rt, okt := r.rate(to)
+		if okf && okt {
+			return rt / rf, true
+		}
+	}
+	return 0, false
+}
+
+// Convert converts the amount from the currency to the other one at the date.
+// It returns false if the rate is not known.
+func (t *Table) Convert(amount float64, from, to string, date time.Time) (float64, bool) {
+	rate, ok := t.Rate(from, to, date)
+	if !ok {
+		return 0, false
+	}
+	return amount * rate, true
+}
@@ -0,0 +1,53 @@
+package fx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTableConvert(t *testing.T) {
+	table := NewTable(
+		&Rates{Date: day(16), Base: "EUR", Rates: map[string]float64{"USD": 1.1, "GBP": 0.8}},
+		&Rates{Date: day(14), Base: "EUR", Rates: map[string]float64{"USD": 1.2, "GBP": 0.85, "CHF": 0.95}},
+	)
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		date     time.Time
+		want     float64
+		ok       bool
+	}{
+		{11, "USD", "EUR", day(16), 10, true},
+		{10, "EUR", "USD", day(17), 11, true},
+		{12, "USD", "EUR", day(15), 10, true},   // rates of the previous day
+		{12, "USD", "EUR", day(1), 10, true},    // oldest rates
+		{1.1, "USD", "GBP", day(16), 0.8, true}, // cross rate
+		{8000, "GBX", "EUR", day(16), 100, true},
+		{8000, "GBp", "GBP", day(16), 80, true},
+		{95, "CHF", "EUR", day(16), 100, true}, // the most recent rates with CHF
+		{10, "EUR", "EUR", day(16), 10, true},
+		{10, "JPY", "EUR", day(16), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := table.Convert(tt.amount, tt.from, tt.to, tt.date)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Convert(%v %s -> %s at %s): got (%v, %v), expected (%v, %v)",
+				tt.amount, tt.from, tt.to, tt.date.Format("2006-01-02"), got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// the rates of the same day replace the existing ones
+	table.Add(&Rates{Date: day(16), Base: "EUR", Rates: map[string]float64{"USD": 1.0}})
+	if table.Len() != 2 {
+		t.Errorf("expected 2 days, found %d", table.Len())
+	}
+	if got, _ := table.Convert(10, "USD", "EUR", day(16)); got != 10 {
+		t.Errorf("expected the replaced rates, found %v", got)
+	}
+}
@@ -0,0 +1,207 @@
+// Package portfolio computes the valuation of the holdings
+// from the last quotes of their isins.
+//
+// The market value of each position is the quantity multiplied
+// by the last price of the isin, converted to the reporting currency.
+// The unrealized gain or loss is the market value minus the cost basis.
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mmbros/quotes/internal/fx"
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+// Holding is the quantity of an isin held in an account.
+// Cost is the total cost basis of the holding, in the reporting currency:
+// if zero, the gain of the holding is not known.
+type Holding struct {
+	Isin     string  `json:"isin"`
+	Name     string  `json:"name,omitempty"`
+	Account  string  `json:"account,omitempty"`
+	Quantity float64 `json:"quantity"`
+	Cost     float64 `json:"cost,omitempty"`
+}
+
+// Position is the valuation of a holding.
+//
+// Price, PriceCurrency, Date and Source are the ones of the last quote of the isin.
+// Value, Gain and GainPct are in the reporting currency, and Weight is the
+// ratio of the value on the total value of the portfolio.
+// Err is not empty if the position can't be valued: the position is not
+// included in the totals.
+type Position struct {
+	Holding
+	Source        string     `json:"source,omitempty"`
+	Date          *time.Time `json:"date,omitempty"`
+	Price         float64    `json:"price,omitempty"`
+	PriceCurrency string     `json:"price_currency,omitempty"`
+	Value         float64    `json:"value"`
+	Gain          float64    `json:"gain,omitempty"`
+	GainPct       float64    `json:"gain_pct,omitempty"`
+	Weight        float64    `json:"weight"`
+	Err           string     `json:"error,omitempty"`
+}
+
+// Total is the valuation of the positions of an account,
+// or of the whole portfolio (with an empty Account).
+// Cost, Gain and GainPct consider only the positions with a known cost.
+type Total struct {
+	Account   string  `json:"account,omitempty"`
+	Positions int     `json:"positions"`
+	Value     float64 `json:"value"`
+	Cost      float64 `json:"cost,omitempty"`
+	Gain      float64 `json:"gain,omitempty"`
+	GainPct   float64 `json:"gain_pct,omitempty"`
+	Weight    float64 `json:"weight"`
+}
+
+// Portfolio is the valuation of the holdings in the reporting currency.
+type Portfolio struct {
+	Currency  string      `json:"currency"`
+	Positions []*Position `json:"positions"` // ordered by account and isin
+	Accounts  []*Total    `json:"accounts"`  // ordered by account
+	Total     *Total      `json:"total"`
+}
+
+// ErrCurrency is returned by New if the reporting currency is not defined
+// and the quotes have different currencies.
+var ErrCurrency = errors.New("quotes with different currencies: the reporting currency must be defined")
+
+// New returns the valuation of the holdings from the last quotes of the isins.
+// The successful results with the most recent date are used,
+// preferring the best quote of the consensus.
+//
+// If currency is empty, the common currency of the quotes is used.
+// Otherwise the prices are converted to the currency with the rates of the table,
+// at the date of the quote; the table can be nil if no conversion is needed.
+func New(holdings []*Holding, results []*quotes.Result, currency string, table *fx.Table) (*Portfolio, error) {
+	last := quotes.Last(results)
+
+	if currency == "" {
+		for _, h := range holdings {
+			r := last[h.Isin]
+			if r == nil {
+				continue
+			}
+			if currency == "" {
+				currency = r.Currency
+			} else if r.Currency != currency {
+				return nil, ErrCurrency
+			}
+		}
+	}
+
+	p := &Portfolio{Currency: currency, Total: &Total{}}
+	accounts := map[string]*Total{}
+
+	for _, h := range holdings {
+		pos := &Position{Holding: *h}
+		p.Positions = append(p.Positions, pos)
+
+		acc := accounts[h.Account]
+		if acc == nil {
+			acc = &Total{Account: h.Account}
+			accounts[h.Account] = acc
+			p.Accounts = append(p.Accounts, acc)
+		}
+
+		r := last[h.Isin]
+		if r == nil {
+			pos.Err = "quote not found"
+			continue
+		}
+		pos.Source = r.Source
+		pos.Date = r.Date
+		pos.Price = r.Price
+		pos.PriceCurrency = r.Currency
+
+		value := h.Quantity * r.Price
+		if r.Currency != currency {
+			var ok bool
+			value, ok = convert(table, value, r.Currency, currency, r.Date)
+			if !ok {
+				pos.Err = fmt.Sprintf("exchange rate %s/%s not found", r.Currency, currency)
+				continue
+			}
+		}
+		pos.Value = value
+		if h.Cost != 0 {
+			pos.Gain = value - h.Cost
+			pos.GainPct = pos.Gain / h.Cost
+		}
+		acc.add(pos)
+		p.Total.add(pos)
+	}
+
+	// weights and gains of the totals
+	for _, pos := range p.Positions {
+		if pos.Err == "" {
+			pos.Weight = ratio(pos.Value, p.Total.Value)
+		}
+	}
+	for _, acc := range p.Accounts {
+		acc.Weight = ratio(acc.Value, p.Total.Value)
+		acc.GainPct = ratio(acc.Gain, acc.Cost)
+	}
+	p.Total.Weight = ratio(p.Total.Value, p.Total.Value)
+	p.Total.GainPct = ratio(p.Total.Gain, p.Total.Cost)
+
+	sort.SliceStable(p.Positions, func(i, j int) bool {
+		a, b := p.Positions[i], p.Positions[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Isin < b.Isin
+	})
+	sort.Slice(p.Accounts, func(i, j int) bool { return p.Accounts[i].Account < p.Accounts[j].Account })
+	return p, nil
+}
+
+// add adds the valued position to the total.
+func (t *Total) add(pos *Position) {
+	t.Positions++
+	t.Value += pos.Value
+	if pos.Cost != 0 {
+		t.Cost += pos.Cost
+		t.Gain += pos.Gain
+	}
+}
+
+// ratio returns a/b, or 0 if b is 0.
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// convert converts the amount at the date with the rates of the table.
+func convert(table *fx.Table, amount float64, from, to string, date *time.Time) (float64, bool) {
+	if table == nil || from == "" {
+		return 0, false
+	}
+	var d time.Time
+	if date != nil {
+		d = *date
+	}
+	return table.Convert(amount, from, to, d)
+}
+
+// Isins returns the sorted isins of the holdings, without duplicates.
+func Isins(holdings []*Holding) []string {
+	set := map[string]bool{}
+	for _, h := range holdings {
+		set[h.Isin] = true
+	}
+	isins := make([]string, 0, len(set))
+	for i := range set {
+		isins = append(isins, i)
+	}
+	sort.Strings(isins)
+	return isins
+}
@@ -0,0 +1,136 @@
+package portfolio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/fx"
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func result(isin, source string, day int, price float64, currency string, best bool) *quotes.Result {
+	date := time.Date(2026, time.October, day, 0, 0, 0, 0, time.UTC)
+	return &quotes.Result{
+		Isin:     isin,
+		Source:   source,
+		Date:     &date,
+		Price:    price,
+		Currency: currency,
+		Best:     best,
+	}
+}
+
+func testHoldings() []*Holding {
+	return []*Holding{
+		{Isin: "isin1", Account: "broker", Quantity: 10, Cost: 80},
+		{Isin: "isin2", Account: "broker", Quantity: 5},
+		{Isin: "isin1", Account: "pension", Quantity: 2, Cost: 25},
+		{Isin: "isin3", Account: "pension", Quantity: 1, Cost: 10},
+	}
+}
+
+func testResults() []*quotes.Result {
+	failed := result("isin1", "source3", 16, 0, "", false)
+	failed.Err = errors.New("isin not found")
+	return []*quotes.Result{
+		result("isin1", "source1", 15, 9, "EUR", true),
+		result("isin1", "source1", 16, 10.5, "EUR", false),
+		result("isin1", "source2", 16, 10, "EUR", true),
+		failed,
+		result("isin2", "source1", 16, 44, "USD", true),
+	}
+}
+
+func TestNew(t *testing.T) {
+	table := fx.NewTable(&fx.Rates{
+		Date:  time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Base:  "EUR",
+		Rates: map[string]float64{"USD": 1.1},
+	})
+
+	p, err := New(testHoldings(), testResults(), "EUR", table)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", p.Currency)
+
+	require.Len(t, p.Positions, 4)
+	b1, b2, p1, p3 := p.Positions[0], p.Positions[1], p.Positions[2], p.Positions[3]
+
+	// the best quote of the most recent date
+	assert.Equal(t, "source2", b1.Source)
+	assert.InDelta(t, 100, b1.Value, 1e-9)
+	assert.InDelta(t, 20, b1.Gain, 1e-9)
+	assert.InDelta(t, 0.25, b1.GainPct, 1e-9)
+	assert.InDelta(t, 100.0/320, b1.Weight, 1e-9)
+
+	// converted, without cost
+	assert.Equal(t, "USD", b2.PriceCurrency)
+	assert.InDelta(t, 200, b2.Value, 1e-9)
+	assert.Zero(t, b2.Gain)
+
+	assert.InDelta(t, 20, p1.Value, 1e-9)
+	assert.InDelta(t, -5, p1.Gain, 1e-9)
+
+	// no quote
+	assert.Equal(t, "quote not found", p3.Err)
+	assert.Zero(t, p3.Value)
+
+	require.Len(t, p.Accounts, 2)
+	broker, pension := p.Accounts[0], p.Accounts[1]
+	assert.Equal(t, 2, broker.Positions)
+	assert.InDelta(t, 300, broker.Value, 1e-9)
+	assert.InDelta(t, 80, broker.Cost, 1e-9)
+	assert.InDelta(t, 20, broker.Gain, 1e-9)
+	assert.Equal(t, 1, pension.Positions)
+	assert.InDelta(t, 20.0/320, pension.Weight, 1e-9)
+
+	assert.Equal(t, 3, p.Total.Positions)
+	assert.InDelta(t, 320, p.Total.Value, 1e-9)
+	assert.InDelta(t, 105, p.Total.Cost, 1e-9)
+	assert.InDelta(t, 15, p.Total.Gain, 1e-9)
+	assert.InDelta(t, 1, p.Total.Weight, 1e-9)
+}
+
+func TestNewCurrency(t *testing.T) {
+	// without the reporting currency, the quotes must have the same currency
+	_, err := New(testHoldings(), testResults(), "", nil)
+	assert.ErrorIs(t, err, ErrCurrency)
+
+	p, err := New(testHoldings()[2:], testResults(), "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", p.Currency)
+
+	// without the exchange rates
+	p, err = New(testHoldings()[:2], testResults(), "EUR", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "exchange rate USD/EUR not found", p.Positions[1].Err)
+	assert.InDelta(t, 1, p.Positions[0].Weight, 1e-9)
+}
+
+func TestWrite(t *testing.T) {
+	p, err := New(testHoldings()[2:], testResults(), "", nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Write(&buf, CSV))
+	assert.Equal(t, `account,isin,name,quantity,price,price_currency,date,source,value,cost,gain,gain_pct,weight,currency,error
+pension,isin1,,2,10,EUR,2026-10-16,source2,20.00,25.00,-5.00,-0.2000,1.0000,EUR,
+pension,isin3,,1,,,,,,10.00,,,,EUR,quote not found
+pension,,,,,,,,20.00,25.00,-5.00,-0.2000,1.0000,EUR,
+,,,,,,,,20.00,25.00,-5.00,-0.2000,1.0000,EUR,
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, p.Write(&buf, Table))
+	assert.Contains(t, buf.String(), "quote not found")
+	assert.Contains(t, buf.String(), "TOTAL EUR")
+
+	f, err := ParseFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, JSON, f)
+	_, err = ParseFormat("ledger")
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// Format is the output format of the portfolio.
+type Format string
+
+// Available formats
+const (
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	Table Format = "table"
+)
+
+// writers returns the write function of each format.
+var writers = map[Format]func(w io.Writer, p *Portfolio) error{
+	JSON:  writeJSON,
+	CSV:   writeCSV,
+	Table: writeTable,
+}
+
+// Formats returns the sorted names of the available formats.
+func Formats() []string {
+	names := make([]string, 0, len(writers))
+	for f := range writers {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFormat returns the format with the given name (case insensitive).
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(name))
+	if _, ok := writers[f]; !ok {
+		return "", fmt.Errorf("invalid format %q: accepted values are %s", name, strings.Join(Formats(), ", "))
+	}
+	return f, nil
+}
+
+// FormatFromPath returns the format corresponding to the extension of the path
+// (".json" or ".csv"). It returns false if the extension is unknown.
+func FormatFromPath(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, true
+	case ".csv":
+		return CSV, true
+	}
+	return "", false
+}
+
+// Write writes the portfolio in the format.
+func (p *Portfolio) Write(w io.Writer, format Format) error {
+	fn, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("invalid format %q", format)
+	}
+	return fn(w, p)
+}
+
+func writeJSON(w io.Writer, p *Portfolio) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// csvHeader are the columns of the csv format.
+var csvHeader = []string{
+	"account", "isin", "name", "quantity", "price", "price_currency", "date", "source",
+	"value", "cost", "gain", "gain_pct", "weight", "currency", "error",
+}
+
+// writeCSV writes a row for each position, followed by a row for the total
+// of each account (with an empty isin) and by a row for the total
+// of the portfolio (with empty account and isin).
+func writeCSV(w io.Writer, p *Portfolio) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, pos := range p.Positions {
+		var date, value, gain, gainPct, weight string
+		if pos.Date != nil {
+			date = pos.Date.Format("2006-01-02")
+		}
+		if pos.Err == "" {
+			value, weight = formatAmount(pos.Value), formatRatio(pos.Weight)
+			gain, gainPct = formatGain(pos.Cost, pos.Gain), formatGainPct(pos.Cost, pos.GainPct)
+		}
+		err := cw.Write([]string{
+			pos.Account,
+			pos.Isin,
+			pos.Name,
+			strconv.FormatFloat(pos.Quantity, 'f', -1, 64),
+			formatFloat(pos.Price),
+			pos.PriceCurrency,
+			date,
+			pos.Source,
+			value,
+			formatCost(pos.Cost),
+			gain,
+			gainPct,
+			weight,
+			p.Currency,
+			pos.Err,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	totals := make([]*Total, 0, len(p.Accounts)+1)
+	totals = append(totals, p.Accounts...)
+	for _, t := range append(totals, p.Total) {
+		row := make([]string, len(csvHeader))
+		row[0] = t.Account
+		row[8] = formatAmount(t.Value)
+		row[9] = formatCost(t.Cost)
+		row[10] = formatGain(t.Cost, t.Gain)
+		row[11] = formatGainPct(t.Cost, t.GainPct)
+		row[12] = formatRatio(t.Weight)
+		row[13] = p.Currency
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTable writes the table of the positions followed by the table
+// of the accounts, with the total of the portfolio in the footer.
+func writeTable(w io.Writer, p *Portfolio) error {
+	percent := func(v float64) string { return strconv.FormatFloat(100*v, 'f', 2, 64) + "%" }
+	gainPct := func(cost, v float64) string {
+		if cost == 0 {
+			return "-"
+		}
+		return percent(v)
+	}
+	gain := func(cost, v float64) string {
+		if cost == 0 {
+			return "-"
+		}
+		return formatAmount(v)
+	}
+
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleLight)
+	tw.AppendHeader(table.Row{"ACCOUNT", "ISIN", "NAME", "QUANTITY", "PRICE", "DATE", "VALUE", "GAIN", "GAIN%", "WEIGHT"})
+	tw.SetColumnConfigs(rightAligned("QUANTITY", "PRICE", "VALUE", "GAIN", "GAIN%", "WEIGHT"))
+	for _, pos := range p.Positions {
+		price, date := "-", "-"
+		if pos.PriceCurrency != "" {
+			price = formatFloat(pos.Price) + " " + pos.PriceCurrency
+		}
+		if pos.Date != nil {
+			date = pos.Date.Format("2006-01-02")
+		}
+		if pos.Err != "" {
+			tw.AppendRow(table.Row{pos.Account, pos.Isin, pos.Name,
+				strconv.FormatFloat(pos.Quantity, 'f', -1, 64), price, date, pos.Err, "", "", ""})
+			continue
+		}
+		tw.AppendRow(table.Row{
+			pos.Account,
+			pos.Isin,
+			pos.Name,
+			strconv.FormatFloat(pos.Quantity, 'f', -1, 64),
+			price,
+			date,
+			formatAmount(pos.Value),
+			gain(pos.Cost, pos.Gain),
+			gainPct(pos.Cost, pos.GainPct),
+			percent(pos.Weight),
+		})
+	}
+	if _, err := io.WriteString(w, tw.Render()+"\n\n"); err != nil {
+		return err
+	}
+
+	tw = table.NewWriter()
+	tw.SetStyle(table.StyleLight)
+	tw.AppendHeader(table.Row{"ACCOUNT", "POSITIONS", "VALUE", "COST", "GAIN", "GAIN%", "WEIGHT"})
+	tw.SetColumnConfigs(rightAligned("POSITIONS", "VALUE", "COST", "GAIN", "GAIN%", "WEIGHT"))
+	row := func(name string, t *Total) table.Row {
+		cost := "-"
+		if t.Cost != 0 {
+			cost = formatAmount(t.Cost)
+		}
+		return table.Row{name, t.Positions, formatAmount(t.Value), cost,
+			gain(t.Cost, t.Gain), gainPct(t.Cost, t.GainPct), percent(t.Weight)}
+	}
+	for _, t := range p.Accounts {
+		tw.AppendRow(row(t.Account, t))
+	}
+	tw.AppendFooter(row("TOTAL "+p.Currency, p.Total))
+	_, err := io.WriteString(w, tw.Render()+"\n")
+	return err
+}
+
+func rightAligned(names ...string) []table.ColumnConfig {
+	configs := make([]table.ColumnConfig, len(names))
+	for j, name := range names {
+		configs[j] = table.ColumnConfig{Name: name, Align: text.AlignRight, AlignFooter: text.AlignRight}
+	}
+	return configs
+}
+
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatAmount(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+func formatRatio(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return ""
+	}
+	return formatAmount(cost)
+}
+
+func formatGain(cost, gain float64) string {
+	if cost == 0 {
+		return ""
+	}
+	return formatAmount(gain)
+}
+
+func formatGainPct(cost, gainPct float64) string {
+	if cost == 0 {
+		return ""
+	}
+	return formatRatio(gainPct)
+}
@@ -3,6 +3,7 @@ package progress
 import (
 	"fmt"
 	"io"
+	"math"
 	"time"
 
 	prog "github.com/jedib0t/go-pretty/v6/progress"
@@ -27,7 +28,7 @@ var unitsCurrency = prog.Units{
 }
 
 func formatCurrency(value int64) string {
-	return fmt.Sprintf("%.2f", float32(value)/100)
+	return fmt.Sprintf("%.2f", float64(value)/100)
 }
 
 func trackerUnits(currency string) prog.Units {
@@ -49,8 +50,8 @@ func trackerUnits(currency string) prog.Units {
 	return u
 }
 
-func trackerValue(price float32) int64 {
-	return int64(price * 100)
+func trackerValue(price float64) int64 {
+	return int64(math.Round(price * 100))
 }
 
 func New(w io.Writer, trackers int) *Progress {
@@ -116,7 +117,7 @@ func (p *Progress) InitTrackerIfNew(name string) {
 	}
 }
 
-func (p *Progress) SetSuccess(name string, message string, price float32, currency string) {
+func (p *Progress) SetSuccess(name string, message string, price float64, currency string) {
 	if p == nil {
 		return
 	}
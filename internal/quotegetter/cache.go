@@ -0,0 +1,156 @@
+package quotegetter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache is an on-disk cache of the http responses.
+//
+// The responses are saved in the Dir folder, one file for each request,
+// keyed by method and url. A cached response is used if it is not older than TTL.
+// Only the successful responses (200 OK) of the GET requests are cached.
+type Cache struct {
+	Dir string        `json:"dir,omitempty"`
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+// path returns the pathname of the cache file of the request.
+func (c *Cache) path(req *http.Request) string {
+	h := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	return filepath.Join(c.Dir, hex.EncodeToString(h[:]))
+}
+
+// get returns the cached response of the request, if any and not expired.
+func (c *Cache) get(req *http.Request, now time.Time) *http.Response {
+	path := c.path(req)
+
+	fi, err := os.Stat(path)
+	if err != nil || now.Sub(fi.ModTime()) > c.TTL {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// put saves the response data in the cache.
+func (c *Cache) put(req *http.Request, data []byte) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it,
+	// so that concurrent readers never see a partial response
+	f, err := ioutil.TempFile(c.Dir, tempPrefix)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(req))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// tempPrefix is the prefix of the temporary files of the cache.
+const tempPrefix = "tmp-"
+
+// PruneCache removes the files of the cache folder older than ttl:
+// the expired responses and the temporary files left by an interrupted put.
+// It returns the number of removed files.
+// A missing folder is not an error.
+func PruneCache(dir string, ttl time.Duration, now time.Time) (int, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	n := 0
+	for _, fi := range entries {
+		if fi.IsDir() || now.Sub(fi.ModTime()) <= ttl {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, fi.Name())); err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// cacheTransport is an http.RoundTripper that returns the cached responses
+// and saves the new ones in the cache.
+type cacheTransport struct {
+	base  http.RoundTripper
+	cache *Cache
+}
+
+// errReader is an io.Reader that always returns the error.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// RoundTrip implements the http.RoundTripper interface.
+//
+// The body of a successful response is read in memory and replaced by a copy.
+// If the body cannot be read, the response is not cached and its new body
+// returns the bytes read so far, then the read error.
+// A failure of the cache is logged, and the response is returned anyway.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+	if resp := t.cache.get(req, time.Now()); resp != nil {
+		return resp, nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		resp.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return resp, nil
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// dump a copy of the response with the buffered body
+	c := *resp
+	c.Body = ioutil.NopCloser(bytes.NewReader(body))
+	data, err := httputil.DumpResponse(&c, true)
+	if err == nil {
+		err = t.cache.put(req, data)
+	}
+	if err != nil {
+		log.Printf("cache %q: %v", req.URL, err)
+	}
+	return resp, nil
+}
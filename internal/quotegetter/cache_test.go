@@ -0,0 +1,156 @@
+package quotegetter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		if r.URL.Path == "/ko" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	cache := &Cache{Dir: t.TempDir(), TTL: time.Minute}
+	client, err := (&ClientConfig{Cache: cache}).NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) (string, int, int) {
+		ctx, attempts := WithAttemptsCounter(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("GET %s: read body: %v", path, err)
+		}
+		return string(body), resp.StatusCode, attempts()
+	}
+
+	// first request: from the server
+	body, _, attempts := get("/a")
+	if body != "body of /a" || attempts != 1 {
+		t.Errorf("first request: found body %q, attempts %d", body, attempts)
+	}
+
+	// second request: from the cache
+	body, _, attempts = get("/a")
+	if body != "body of /a" || attempts != 0 {
+		t.Errorf("cached request: found body %q, attempts %d", body, attempts)
+	}
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("server requests: expected 1, found %d", n)
+	}
+
+	// error responses are not cached
+	get("/ko")
+	_, code, _ := get("/ko")
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("error request: expected status %d, found %d", http.StatusServiceUnavailable, code)
+	}
+	if n := atomic.LoadInt32(&count); n != 3 {
+		t.Errorf("server requests: expected 3, found %d", n)
+	}
+
+	// expired response
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/a", nil)
+	path := cache.path(req)
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	_, _, attempts = get("/a")
+	if attempts != 1 {
+		t.Errorf("expired request: expected 1 attempt, found %d", attempts)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	for _, cache := range []*Cache{nil, {Dir: t.TempDir()}} {
+		client, _ := (&ClientConfig{Cache: cache}).NewClient()
+		if _, ok := client.Transport.(*cacheTransport); ok {
+			t.Errorf("cache %v: unexpected cacheTransport", cache)
+		}
+	}
+}
+
+// roundTripFunc is an http.RoundTripper function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestCacheBodyError(t *testing.T) {
+	errBody := errors.New("connection reset")
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(io.MultiReader(strings.NewReader("partial"), errReader{errBody})),
+			Request:    req,
+		}, nil
+	})
+	cache := &Cache{Dir: t.TempDir(), TTL: time.Minute}
+	rt := &cacheTransport{base, cache}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/a", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if string(body) != "partial" || err != errBody {
+		t.Errorf("body: found %q, %v", body, err)
+	}
+	if _, err := os.Stat(cache.path(req)); !os.IsNotExist(err) {
+		t.Errorf("the response with a body error must not be cached (err %v)", err)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	for name, mtime := range map[string]time.Time{"expired": old, "tmp-1": old, "valid": now} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := PruneCache(dir, time.Hour, now)
+	if err != nil || n != 2 {
+		t.Errorf("expected 2 removed files, found %d (err %v)", n, err)
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "valid" {
+		t.Errorf("unexpected files after prune: %v", entries)
+	}
+
+	if n, err := PruneCache(filepath.Join(dir, "missing"), time.Hour, now); n != 0 || err != nil {
+		t.Errorf("missing folder: found %d, %v", n, err)
+	}
+}
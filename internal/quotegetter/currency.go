@@ -0,0 +1,136 @@
+package quotegetter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownCurrency is returned by ParseCurrency for an unknown currency string.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// isoCurrencyCodes are the active ISO 4217 currency codes,
+// funds and precious metals included.
+const isoCurrencyCodes = `
+AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV
+BRL BSD BTN BWP BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC CUP CVE
+CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD
+HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD
+KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV
+MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB
+RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT
+TND TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF
+XAG XAU XBA XBB XBC XBD XCD XCG XDR XOF XPD XPF XPT XSU XTS XUA XXX YER ZAR ZMW
+ZWG ZWL
+`
+
+// isoCurrencies is the set of the ISO 4217 codes.
+var isoCurrencies = func() map[string]bool {
+	m := map[string]bool{}
+	for _, code := range strings.Fields(isoCurrencyCodes) {
+		m[code] = true
+	}
+	return m
+}()
+
+// currencyAlias is a currency string other than the ISO 4217 code:
+// a symbol, a name or a minor unit of the currency.
+// Divisor is the number of units of the alias in a unit of the currency
+// (es. 100 pence are 1 GBP), 1 if the alias is not a minor unit.
+type currencyAlias struct {
+	code    string
+	divisor float64
+}
+
+// currencyAliasesCase are the aliases matched case sensitive:
+// "GBp" is pence, while "GBP" is pounds.
+var currencyAliasesCase = map[string]currencyAlias{
+	"GBp": {"GBP", 100},
+	"ZAc": {"ZAR", 100},
+}
+
+// currencyAliases are the aliases matched case insensitive (in lower case).
+var currencyAliases = map[string]currencyAlias{
+	// euro
+	"€":     {"EUR", 1},
+	"euro":  {"EUR", 1},
+	"euros": {"EUR", 1},
+	"eur.":  {"EUR", 1},
+	// dollars
+	"$":          {"USD", 1},
+	"us$":        {"USD", 1},
+	"dollar":     {"USD", 1},
+	"dollars":    {"USD", 1},
+	"us dollar":  {"USD", 1},
+	"us dollars": {"USD", 1},
+	"c$":         {"CAD", 1},
+	"ca$":        {"CAD", 1},
+	"a$":         {"AUD", 1},
+	"au$":        {"AUD", 1},
+	"nz$":        {"NZD", 1},
+	"hk$":        {"HKD", 1},
+	"s$":         {"SGD", 1},
+	"r$":         {"BRL", 1},
+	// pounds and pence
+	"£":              {"GBP", 1},
+	"pound":          {"GBP", 1},
+	"pounds":         {"GBP", 1},
+	"sterling":       {"GBP", 1},
+	"pound sterling": {"GBP", 1},
+	"gbx":            {"GBP", 100},
+	"pence":          {"GBP", 100},
+	"penny":          {"GBP", 100},
+	// other currencies
+	"¥":           {"JPY", 1},
+	"yen":         {"JPY", 1},
+	"yuan":        {"CNY", 1},
+	"rmb":         {"CNY", 1},
+	"fr.":         {"CHF", 1},
+	"sfr.":        {"CHF", 1},
+	"swiss franc": {"CHF", 1},
+	"₹":           {"INR", 1},
+	"₽":           {"RUB", 1},
+	"₩":           {"KRW", 1},
+	"₺":           {"TRY", 1},
+	"₪":           {"ILS", 1},
+	"ila":         {"ILS", 100},
+	"agorot":      {"ILS", 100},
+	"zł":          {"PLN", 1},
+	"zac":         {"ZAR", 100},
+}
+
+// ParseCurrency returns the ISO 4217 code of the currency string:
+// an ISO code (case insensitive), a symbol (es. "€"), a name (es. "euro")
+// or a minor unit (es. "GBp" or "GBX" pence).
+// The divisor converts a price in the currency string to a price
+// in the ISO currency (es. 100 for pence): it is 1 if not a minor unit.
+// An empty string returns an empty code, while an unknown currency
+// returns an ErrUnknownCurrency error.
+func ParseCurrency(currency string) (code string, divisor float64, err error) {
+	s := strings.TrimSpace(currency)
+	if s == "" {
+		return "", 1, nil
+	}
+	if a, ok := currencyAliasesCase[s]; ok {
+		return a.code, a.divisor, nil
+	}
+	if upper := strings.ToUpper(s); isoCurrencies[upper] {
+		return upper, 1, nil
+	}
+	if a, ok := currencyAliases[strings.ToLower(s)]; ok {
+		return a.code, a.divisor, nil
+	}
+	return "", 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+}
+
+// NormalizeCurrency return the standard ISO4217 representation
+// of the known currency, otherwise the currency without the surrounding spaces.
+// NOTE: the minor units are converted to the ISO code, without any price scaling:
+// use ParseCurrency to get the divisor of the price.
+func NormalizeCurrency(currency string) string {
+	code, _, err := ParseCurrency(currency)
+	if err != nil {
+		return strings.TrimSpace(currency)
+	}
+	return code
+}
@@ -0,0 +1,64 @@
+package quotegetter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		code     string
+		divisor  float64
+		err      error
+	}{
+		{"EUR", "EUR", 1, nil},
+		{"eur", "EUR", 1, nil},
+		{" usd ", "USD", 1, nil},
+		{"Euro", "EUR", 1, nil},
+		{"EURO", "EUR", 1, nil},
+		{"€", "EUR", 1, nil},
+		{"$", "USD", 1, nil},
+		{"£", "GBP", 1, nil},
+		{"GBP", "GBP", 1, nil},
+		{"gbp", "GBP", 1, nil},
+		{"GBp", "GBP", 100, nil},
+		{"GBX", "GBP", 100, nil},
+		{"gbx", "GBP", 100, nil},
+		{"pence", "GBP", 100, nil},
+		{"ZAc", "ZAR", 100, nil},
+		{"ZAR", "ZAR", 1, nil},
+		{"ILA", "ILS", 100, nil},
+		{"¥", "JPY", 1, nil},
+		{"XCG", "XCG", 1, nil},
+		{"", "", 1, nil},
+		{"XYZ", "", 0, ErrUnknownCurrency},
+		{"dollaro", "", 0, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		code, divisor, err := ParseCurrency(tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseCurrency(%q): expected error %v, found %v", tt.currency, tt.err, err)
+			continue
+		}
+		if code != tt.code || divisor != tt.divisor {
+			t.Errorf("ParseCurrency(%q): expected (%q, %v), found (%q, %v)",
+				tt.currency, tt.code, tt.divisor, code, divisor)
+		}
+	}
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := map[string]string{
+		"euro":  "EUR",
+		"GBp":   "GBP",
+		"usd":   "USD",
+		" XYZ ": "XYZ",
+	}
+	for currency, want := range tests {
+		if got := NormalizeCurrency(currency); got != want {
+			t.Errorf("NormalizeCurrency(%q): expected %q, found %q", currency, want, got)
+		}
+	}
+}
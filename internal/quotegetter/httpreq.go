@@ -7,14 +7,37 @@ import (
 	"time"
 )
 
-// DefaultClient xxx
-func DefaultClient(proxy string) (*http.Client, error) {
+// DefaultTimeout is the default timeout of each http request attempt.
+const DefaultTimeout = 10 * time.Second
+
+// ClientConfig defines the http.Client used by the QuoteGetters.
+//
+// Timeout is the timeout of each attempt of an http request
+// (DefaultTimeout if not defined). It doesn't include the time spent
+// waiting for the Limiters and the delays between the retries.
+//
+// Each request waits for all the Limiters: a Limiter can be shared
+// by many clients, es. to limit the requests through the same proxy.
+//
+// If the Cache is defined (with TTL > 0), the cached responses are returned
+// without any network access, so they don't wait for the Limiters
+// and they are not counted as attempts.
+type ClientConfig struct {
+	Proxy    string
+	Timeout  time.Duration
+	Retry    *RetryPolicy
+	Limiters []*Limiter
+	Cache    *Cache
+}
+
+// NewClient returns a new http.Client as specified by the config.
+func (cc *ClientConfig) NewClient() (*http.Client, error) {
 	// tr := &http.Transport{}
 	tr := http.DefaultTransport.(*http.Transport).Clone()
 
-	if len(proxy) > 0 {
+	if len(cc.Proxy) > 0 {
 		// Parse proxy URL string to a URL type
-		proxyURL, err := url.Parse(proxy)
+		proxyURL, err := url.Parse(cc.Proxy)
 		if err != nil {
 			return nil, err
 			// panic(fmt.Sprintf("Error parsing proxy URL: %q. %v", proxy, err))
@@ -22,13 +45,42 @@ func DefaultClient(proxy string) (*http.Client, error) {
 		tr.Proxy = http.ProxyURL(proxyURL)
 	}
 
+	timeout := cc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	// skip the nil limiters
+	var limiters []*Limiter
+	for _, l := range cc.Limiters {
+		if l != nil {
+			limiters = append(limiters, l)
+		}
+	}
+
+	var rt http.RoundTripper = &transport{
+		base:     tr,
+		retry:    cc.Retry,
+		limiters: limiters,
+		timeout:  timeout,
+	}
+	if cc.Cache != nil && cc.Cache.TTL > 0 {
+		rt = &cacheTransport{rt, cc.Cache}
+	}
+
 	client := &http.Client{
-		Transport: tr,
-		Timeout:   10 * time.Second,
+		Transport: rt,
 	}
 	return client, nil
 }
 
+// DefaultClient returns an http.Client with the given proxy,
+// the default timeout, no retry, no rate limit and no cache.
+func DefaultClient(proxy string) (*http.Client, error) {
+	cc := &ClientConfig{Proxy: proxy}
+	return cc.NewClient()
+}
+
 // DoHTTPRequest executes the http request.
 func DoHTTPRequest(client *http.Client, req *http.Request) (*http.Response, error) {
 	if client == nil {
@@ -1,124 +1,38 @@
 package cryptonatorcom
 
 import (
-	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetter/jsons"
 )
 
-// getter gets cryptocurrrencies prices from cryptonator.com
-type getter struct {
-	name     string
-	client   *http.Client
-	currency string
-}
-
-type jsonTicker struct {
-	Base   string
-	Target string
-	Price  string
-	Volume string
-	Change string
-}
-type jsonResult struct {
-	Ticker    jsonTicker
-	Timestamp int64
-	Success   bool
-	Error     string
-}
-
-// NewQuoteGetter creates a new QuoteGetter
-// that gets stock/fund prices from cryptonator.com
-func NewQuoteGetter(name string, client *http.Client, currency string) quotegetter.QuoteGetter {
-	return &getter{name, client, currency}
-}
-
-// Name returns the name of the scraper
-func (g *getter) Source() string {
-	return g.name
-}
-
-// Name returns the name of the scraper
-func (g *getter) Client() *http.Client {
-	return g.client
-}
-
-// GetQuote ....
-func (g *getter) GetQuote(ctx context.Context, crypto, url string) (*quotegetter.Result, error) {
-	var (
-		res  *http.Response
-		body []byte
-		r    *quotegetter.Result
-	)
-
-	// url
-	if url == "" {
-		url = fmt.Sprintf("https://api.cryptonator.com/api/ticker/%s-%s",
-			strings.ToLower(crypto),
-			strings.ToLower(g.currency))
-	}
-
-	// http.Request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-
-	// http.Response
-	if err == nil {
-		res, err = quotegetter.DoHTTPRequest(g.client, req)
-	}
-
-	// read body of http.Response
-	if err == nil {
-		body, err = ioutil.ReadAll(res.Body)
-		res.Body.Close()
-	}
-
-	// parse json to get the result
-	if err == nil {
-		r, err = g.parseJSON(body)
-	}
-
-	// success
-	if err == nil {
-		r.URL = url
-		return r, nil
+// newDefinition returns the definition of the json getter
+// of cryptonator.com for the given currency.
+//
+// Example of response:
+//
+//	{"ticker":{"base":"BTC","target":"EUR","price":"11872.29709977","volume":"9489.21251997","change":"56.52524067"},"timestamp":1604159942,"success":true,"error":""}
+func newDefinition(currency string) *jsons.Definition {
+	return &jsons.Definition{
+		URL:         "https://api.cryptonator.com/api/ticker/{{lower .Isin}}-" + currency,
+		Price:       &jsons.Field{Path: "ticker.price"},
+		PriceFormat: "point",
+		Currency:    &jsons.Field{Path: "ticker.target"},
+		Date:        &jsons.Field{Path: "timestamp"},
+		DateLayout:  "unix",
+		Error:       &jsons.Field{Path: "error"},
 	}
-
-	// error
-	return nil, err
 }
 
-func (g *getter) parseJSON(body []byte) (*quotegetter.Result, error) {
-
-	var res jsonResult
-
-	err := json.Unmarshal(body, &res)
+// NewQuoteGetter creates a new QuoteGetter
+// that gets cryptocurrrencies prices from cryptonator.com
+func NewQuoteGetter(name string, client *http.Client, currency string) (quotegetter.QuoteGetter, error) {
+	fn, err := jsons.NewQuoteGetterFactory(newDefinition(strings.ToLower(currency)))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("cryptonator.com definition: %w", err)
 	}
-
-	if res.Success {
-		price64, err := strconv.ParseFloat(res.Ticker.Price, 32)
-		if err != nil {
-			return nil, err
-		}
-
-		r := &quotegetter.Result{
-			// Isin:     res.Ticker.Base,
-			Currency: res.Ticker.Target,
-			// Source:   g.Source(),
-			Date:  time.Unix(res.Timestamp, 0),
-			Price: float32(price64),
-		}
-		return r, nil
-	}
-
-	return nil, errors.New(res.Error)
+	return fn(name, client), nil
 }
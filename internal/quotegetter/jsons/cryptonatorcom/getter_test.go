@@ -1,8 +1,13 @@
 package cryptonatorcom
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // func TestGetJson(t *testing.T) {
@@ -80,3 +85,47 @@ func TestParseJson(t *testing.T) {
 // 	// BTC2 -> Pair not found
 // 	// EURO -> Pair not found
 // }
+
+func TestGetQuoteFromServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/btc-eur":
+			fmt.Fprint(w, `{"ticker":{"base":"BTC","target":"EUR","price":"11872.29709977","volume":"9489.21251997","change":"56.52524067"},"timestamp":1604159942,"success":true,"error":""}`)
+		default:
+			fmt.Fprint(w, `{"success":false,"error":"Pair not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	g, err := NewQuoteGetter("cryptonator-eur", nil, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := g.GetQuote(context.Background(), "BTC", server.URL+"/btc-eur")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Price != 11872.29709977 {
+		t.Errorf("price: expected %v, got %v", 11872.29709977, r.Price)
+	}
+	if r.Currency != "EUR" {
+		t.Errorf("currency: expected %q, got %q", "EUR", r.Currency)
+	}
+	if !r.Date.Equal(time.Unix(1604159942, 0)) {
+		t.Errorf("date: expected %v, got %v", time.Unix(1604159942, 0), r.Date)
+	}
+
+	_, err = g.GetQuote(context.Background(), "BTC2", server.URL+"/btc2-eur")
+	if err == nil {
+		t.Errorf("expected error, got <nil>")
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	for _, currency := range []string{"eur", "usd"} {
+		if err := newDefinition(currency).Compile(); err != nil {
+			t.Errorf("%s: invalid definition: %v", currency, err)
+		}
+	}
+}
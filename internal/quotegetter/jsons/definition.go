@@ -0,0 +1,159 @@
+package jsons
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+)
+
+// Field defines how to extract a value from the json document.
+//
+// The value is the one at the Path of the document (see lookup for the syntax).
+// The Path is a template, so it can reference the isin.
+// If Value is defined, it is used as a constant value
+// and the Path is ignored.
+type Field struct {
+	Path  string `json:"path,omitempty"`
+	Value string `json:"value,omitempty"`
+
+	path *template.Template
+}
+
+// Definition defines a json getter by means of an url template and fields.
+//
+// The url template, the header values and the field paths
+// use the text/template syntax: the isin is available as {{.Isin}}
+// and the lower and upper functions can be used to change its case,
+// es. "https://host/api/{{lower .Isin}}".
+//
+// If the Isin field is not defined, the isin of the json document is not checked.
+// If the Error field is defined and its value is not empty,
+// the value is returned as error message.
+type Definition struct {
+	URL         string            `json:"url,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Isin        *Field            `json:"isin,omitempty"`
+	Price       *Field            `json:"price,omitempty"`
+	Currency    *Field            `json:"currency,omitempty"`
+	Date        *Field            `json:"date,omitempty"`
+	DateLayout  string            `json:"datelayout,omitempty"`
+	PriceFormat string            `json:"priceformat,omitempty"`
+	Error       *Field            `json:"error,omitempty"`
+
+	url         *template.Template
+	headers     map[string]*template.Template
+	priceFormat scrapers.NumberFormat
+}
+
+// templateData is the data passed to the templates.
+type templateData struct {
+	Isin string
+}
+
+var funcMap = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcMap).Parse(text)
+}
+
+// execute returns the template output for the given isin.
+func execute(tmpl *template.Template, isin string) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{isin}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// compile compiles the path template of the field.
+func (f *Field) compile(name string) (err error) {
+	if f.Value != "" {
+		return nil
+	}
+	if f.Path == "" {
+		return errors.New("path is empty")
+	}
+	f.path, err = parseTemplate(name, f.Path)
+	return err
+}
+
+// extract returns the value of the field from the json document
+// and if the value is a json number.
+// It returns an empty string if the field is nil or the value is not found.
+func (f *Field) extract(doc interface{}, isin string) (string, bool, error) {
+	if f == nil {
+		return "", false, nil
+	}
+	if f.Value != "" {
+		return f.Value, false, nil
+	}
+	path, err := execute(f.path, isin)
+	if err != nil {
+		return "", false, err
+	}
+	v, ok := lookup(doc, path)
+	if !ok {
+		return "", false, nil
+	}
+	s, isNumber := valueString(v)
+	return strings.TrimSpace(s), isNumber, nil
+}
+
+// Compile checks the definition and compiles its templates.
+// It must be called before the definition is used.
+func (d *Definition) Compile() error {
+	var err error
+
+	if d.URL == "" {
+		return errors.New("url is empty")
+	}
+	if d.url, err = parseTemplate("url", d.URL); err != nil {
+		return err
+	}
+
+	d.headers = make(map[string]*template.Template, len(d.Headers))
+	for k, v := range d.Headers {
+		if d.headers[k], err = parseTemplate(k, v); err != nil {
+			return err
+		}
+	}
+
+	// fields
+	if d.Price == nil {
+		return errors.New("price is required")
+	}
+	if d.Date == nil {
+		return errors.New("date is required")
+	}
+	if d.DateLayout == "" {
+		return errors.New("datelayout is required")
+	}
+	fields := []struct {
+		name  string
+		field *Field
+	}{
+		{"isin", d.Isin},
+		{"price", d.Price},
+		{"currency", d.Currency},
+		{"date", d.Date},
+		{"error", d.Error},
+	}
+	for _, f := range fields {
+		if f.field == nil {
+			continue
+		}
+		if err = f.field.compile(f.name); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	d.priceFormat, err = scrapers.ParseNumberFormat(d.PriceFormat)
+	return err
+}
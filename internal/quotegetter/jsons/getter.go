@@ -0,0 +1,163 @@
+// Package jsons implements a generic QuoteGetter for the json APIs.
+// The getter is defined by an url template and the paths of
+// the values in the json response.
+//
+// The errors are returned as *scrapers.Error, so they are classified
+// by the same ErrorType of the scrapers.
+package jsons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+)
+
+// getter gets stock/fund prices from a json API as specified by the definition
+type getter struct {
+	name   string
+	client *http.Client
+	def    *Definition
+}
+
+// NewQuoteGetterFactory compiles the definition and returns
+// the function that creates the corrisponding QuoteGetter.
+func NewQuoteGetterFactory(def *Definition) (quotegetter.NewQuoteGetterFunc, error) {
+	if err := def.Compile(); err != nil {
+		return nil, err
+	}
+	return func(name string, client *http.Client) quotegetter.QuoteGetter {
+		return &getter{name, client, def}
+	}, nil
+}
+
+// Source returns the name of the getter
+func (g *getter) Source() string {
+	return g.name
+}
+
+// Client returns the http.Client of the getter
+func (g *getter) Client() *http.Client {
+	return g.client
+}
+
+// newRequest creates the http GET request with the headers of the definition.
+func (g *getter) newRequest(ctx context.Context, isin, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, tmpl := range g.def.headers {
+		v, err := execute(tmpl, isin)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// GetQuote implements the method of the QuoteGetter interface.
+// If url is empty, it is built from the url template of the definition.
+func (g *getter) GetQuote(ctx context.Context, isin, url string) (*quotegetter.Result, error) {
+	var (
+		req  *http.Request
+		resp *http.Response
+		body []byte
+		err  error
+	)
+
+	// aux function
+	theError := func(err error, typ scrapers.ErrorType, pir *scrapers.ParseInfoResult) (*quotegetter.Result, error) {
+		return nil, scrapers.NewError(g.name, isin, url, typ, err, pir)
+	}
+
+	if url == "" {
+		url, err = execute(g.def.url, isin)
+	}
+	if err == nil {
+		req, err = g.newRequest(ctx, isin, url)
+	}
+	if err == nil {
+		resp, err = quotegetter.DoHTTPRequest(g.client, req)
+		if resp != nil {
+			defer resp.Body.Close()
+		}
+	}
+	if err == nil {
+		body, err = ioutil.ReadAll(resp.Body)
+	}
+	if err != nil {
+		return theError(err, scrapers.GetInfoError, nil)
+	}
+
+	pir, err := g.parse(body, isin)
+	if err != nil {
+		errType := scrapers.ParseInfoError
+		if err == scrapers.ErrNoResultFound {
+			errType = scrapers.NoResultFoundError
+		}
+		return theError(err, errType, pir)
+	}
+
+	return scrapers.NewResult(pir, g.name, isin, url)
+}
+
+// parse extracts the fields of the definition from the json body.
+func (g *getter) parse(body []byte, isin string) (*scrapers.ParseInfoResult, error) {
+	def := g.def
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	msg, _, err := def.Error.extract(doc, isin)
+	if err != nil {
+		return nil, err
+	}
+	if msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	r := &scrapers.ParseInfoResult{
+		PriceFormat: def.priceFormat,
+		DateLayout:  def.DateLayout,
+	}
+	var isNumber bool
+
+	if r.IsinStr, _, err = def.Isin.extract(doc, isin); err != nil {
+		return r, err
+	}
+	if r.PriceStr, isNumber, err = def.Price.extract(doc, isin); err != nil {
+		return r, err
+	}
+	if isNumber {
+		// json numbers always use the point as decimal separator
+		r.PriceFormat = scrapers.NumberFormatPoint
+	}
+	if r.CurrencyStr, _, err = def.Currency.extract(doc, isin); err != nil {
+		return r, err
+	}
+	if r.DateStr, _, err = def.Date.extract(doc, isin); err != nil {
+		return r, err
+	}
+
+	if r.PriceStr == "" && r.DateStr == "" {
+		return r, scrapers.ErrNoResultFound
+	}
+
+	// the isin is not checked if the definition has no isin field
+	if def.Isin == nil {
+		r.IsinStr = isin
+	}
+
+	return r, nil
+}
@@ -0,0 +1,139 @@
+package jsons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers/testingscraper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestDefinition(url string) *Definition {
+	return &Definition{
+		URL:        url + "/api/{{lower .Isin}}",
+		Headers:    map[string]string{"X-Isin": "{{.Isin}}"},
+		Isin:       &Field{Path: "data.isin"},
+		Price:      &Field{Path: "data.nav"},
+		Currency:   &Field{Path: "data.currency"},
+		Date:       &Field{Path: "data.date"},
+		DateLayout: "2006-01-02",
+		Error:      &Field{Path: "error"},
+	}
+}
+
+func TestNewQuoteGetter(t *testing.T) {
+	fn, err := NewQuoteGetterFactory(getTestDefinition("http://127.0.0.1"))
+	require.NoError(t, err)
+	testingscraper.TestNewQuoteGetter(t, fn)
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Definition)
+		errmsg string
+	}{
+		{"ok", func(d *Definition) {}, ""},
+		{"no url", func(d *Definition) { d.URL = "" }, "url is empty"},
+		{"invalid url template", func(d *Definition) { d.URL = "http://x/{{.Isin" }, "unclosed action"},
+		{"invalid header", func(d *Definition) { d.Headers["X"] = "{{" }, "unclosed action"},
+		{"no price", func(d *Definition) { d.Price = nil }, "price is required"},
+		{"no date", func(d *Definition) { d.Date = nil }, "date is required"},
+		{"no date layout", func(d *Definition) { d.DateLayout = "" }, "datelayout is required"},
+		{"empty path", func(d *Definition) { d.Currency = &Field{} }, "currency: path is empty"},
+		{"constant value", func(d *Definition) { d.Currency = &Field{Value: "EUR"} }, ""},
+		{"invalid path template", func(d *Definition) { d.Isin.Path = "{{.Isin" }, "isin:"},
+		{"invalid price format", func(d *Definition) { d.PriceFormat = "space" }, "invalid number format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := getTestDefinition("http://127.0.0.1")
+			tt.modify(def)
+			err := def.Compile()
+			if tt.errmsg == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.errmsg)
+			}
+		})
+	}
+}
+
+func TestGetQuote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Isin") == "" {
+			http.Error(w, "missing header", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/isin00000001":
+			fmt.Fprint(w, `{"data":{"isin":"ISIN00000001","nav":1234.5,"currency":"EUR","date":"2020-09-11"}}`)
+		case "/api/isin00000002":
+			fmt.Fprint(w, `{"data":{"isin":"ISIN00000002","nav":"1.234,50","currency":"EUR","date":"2020-09-11"}}`)
+		case "/api/isin00000003":
+			fmt.Fprint(w, `{"data":{"isin":"ISIN99999999","nav":1,"currency":"EUR","date":"2020-09-11"}}`)
+		case "/api/isin00000004":
+			fmt.Fprint(w, `{"data":{}}`)
+		case "/api/isin00000005":
+			fmt.Fprint(w, `{"error":"Pair not found"}`)
+		case "/api/isin00000006":
+			fmt.Fprint(w, `{"data":{"isin":"ISIN00000006","nav":"N/A","date":"2020-09-11"}}`)
+		case "/api/isin00000007":
+			fmt.Fprint(w, `{"data":{"isin":"ISIN00000007","nav":1,"date":"11/09/2020"}}`)
+		case "/api/isin00000008":
+			fmt.Fprint(w, `<html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fn, err := NewQuoteGetterFactory(getTestDefinition(server.URL))
+	require.NoError(t, err)
+	qg := fn("test", http.DefaultClient)
+
+	date := time.Date(2020, time.September, 11, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		isin    string
+		price   float64
+		errType scrapers.ErrorType
+		errmsg  string
+	}{
+		{"ISIN00000001", 1234.5, scrapers.Success, ""},
+		{"ISIN00000002", 1234.5, scrapers.Success, ""},
+		{"ISIN00000003", 0, scrapers.IsinMismatchError, "isin mismatch"},
+		{"ISIN00000004", 0, scrapers.NoResultFoundError, "no result found"},
+		{"ISIN00000005", 0, scrapers.ParseInfoError, "Pair not found"},
+		{"ISIN00000006", 0, scrapers.InvalidPriceError, "invalid price string"},
+		{"ISIN00000007", 0, scrapers.InvalidDateError, "cannot parse"},
+		{"ISIN00000008", 0, scrapers.ParseInfoError, "invalid character"},
+		{"ISIN00000009", 0, scrapers.GetInfoError, "404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.isin, func(t *testing.T) {
+			res, err := qg.GetQuote(context.Background(), tt.isin, "")
+			if tt.errType == scrapers.Success {
+				require.NoError(t, err)
+				assert.Equal(t, tt.price, res.Price)
+				assert.Equal(t, "EUR", res.Currency)
+				assert.Equal(t, date, res.Date)
+				assert.Equal(t, server.URL+"/api/"+"isin0000000"+tt.isin[11:], res.URL)
+				return
+			}
+			var e *scrapers.Error
+			if assert.True(t, errors.As(err, &e), "expected *scrapers.Error, found %v", err) {
+				assert.Contains(t, e.Error(), tt.errmsg)
+				assert.Equal(t, tt.isin, e.Isin())
+				assert.Equal(t, "test", e.Source())
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package jsons
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// splitPath splits the path in its components.
+// The components are separated by ".", except inside parentheses;
+// a "." can be escaped by "\.".
+func splitPath(path string) []string {
+	var (
+		parts []string
+		sb    strings.Builder
+		depth int
+	)
+	for j := 0; j < len(path); j++ {
+		c := path[j]
+		switch {
+		case c == '\\' && j+1 < len(path) && path[j+1] == '.':
+			sb.WriteByte('.')
+			j++
+		case c == '(':
+			depth++
+			sb.WriteByte(c)
+		case c == ')':
+			depth--
+			sb.WriteByte(c)
+		case c == '.' && depth == 0:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(parts, sb.String())
+}
+
+// parseQuery parses a component of the path in the form #(key==value).
+// The value can be enclosed in double quotes.
+func parseQuery(part string) (key, value string, ok bool) {
+	if !strings.HasPrefix(part, "#(") || !strings.HasSuffix(part, ")") {
+		return "", "", false
+	}
+	a := strings.SplitN(part[2:len(part)-1], "==", 2)
+	if len(a) != 2 {
+		return "", "", false
+	}
+	key = strings.TrimSpace(a[0])
+	value = strings.TrimSpace(a[1])
+	if uq, err := strconv.Unquote(value); err == nil {
+		value = uq
+	}
+	return key, value, true
+}
+
+// lookup returns the value of the json document at the given path.
+//
+// The path is a "." separated list of components. Each component can be:
+//   - the key of an object: "ticker";
+//   - the index of an array: "0";
+//   - "#": the length of an array;
+//   - "#(key==value)": the first object of an array
+//     whose key has the given value.
+//
+// For example "data.#(isin==IT0000000001).nav" returns the nav of the
+// element of the data array with isin equal to IT0000000001.
+// The empty path returns the whole document.
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return doc, true
+	}
+
+	v := doc
+	for _, part := range splitPath(path) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = t[part]; !ok {
+				return nil, false
+			}
+
+		case []interface{}:
+			if part == "#" {
+				v = json.Number(strconv.Itoa(len(t)))
+				continue
+			}
+			if key, value, ok := parseQuery(part); ok {
+				v = nil
+				for _, item := range t {
+					if m, ok := item.(map[string]interface{}); ok {
+						if s, _ := valueString(m[key]); s == value {
+							v = item
+							break
+						}
+					}
+				}
+				if v == nil {
+					return nil, false
+				}
+				continue
+			}
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(t) {
+				return nil, false
+			}
+			v = t[idx]
+
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// valueString returns the string representation of a scalar json value
+// and if the value is a number.
+// Objects, arrays and null values are returned as an empty string.
+func valueString(v interface{}) (s string, isNumber bool) {
+	switch t := v.(type) {
+	case string:
+		return t, false
+	case json.Number:
+		return t.String(), true
+	case bool:
+		return strconv.FormatBool(t), false
+	}
+	return "", false
+}
@@ -0,0 +1,76 @@
+package jsons
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"a", []string{"a"}},
+		{"a.b.0", []string{"a", "b", "0"}},
+		{`a\.b.c`, []string{"a.b", "c"}},
+		{"data.#(id==a.b).nav", []string{"data", "#(id==a.b)", "nav"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, splitPath(tt.path), tt.path)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	const body = `{
+		"ticker": {"base": "BTC", "price": "11872.29", "volume": 9489.21},
+		"timestamp": 1604159942,
+		"success": true,
+		"empty": null,
+		"data": [
+			{"isin": "IT0000000001", "nav": 10.5},
+			{"isin": "IT0000000002", "nav": "11,25"}
+		],
+		"a.b": "dotted"
+	}`
+
+	var doc interface{}
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	require.NoError(t, dec.Decode(&doc))
+
+	tests := []struct {
+		path     string
+		want     string
+		isNumber bool
+		found    bool
+	}{
+		{"ticker.base", "BTC", false, true},
+		{"ticker.price", "11872.29", false, true},
+		{"ticker.volume", "9489.21", true, true},
+		{"timestamp", "1604159942", true, true},
+		{"success", "true", false, true},
+		{"empty", "", false, true},
+		{"data.1.isin", "IT0000000002", false, true},
+		{"data.#", "2", true, true},
+		{"data.#(isin==IT0000000002).nav", "11,25", false, true},
+		{`data.#(isin=="IT0000000001").nav`, "10.5", true, true},
+		{`a\.b`, "dotted", false, true},
+		{"ticker", "", false, true},
+		{"ticker.missing", "", false, false},
+		{"data.2.isin", "", false, false},
+		{"data.x", "", false, false},
+		{"data.#(isin==IT0000000003).nav", "", false, false},
+		{"timestamp.value", "", false, false},
+	}
+	for _, tt := range tests {
+		v, found := lookup(doc, tt.path)
+		assert.Equal(t, tt.found, found, "found %q", tt.path)
+		s, isNumber := valueString(v)
+		assert.Equal(t, tt.want, s, "value %q", tt.path)
+		assert.Equal(t, tt.isNumber, isNumber, "isNumber %q", tt.path)
+	}
+}
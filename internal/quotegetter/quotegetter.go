@@ -3,14 +3,17 @@ package quotegetter
 import (
 	"context"
 	"net/http"
-	"strings"
 	"time"
 )
 
-// Result represents the info returned by the GetQuote function in case of success
+// Result represents the info returned by the GetQuote function in case of success.
+//
+// PriceRaw is the price string as returned by the source,
+// before it is parsed to the Price value.
 type Result struct {
 	URL      string
-	Price    float32
+	Price    float64
+	PriceRaw string
 	Currency string
 	Date     time.Time
 }
@@ -22,11 +25,12 @@ type QuoteGetter interface {
 	GetQuote(ctx context.Context, isin, url string) (*Result, error)
 }
 
-// NormalizeCurrency return the standard ISO4217 representation
-// of the known currency
-func NormalizeCurrency(currency string) string {
-	if strings.EqualFold(currency, "euro") {
-		return "EUR"
-	}
-	return currency
+// HistoryGetter interface is implemented by the QuoteGetters
+// that can also retrieve the price history of an isin.
+//
+// GetHistory returns the quotes with date in the [from, to] interval,
+// sorted by ascending date.
+type HistoryGetter interface {
+	QuoteGetter
+	GetHistory(ctx context.Context, isin, url string, from, to time.Time) ([]*Result, error)
 }
@@ -0,0 +1,102 @@
+package quotegetter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit defines the max rate of the http requests.
+//
+// Rate is the number of requests per second (0 for no limit)
+// and Burst is the max number of requests that can be made at once (default 1).
+// MinDelay is the minimum delay between two consecutive requests.
+type RateLimit struct {
+	Rate     float64       `json:"rate,omitempty"`
+	Burst    int           `json:"burst,omitempty"`
+	MinDelay time.Duration `json:"min_delay,omitempty"`
+}
+
+// Limiter is a token bucket rate limiter.
+// It is safe for concurrent use and it can be shared by many http.Client.
+type Limiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	minDelay time.Duration
+	tokens   float64
+	last     time.Time // last update of tokens
+	next     time.Time // earliest time of the next request, due to minDelay
+}
+
+// NewLimiter returns a new Limiter with the given RateLimit.
+// It returns nil if the RateLimit is nil or has no limits.
+func NewLimiter(rl *RateLimit) *Limiter {
+	if rl == nil || (rl.Rate <= 0 && rl.MinDelay <= 0) {
+		return nil
+	}
+	burst := rl.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:     rl.Rate,
+		burst:    float64(burst),
+		minDelay: rl.MinDelay,
+		tokens:   float64(burst),
+	}
+}
+
+// reserve reserves a request at the given time
+// and returns how long the request has to wait.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+
+	if l.rate > 0 {
+		// refill the bucket
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * l.rate
+			if l.tokens > l.burst {
+				l.tokens = l.burst
+			}
+		}
+		l.last = now
+
+		// tokens can be negative: the requests are queued
+		l.tokens--
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+
+	if d := l.next.Sub(now); d > wait {
+		wait = d
+	}
+	l.next = now.Add(wait + l.minDelay)
+
+	return wait
+}
+
+// Wait blocks until the request is allowed by the limiter
+// or the context is done. A nil Limiter does not block.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	wait := l.reserve(time.Now())
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,146 @@
+package quotegetter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewLimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		rl   *RateLimit
+		nil  bool
+	}{
+		{"nil", nil, true},
+		{"no limits", &RateLimit{Burst: 3}, true},
+		{"rate", &RateLimit{Rate: 1}, false},
+		{"min delay", &RateLimit{MinDelay: time.Second}, false},
+	}
+	for _, tt := range tests {
+		if l := NewLimiter(tt.rl); (l == nil) != tt.nil {
+			t.Errorf("%s: expected nil=%v, found %v", tt.name, tt.nil, l)
+		}
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	t0 := time.Date(2022, time.May, 1, 10, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	ms := func(ms int) time.Duration { return time.Duration(ms) * time.Millisecond }
+
+	type step struct {
+		at   int // ms from t0
+		want int // ms of wait
+	}
+	tests := []struct {
+		name  string
+		rl    *RateLimit
+		steps []step
+	}{
+		{
+			name:  "rate 10/s",
+			rl:    &RateLimit{Rate: 10},
+			steps: []step{{0, 0}, {0, 100}, {0, 200}, {500, 0}, {500, 100}},
+		},
+		{
+			name:  "rate 10/s burst 2",
+			rl:    &RateLimit{Rate: 10, Burst: 2},
+			steps: []step{{0, 0}, {0, 0}, {0, 100}, {1000, 0}, {1000, 0}, {1000, 100}},
+		},
+		{
+			name:  "min delay",
+			rl:    &RateLimit{MinDelay: ms(300)},
+			steps: []step{{0, 0}, {100, 200}, {200, 400}, {2000, 0}},
+		},
+		{
+			name:  "rate and min delay",
+			rl:    &RateLimit{Rate: 10, Burst: 5, MinDelay: ms(50)},
+			steps: []step{{0, 0}, {0, 50}, {0, 100}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.rl)
+			for j, s := range tt.steps {
+				if got := l.reserve(at(s.at)); got != ms(s.want) {
+					t.Errorf("step %d: expected wait %v, found %v", j, ms(s.want), got)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	l := NewLimiter(&RateLimit{Rate: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("first wait: unexpected error %v", err)
+	}
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("second wait: expected %v, found %v", context.DeadlineExceeded, err)
+	}
+
+	// a nil limiter does not block
+	var lnil *Limiter
+	if err := lnil.Wait(context.Background()); err != nil {
+		t.Errorf("nil limiter: unexpected error %v", err)
+	}
+}
+
+func TestClientWithLimiters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// the proxy limiter is shared by the two clients
+	proxyLimiter := NewLimiter(&RateLimit{MinDelay: 40 * time.Millisecond})
+
+	cc1 := &ClientConfig{Timeout: 30 * time.Millisecond, Limiters: []*Limiter{nil, proxyLimiter}}
+	cc2 := &ClientConfig{Timeout: 30 * time.Millisecond, Limiters: []*Limiter{proxyLimiter}}
+	client1, _ := cc1.NewClient()
+	client2, _ := cc2.NewClient()
+
+	start := time.Now()
+	for _, client := range []*http.Client{client1, client2, client1} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		// the wait of the limiter is not included in the timeout
+		resp, err := DoHTTPRequest(client, req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected elapsed >= 80ms, found %v", elapsed)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	cc := &ClientConfig{
+		Timeout: 20 * time.Millisecond,
+		Retry:   &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	}
+	client, _ := cc.NewClient()
+
+	ctx, attempts := WithAttemptsCounter(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err := DoHTTPRequest(client, req)
+	if err == nil {
+		t.Fatal("Expected error, got success")
+	}
+	// the attempt timeout is retried
+	if got := attempts(); got != 2 {
+		t.Errorf("attempts: expected 2, found %d", got)
+	}
+}
@@ -0,0 +1,136 @@
+package quotegetter
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Default values of the RetryPolicy
+const (
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// DefaultRetryStatusCodes are the status codes retried
+// if the RetryPolicy does not specify them.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy defines how the http requests are retried
+// in case of network errors or retryable status codes.
+//
+// The delay before the n-th retry is BaseDelay * 2^(n-1), capped to MaxDelay,
+// with a random jitter of up to half the delay.
+// If the response has the Retry-After header, its value is used instead
+// (capped to MaxDelay).
+type RetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts,omitempty"`
+	BaseDelay   time.Duration `json:"base_delay,omitempty"`
+	MaxDelay    time.Duration `json:"max_delay,omitempty"`
+	StatusCodes []int         `json:"status_codes,omitempty"`
+}
+
+// attemptsKey is the key of the attempts counter in the context.
+type attemptsKey struct{}
+
+// WithAttemptsCounter returns a copy of the context with a counter
+// of the http attempts made with it, retries included.
+// The returned function returns the current value of the counter.
+func WithAttemptsCounter(ctx context.Context) (context.Context, func() int) {
+	var n int32
+	ctx = context.WithValue(ctx, attemptsKey{}, &n)
+	return ctx, func() int { return int(atomic.LoadInt32(&n)) }
+}
+
+// incAttempts increments the attempts counter of the context, if any.
+func incAttempts(ctx context.Context) {
+	if n, ok := ctx.Value(attemptsKey{}).(*int32); ok {
+		atomic.AddInt32(n, 1)
+	}
+}
+
+// maxAttempts returns the number of attempts of the policy.
+// A nil policy makes a single attempt.
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// isRetryableStatus returns if the status code must be retried.
+func (p *RetryPolicy) isRetryableStatus(code int) bool {
+	codes := p.StatusCodes
+	if len(codes) == 0 {
+		codes = DefaultRetryStatusCodes
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the delay before the retry following the given attempt (1 based).
+// The Retry-After header of the response, if present, has precedence.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	if d, ok := retryAfter(resp, time.Now()); ok {
+		if d > maxDelay {
+			d = maxDelay
+		}
+		return d
+	}
+
+	d := p.BaseDelay
+	if d <= 0 {
+		d = DefaultRetryBaseDelay
+	}
+	for j := 1; j < attempt && d < maxDelay; j++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	// jitter
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter returns the delay specified by the Retry-After header of the response.
+// The header can be expressed in seconds or as http date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
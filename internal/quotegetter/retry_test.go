@@ -0,0 +1,195 @@
+package quotegetter
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFailingServer returns a server that responds with the given status code
+// to the first fails requests, and with 200 OK to the others.
+// The number of received requests is stored in count.
+func newFailingServer(fails int32, code int, retryAfter string, count *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(count, 1)
+		if n <= fails {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(code)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name         string
+		fails        int32
+		code         int
+		retryAfter   string
+		policy       *RetryPolicy
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"ok", 0, 0, "", policy, 1, false},
+		{"ok after retries", 2, http.StatusServiceUnavailable, "", policy, 3, false},
+		{"ko after max attempts", 5, http.StatusServiceUnavailable, "", policy, 3, true},
+		{"not retryable status", 1, http.StatusNotFound, "", policy, 1, true},
+		{"retry after", 1, http.StatusTooManyRequests, "0", policy, 2, false},
+		{"custom status codes", 1, http.StatusNotFound, "", &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, StatusCodes: []int{404}}, 2, false},
+		{"no policy", 1, http.StatusServiceUnavailable, "", nil, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var count int32
+			server := newFailingServer(tt.fails, tt.code, tt.retryAfter, &count)
+			defer server.Close()
+
+			cc := &ClientConfig{Retry: tt.policy}
+			client, err := cc.NewClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, attempts := WithAttemptsCounter(context.Background())
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			resp, err := DoHTTPRequest(client, req)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if got := attempts(); got != tt.wantAttempts {
+				t.Errorf("attempts: expected %d, found %d", tt.wantAttempts, got)
+			}
+			if got := int(atomic.LoadInt32(&count)); got != tt.wantAttempts {
+				t.Errorf("requests: expected %d, found %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	var count int32
+	server := newFailingServer(5, http.StatusServiceUnavailable, "", &count)
+	defer server.Close()
+
+	cc := &ClientConfig{Retry: &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second}}
+	client, _ := cc.NewClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	_, err := DoHTTPRequest(client, req)
+	if err == nil {
+		t.Error("Expected error, got success")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("backoff not interrupted by the context: elapsed %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&count); got != 1 {
+		t.Errorf("requests: expected 1, found %d", got)
+	}
+}
+
+func TestRetryBody(t *testing.T) {
+	var bodies []string
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if atomic.AddInt32(&count, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cc := &ClientConfig{Retry: &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}}
+	client, _ := cc.NewClient()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("isin=isin1"))
+	body := req.Body
+	resp, err := client.Transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 2 || bodies[0] != "isin=isin1" || bodies[1] != "isin=isin1" {
+		t.Errorf("bodies: expected the body at each attempt, found %q", bodies)
+	}
+	if req.Body != body {
+		t.Error("the body of the request is modified by the retry")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		for j := 0; j < 20; j++ {
+			d := p.delay(tt.attempt, nil)
+			if d < tt.min || d > tt.max {
+				t.Errorf("delay(%d): expected in [%v, %v], found %v", tt.attempt, tt.min, tt.max, d)
+				break
+			}
+		}
+	}
+
+	// Retry-After is capped to MaxDelay
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"120"}}}
+	if d := p.delay(1, resp); d != time.Second {
+		t.Errorf("delay with Retry-After: expected %v, found %v", time.Second, d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2022, time.May, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"Sun, 01 May 2022 10:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 01 May 2022 09:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		d, ok := retryAfter(resp, now)
+		if d != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q): expected (%v, %v), found (%v, %v)", tt.header, tt.want, tt.ok, d, ok)
+		}
+	}
+}
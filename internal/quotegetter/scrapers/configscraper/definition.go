@@ -0,0 +1,187 @@
+package configscraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+)
+
+// Field defines how to extract a value from the html document.
+//
+// The value is the text of the first element matching the CSS Selector,
+// or the value of its Attr attribute, if defined.
+// If Regexp is defined, the value is the first submatch of the regexp
+// (or the whole match, if the regexp has no groups).
+// If Value is defined, it is used as a constant value
+// and the other fields are ignored.
+type Field struct {
+	Selector string `json:"selector,omitempty"`
+	Attr     string `json:"attr,omitempty"`
+	Regexp   string `json:"regexp,omitempty"`
+	Value    string `json:"value,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Definition defines a scraper by means of url templates and fields.
+//
+// The url templates (and the header values) use the text/template syntax;
+// the isin is available as {{.Isin}}.
+//
+// If SearchURL is defined, the search page is retrieved and
+// the url of the info page is extracted by SearchLink
+// (the "href" attribute is used if Attr is not defined).
+// Otherwise the url of the info page is built from the InfoURL template.
+//
+// If the Isin field is not defined, the isin of the info page is not checked.
+type Definition struct {
+	SearchURL   string            `json:"searchurl,omitempty"`
+	SearchLink  *Field            `json:"searchlink,omitempty"`
+	InfoURL     string            `json:"infourl,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Isin        *Field            `json:"isin,omitempty"`
+	Price       *Field            `json:"price,omitempty"`
+	Currency    *Field            `json:"currency,omitempty"`
+	Date        *Field            `json:"date,omitempty"`
+	DateLayout  string            `json:"datelayout,omitempty"`
+	PriceFormat string            `json:"priceformat,omitempty"`
+
+	searchURL   *template.Template
+	infoURL     *template.Template
+	headers     map[string]*template.Template
+	priceFormat scrapers.NumberFormat
+}
+
+// templateData is the data passed to the templates.
+type templateData struct {
+	Isin string
+}
+
+// compile compiles the regexp of the field.
+func (f *Field) compile() error {
+	if f.Value != "" {
+		return nil
+	}
+	if f.Selector == "" {
+		return errors.New("selector is empty")
+	}
+	if f.Regexp != "" {
+		re, err := regexp.Compile(f.Regexp)
+		if err != nil {
+			return err
+		}
+		f.re = re
+	}
+	return nil
+}
+
+// extract returns the value of the field from the document.
+// It returns an empty string if the field is nil or the value is not found.
+func (f *Field) extract(doc *goquery.Document) string {
+	if f == nil {
+		return ""
+	}
+	if f.Value != "" {
+		return f.Value
+	}
+
+	sel := doc.Find(f.Selector).First()
+	var s string
+	if f.Attr != "" {
+		s, _ = sel.Attr(f.Attr)
+	} else {
+		s = sel.Text()
+	}
+	s = strings.TrimSpace(s)
+
+	if f.re != nil {
+		m := f.re.FindStringSubmatch(s)
+		switch len(m) {
+		case 0:
+			return ""
+		case 1:
+			return m[0]
+		}
+		return strings.TrimSpace(m[1])
+	}
+	return s
+}
+
+// Compile checks the definition and compiles its templates and regexps.
+// It must be called before the definition is used.
+func (d *Definition) Compile() error {
+	var err error
+
+	if d.SearchURL == "" && d.InfoURL == "" {
+		return errors.New("searchurl and infourl are both empty")
+	}
+	if d.SearchURL != "" {
+		if d.SearchLink == nil {
+			return errors.New("searchlink is required with searchurl")
+		}
+		if err = d.SearchLink.compile(); err != nil {
+			return fmt.Errorf("searchlink: %w", err)
+		}
+		if d.searchURL, err = template.New("searchurl").Parse(d.SearchURL); err != nil {
+			return err
+		}
+	}
+	if d.InfoURL != "" {
+		if d.infoURL, err = template.New("infourl").Parse(d.InfoURL); err != nil {
+			return err
+		}
+	}
+
+	d.headers = make(map[string]*template.Template, len(d.Headers))
+	for k, v := range d.Headers {
+		if d.headers[k], err = template.New(k).Parse(v); err != nil {
+			return err
+		}
+	}
+
+	// fields
+	if d.Price == nil {
+		return errors.New("price is required")
+	}
+	if d.Date == nil {
+		return errors.New("date is required")
+	}
+	if d.DateLayout == "" {
+		return errors.New("datelayout is required")
+	}
+	fields := []struct {
+		name  string
+		field *Field
+	}{
+		{"isin", d.Isin},
+		{"price", d.Price},
+		{"currency", d.Currency},
+		{"date", d.Date},
+	}
+	for _, f := range fields {
+		if f.field == nil {
+			continue
+		}
+		if err = f.field.compile(); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	d.priceFormat, err = scrapers.ParseNumberFormat(d.PriceFormat)
+	return err
+}
+
+// execute returns the template output for the given isin.
+func execute(tmpl *template.Template, isin string) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{isin}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
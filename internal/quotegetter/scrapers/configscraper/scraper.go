@@ -0,0 +1,117 @@
+// Package configscraper implements a generic scraper
+// defined by the configuration instead of the code.
+package configscraper
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+)
+
+// scraper gets stock/fund prices as specified by the definition
+type scraper struct {
+	name   string
+	client *http.Client
+	def    *Definition
+}
+
+// NewQuoteGetterFactory compiles the definition and returns
+// the function that creates the corrisponding QuoteGetter.
+func NewQuoteGetterFactory(def *Definition) (quotegetter.NewQuoteGetterFunc, error) {
+	if err := def.Compile(); err != nil {
+		return nil, err
+	}
+	return func(name string, client *http.Client) quotegetter.QuoteGetter {
+		return scrapers.NewQuoteGetter(&scraper{name, client, def})
+	}, nil
+}
+
+// Name returns the name of the scraper
+func (s *scraper) Source() string {
+	return s.name
+}
+
+// Client returns the http.Client of the scraper
+func (s *scraper) Client() *http.Client {
+	return s.client
+}
+
+// newRequest creates the http GET request with the headers of the definition.
+func (s *scraper) newRequest(ctx context.Context, isin, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, tmpl := range s.def.headers {
+		v, err := execute(tmpl, isin)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// GetSearch creates the http.Request to get the search page for the specified `isin`.
+// It returns nil if the definition has not the search url.
+func (s *scraper) GetSearch(ctx context.Context, isin string) (*http.Request, error) {
+	if s.def.searchURL == nil {
+		return nil, nil
+	}
+	url, err := execute(s.def.searchURL, isin)
+	if err != nil {
+		return nil, err
+	}
+	return s.newRequest(ctx, isin, url)
+}
+
+// ParseSearch parse the html of the search page to find the URL of the info page.
+// `doc` is nil if the url of the info page is built from the info url template.
+func (s *scraper) ParseSearch(doc *goquery.Document, isin string) (string, error) {
+	if doc == nil {
+		return execute(s.def.infoURL, isin)
+	}
+
+	link := *s.def.SearchLink
+	if link.Attr == "" && link.Value == "" {
+		link.Attr = "href"
+	}
+	url := link.extract(doc)
+	if url == "" {
+		return "", scrapers.ErrNoResultFound
+	}
+	return url, nil
+}
+
+// GetInfo creates the http.Request to get the info page.
+func (s *scraper) GetInfo(ctx context.Context, isin, url string) (*http.Request, error) {
+	return s.newRequest(ctx, isin, url)
+}
+
+// ParseInfo extracts the fields of the definition from the info page.
+func (s *scraper) ParseInfo(doc *goquery.Document, isin string) (*scrapers.ParseInfoResult, error) {
+	def := s.def
+
+	r := &scrapers.ParseInfoResult{
+		IsinStr:     def.Isin.extract(doc),
+		PriceStr:    def.Price.extract(doc),
+		PriceFormat: def.priceFormat,
+		CurrencyStr: def.Currency.extract(doc),
+		DateStr:     def.Date.extract(doc),
+		DateLayout:  def.DateLayout,
+	}
+
+	if r.PriceStr == "" && r.DateStr == "" {
+		return r, scrapers.ErrNoResultFound
+	}
+
+	// the isin is not checked if the definition has no isin field
+	if def.Isin == nil {
+		r.IsinStr = isin
+	}
+
+	return r, nil
+}
@@ -0,0 +1,194 @@
+package configscraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers/testingscraper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fundsquarenet like definition
+func getTestDefinition() *Definition {
+	return &Definition{
+		InfoURL: "http://127.0.0.1/info/{{.Isin}}",
+		Headers: map[string]string{
+			"Referer": "http://127.0.0.1/search?isin={{.Isin}}",
+		},
+		Isin:        &Field{Selector: "div#content table td span"},
+		Price:       &Field{Selector: "div#content span.surligneorange", Regexp: `([0-9.,]+)`},
+		Currency:    &Field{Selector: "div#content span.surligneorange", Regexp: `[A-Z]{3}`},
+		Date:        &Field{Selector: "div#content table td[width='15%']"},
+		DateLayout:  "02/01/2006",
+		PriceFormat: "point",
+	}
+}
+
+const testInfoHTML = `<div id="content">
+ <table style="width: 100%%">
+  <tr><td><span style="font-weight: bold;">%s</span>&nbsp;&nbsp;PIMCO GIS Diversified Income Fund&nbsp;&nbsp;</td><td></td></tr>
+ </table>
+ <table width="85%%">
+  <tr>
+   <td width="30%%">Last NAV</td>
+   <td width="15%%">11/09/2020</td>
+   <td width="55%%"><span class="surligneorange">1,211.49 EUR</span>&nbsp;</td>
+  </tr>
+ </table>
+</div>`
+
+func TestNewQuoteGetter(t *testing.T) {
+	fn, err := NewQuoteGetterFactory(getTestDefinition())
+	require.NoError(t, err)
+	testingscraper.TestNewQuoteGetter(t, fn)
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Definition)
+		errmsg string
+	}{
+		{"ok", func(d *Definition) {}, ""},
+		{"no urls", func(d *Definition) { d.InfoURL = "" }, "searchurl and infourl are both empty"},
+		{"search without link", func(d *Definition) { d.SearchURL = "http://x/{{.Isin}}" }, "searchlink is required"},
+		{"invalid template", func(d *Definition) { d.InfoURL = "http://x/{{.Isin" }, "unclosed action"},
+		{"no price", func(d *Definition) { d.Price = nil }, "price is required"},
+		{"no date layout", func(d *Definition) { d.DateLayout = "" }, "datelayout is required"},
+		{"empty selector", func(d *Definition) { d.Isin = &Field{} }, "isin: selector is empty"},
+		{"invalid regexp", func(d *Definition) { d.Price.Regexp = "([0-9" }, "price: error parsing regexp"},
+		{"constant value", func(d *Definition) { d.Currency = &Field{Value: "EUR"} }, ""},
+		{"invalid price format", func(d *Definition) { d.PriceFormat = "space" }, "invalid number format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := getTestDefinition()
+			tt.modify(def)
+			err := def.Compile()
+			if tt.errmsg == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.errmsg)
+			}
+		})
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		modify   func(*Definition)
+		expected *scrapers.ParseInfoResult
+		err      error
+	}{
+		{
+			name: "ok",
+			html: fmt.Sprintf(testInfoHTML, testingscraper.TestIsin),
+			expected: &scrapers.ParseInfoResult{
+				IsinStr:     testingscraper.TestIsin,
+				PriceStr:    "1,211.49",
+				PriceFormat: scrapers.NumberFormatPoint,
+				CurrencyStr: "EUR",
+				DateStr:     "11/09/2020",
+				DateLayout:  "02/01/2006",
+			},
+		},
+		{
+			name: "ok, no isin field and constant currency",
+			html: fmt.Sprintf(testInfoHTML, "XXX"),
+			modify: func(d *Definition) {
+				d.Isin = nil
+				d.Currency = &Field{Value: "USD"}
+				d.Price = &Field{Selector: "div#content span.surligneorange"}
+				d.PriceFormat = ""
+			},
+			expected: &scrapers.ParseInfoResult{
+				IsinStr:     testingscraper.TestIsin,
+				PriceStr:    "1,211.49 EUR",
+				CurrencyStr: "USD",
+				DateStr:     "11/09/2020",
+				DateLayout:  "02/01/2006",
+			},
+		},
+		{
+			name: "no result",
+			html: `<div class="contenu"><p><span class="surligneorange"> No result</span> produced by your request.</p></div>`,
+			err:  scrapers.ErrNoResultFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := getTestDefinition()
+			if tt.modify != nil {
+				tt.modify(def)
+			}
+			require.NoError(t, def.Compile())
+			scr := &scraper{"test", nil, def}
+
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			require.NoError(t, err)
+
+			pir, err := scr.ParseInfo(doc, testingscraper.TestIsin)
+			if testingscraper.CheckError(t, "ParseInfo", err, tt.err) {
+				return
+			}
+			assert.Equal(t, tt.expected, pir)
+		})
+	}
+}
+
+func TestGetQuote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search":
+			isin := r.URL.Query().Get("q")
+			if isin == testingscraper.TestIsin {
+				fmt.Fprintf(w, `<ul class="results"><li><a href="/info/%s">%s</a></li></ul>`, isin, isin)
+			} else {
+				fmt.Fprint(w, `<ul class="results"></ul>`)
+			}
+		case strings.HasPrefix(r.URL.Path, "/info/"):
+			if r.Header.Get("X-Isin") == "" {
+				http.Error(w, "missing header", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, testInfoHTML, strings.TrimPrefix(r.URL.Path, "/info/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	def := getTestDefinition()
+	def.InfoURL = ""
+	def.SearchURL = server.URL + "/search?q={{.Isin}}"
+	def.SearchLink = &Field{Selector: "ul.results a"}
+	def.Headers = map[string]string{"X-Isin": "{{.Isin}}"}
+
+	fn, err := NewQuoteGetterFactory(def)
+	require.NoError(t, err)
+	qg := fn("test", http.DefaultClient)
+
+	// ok
+	res, err := qg.GetQuote(context.Background(), testingscraper.TestIsin, "")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/info/"+testingscraper.TestIsin, res.URL)
+	assert.Equal(t, 1211.49, res.Price)
+	assert.Equal(t, "EUR", res.Currency)
+	assert.Equal(t, time.Date(2020, time.September, 11, 0, 0, 0, 0, time.Local), res.Date)
+
+	// ko: no search result
+	_, err = qg.GetQuote(context.Background(), "ISIN00000000", "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no result found")
+	}
+}
@@ -11,9 +11,12 @@ var (
 	ErrIsinMismatch           = errors.New("isin mismatch")
 	ErrEmptyInfoURL           = errors.New("parse search returned an empty info URL")
 	ErrInfoRequestIsNil       = errors.New("info request is nil")
+	ErrHistoryRequestIsNil    = errors.New("history request is nil")
 	ErrPriceNotFound          = errors.New("price not found")
 	ErrDateNotFound           = errors.New("date not found")
 	ErrPriceAndCurrencyString = errors.New("invalid price and currency string")
+	ErrInvalidPriceString     = errors.New("invalid price string")
+	ErrStaleQuote             = errors.New("stale quote")
 )
 
 // ErrorType is ...
@@ -34,6 +37,10 @@ const (
 	DateNotFoundError
 	InvalidDateError
 	IsinNotFoundError
+	GetHistoryError
+	ParseHistoryError
+	StaleQuoteError
+	InvalidCurrencyError
 )
 
 // Error  is ...
@@ -59,6 +66,21 @@ type Error struct {
 	err     error
 }
 
+// NewError returns a new *Error.
+// It is used by the QuoteGetters not based on a Scraper,
+// so that their errors are classified by the same ErrorType.
+// pir can be nil.
+func NewError(source, isin, url string, errType ErrorType, err error, pir *ParseInfoResult) *Error {
+	return &Error{
+		ParseInfoResult: pir,
+		errType:         errType,
+		source:          source,
+		isin:            isin,
+		url:             url,
+		err:             err,
+	}
+}
+
 // Source returns the Source of the error
 func (e *Error) Source() string { return e.source }
 
@@ -68,6 +90,9 @@ func (e *Error) Isin() string { return e.isin }
 // URL returns the URL of the error
 func (e *Error) URL() string { return e.url }
 
+// Type returns the ErrorType of the error
+func (e *Error) Type() ErrorType { return e.errType }
+
 // Unwrap returns the inner error
 func (e *Error) Unwrap() error { return e.err }
 
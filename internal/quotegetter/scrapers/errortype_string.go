@@ -20,11 +20,15 @@ func _() {
 	_ = x[DateNotFoundError-9]
 	_ = x[InvalidDateError-10]
 	_ = x[IsinNotFoundError-11]
+	_ = x[GetHistoryError-12]
+	_ = x[ParseHistoryError-13]
+	_ = x[StaleQuoteError-14]
+	_ = x[InvalidCurrencyError-15]
 }
 
-const _ErrorType_name = "SuccessNoResultFoundErrorIsinMismatchErrorGetSearchErrorParseSearchErrorGetInfoErrorParseInfoErrorPriceNotFoundErrorInvalidPriceErrorDateNotFoundErrorInvalidDateErrorIsinNotFoundError"
+const _ErrorType_name = "SuccessNoResultFoundErrorIsinMismatchErrorGetSearchErrorParseSearchErrorGetInfoErrorParseInfoErrorPriceNotFoundErrorInvalidPriceErrorDateNotFoundErrorInvalidDateErrorIsinNotFoundErrorGetHistoryErrorParseHistoryErrorStaleQuoteErrorInvalidCurrencyError"
 
-var _ErrorType_index = [...]uint8{0, 7, 25, 42, 56, 72, 84, 98, 116, 133, 150, 166, 183}
+var _ErrorType_index = [...]uint8{0, 7, 25, 42, 56, 72, 84, 98, 116, 133, 150, 166, 183, 198, 215, 230, 250}
 
 func (i ErrorType) String() string {
 	if i < 0 || i >= ErrorType(len(_ErrorType_index)-1) {
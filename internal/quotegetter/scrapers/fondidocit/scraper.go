@@ -118,6 +118,7 @@ func (s *scraper) ParseInfo(doc *goquery.Document, isin string) (*scrapers.Parse
 
 	r := new(scrapers.ParseInfoResult)
 	r.DateLayout = "02/01/2006"
+	r.PriceFormat = scrapers.NumberFormatComma
 
 	r.IsinStr = doc.Find("div.page-header small").Text()
 
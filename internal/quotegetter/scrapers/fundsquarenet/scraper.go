@@ -145,6 +145,7 @@ func (s *scraper) ParseInfo(doc *goquery.Document, isin string) (*scrapers.Parse
 
 	r := new(scrapers.ParseInfoResult)
 	r.DateLayout = "02/01/2006"
+	r.PriceFormat = scrapers.NumberFormatPoint
 	var txtPriceCurrency string
 
 	isLastNavAvailable := false
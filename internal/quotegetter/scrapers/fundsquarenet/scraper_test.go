@@ -64,6 +64,7 @@ func TestParseInfo(t *testing.T) {
 				CurrencyStr: "EUR",
 				DateStr:     "11/09/2020",
 				DateLayout:  "02/01/2006",
+				PriceFormat: scrapers.NumberFormatPoint,
 			},
 			wantErr: nil,
 		},
@@ -91,6 +91,7 @@ func (s *scraper) ParseInfo(doc *goquery.Document, isin string) (*scrapers.Parse
 
 	r := new(scrapers.ParseInfoResult)
 	r.DateLayout = scrapers.LayoutUnixTimestamp
+	r.PriceFormat = scrapers.NumberFormatPoint
 
 	// div := doc.Find("div[data-is-crypto]")
 	div := doc.Find("div[data-entity-type=\"3\"]")
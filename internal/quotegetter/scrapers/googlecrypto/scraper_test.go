@@ -82,6 +82,7 @@ func TestParseInfo(t *testing.T) {
 				PriceStr:    "40474.87415",
 				DateStr:     "1648292399",
 				DateLayout:  "unix",
+				PriceFormat: scrapers.NumberFormatPoint,
 			},
 			err: nil,
 		},
@@ -99,6 +100,7 @@ func TestParseInfo(t *testing.T) {
 				PriceStr:    "",
 				DateStr:     "1648292399",
 				DateLayout:  "unix",
+				PriceFormat: scrapers.NumberFormatPoint,
 			},
 		},
 		{
@@ -110,6 +112,7 @@ func TestParseInfo(t *testing.T) {
 				PriceStr:    "40474.87415",
 				DateStr:     "",
 				DateLayout:  "unix",
+				PriceFormat: scrapers.NumberFormatPoint,
 			},
 		},
 	}
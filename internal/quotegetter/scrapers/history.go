@@ -0,0 +1,117 @@
+package scrapers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+)
+
+// HistoryScraper interface. A Scraper implementing the HistoryScraper interface
+// can be used to satisfy the quotegetter.HistoryGetter interface also.
+//
+// The GetHistory function of the HistoryGetter first retrieves the last quote
+// of the isin, in order to resolve the url of the info page and the currency.
+// Then it calls the GetHistory and ParseHistory functions.
+type HistoryScraper interface {
+	Scraper
+	GetHistory(ctx context.Context, isin, url string, from, to time.Time) (*http.Request, error)
+	ParseHistory(body []byte, isin string) ([]*ParseInfoResult, error)
+}
+
+// historyGetter is a struct that implements the HistoryScraper interface
+type historyGetter struct {
+	quoteGetter
+	scr HistoryScraper
+}
+
+// GetHistory implements the method of the HistoryGetter interface
+func (hg *historyGetter) GetHistory(ctx context.Context, isin, url string, from, to time.Time) ([]*quotegetter.Result, error) {
+	return getHistory(ctx, isin, url, from, to, hg.scr)
+}
+
+func getHistory(ctx context.Context, isin, url string, from, to time.Time, scr HistoryScraper) ([]*quotegetter.Result, error) {
+
+	// aux function
+	theError := func(err error, typ ErrorType) ([]*quotegetter.Result, error) {
+		return nil, NewError(scr.Source(), isin, url, typ, err, nil)
+	}
+
+	// get the last quote to resolve the info url and the currency
+	last, err := getQuote(ctx, isin, url, scr)
+	if err != nil {
+		return nil, err
+	}
+	url = last.URL
+
+	// get the history
+	req, err := scr.GetHistory(ctx, isin, url, from, to)
+	if err == nil {
+		if req == nil {
+			return theError(ErrHistoryRequestIsNil, GetHistoryError)
+		}
+		var resp *http.Response
+		resp, err = quotegetter.DoHTTPRequest(scr.Client(), req)
+		if err == nil {
+			defer resp.Body.Close()
+		}
+		var body []byte
+		if err == nil {
+			body, err = ioutil.ReadAll(resp.Body)
+		}
+		if err == nil {
+			return getHistoryFromBody(body, isin, url, from, to, last.Currency, scr)
+		}
+	}
+	return theError(err, GetHistoryError)
+}
+
+// getHistoryFromBody parses the history response and returns the results
+// with date in the [from, to] days interval, sorted by date.
+// The currency param is used for the items without currency.
+func getHistoryFromBody(body []byte, isin, url string, from, to time.Time, currency string, scr HistoryScraper) ([]*quotegetter.Result, error) {
+
+	pirs, err := scr.ParseHistory(body, isin)
+	if err != nil {
+		errType := ParseHistoryError
+		if err == ErrNoResultFound {
+			errType = NoResultFoundError
+		}
+		return nil, NewError(scr.Source(), isin, url, errType, err, nil)
+	}
+
+	// the items of the last day can have a time
+	end := to.AddDate(0, 0, 1)
+
+	results := make([]*quotegetter.Result, 0, len(pirs))
+	for _, pir := range pirs {
+
+		// the isin is checked only if returned by the history
+		if pir.IsinStr == "" {
+			c := *pir
+			c.IsinStr = isin
+			pir = &c
+		}
+
+		r, err := NewResult(pir, scr.Source(), isin, url)
+		if err != nil {
+			return nil, err
+		}
+		if r.Date.Before(from) || !r.Date.Before(end) {
+			continue
+		}
+		if r.Currency == "" {
+			r.Currency = currency
+		}
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Date.Before(results[j].Date)
+	})
+
+	return results, nil
+}
@@ -0,0 +1,109 @@
+package scrapers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotetesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testHistoryScraper extends testScraper with the HistoryScraper methods.
+// The history server returns a "date price" line for each quote.
+type testHistoryScraper struct {
+	testScraper
+	historyURL string
+}
+
+func (scr testHistoryScraper) GetHistory(ctx context.Context, isin, url string, from, to time.Time) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, http.MethodGet, scr.historyURL+"?isin="+isin, nil)
+}
+
+func (scr testHistoryScraper) ParseHistory(body []byte, isin string) ([]*ParseInfoResult, error) {
+	var pirs []*ParseInfoResult
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		a := strings.Fields(line)
+		if len(a) != 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		layout := "2006-01-02"
+		if strings.Contains(a[0], "T") {
+			layout = "2006-01-02T15:04"
+		}
+		pirs = append(pirs, &ParseInfoResult{
+			DateStr:    a[0],
+			DateLayout: layout,
+			PriceStr:   a[1],
+		})
+	}
+	if len(pirs) == 0 {
+		return nil, ErrNoResultFound
+	}
+	return pirs, nil
+}
+
+func TestGetHistory(t *testing.T) {
+	server := quotetesting.NewTestServer()
+	defer server.Close()
+
+	history := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("isin") {
+		case "ISIN00000001":
+			fmt.Fprint(w, "2020-02-23 12.34\n2020-02-21 12.10\n2020-02-01 11.00\n2020-02-22 12.20")
+		case "ISIN00000002":
+			fmt.Fprint(w, "2020-02-23 N/A")
+		case "ISIN00000015":
+			fmt.Fprint(w, "2020-02-22T17:30 12.20\n2020-02-23T09:00 12.34")
+		}
+	}))
+	defer history.Close()
+
+	var scr Scraper = testHistoryScraper{testScraper{"localhost", server.URL}, history.URL}
+
+	qg := NewQuoteGetter(scr)
+	hg, ok := qg.(quotegetter.HistoryGetter)
+	require.True(t, ok, "NewQuoteGetter: HistoryGetter interface not implemented")
+
+	from := time.Date(2020, time.February, 20, 0, 0, 0, 0, time.Local)
+	to := time.Date(2020, time.February, 22, 0, 0, 0, 0, time.Local)
+
+	// ok: results are filtered and sorted by date
+	res, err := hg.GetHistory(context.Background(), "ISIN00000001", "", from, to)
+	require.NoError(t, err)
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, 12.10, res[0].Price)
+		assert.Equal(t, "12.10", res[0].PriceRaw)
+		assert.Equal(t, 12.20, res[1].Price)
+		assert.Equal(t, "EUR", res[0].Currency, "currency of the last quote")
+	}
+
+	// ok: the quotes of the last day with the time
+	res, err = hg.GetHistory(context.Background(), "ISIN00000015", "", from, to)
+	require.NoError(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, 12.20, res[0].Price)
+	}
+
+	// ko: invalid price
+	_, err = hg.GetHistory(context.Background(), "ISIN00000002", "", from, to)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "for isin")
+	}
+
+	// ko: last quote error
+	_, err = hg.GetHistory(context.Background(), "ISIN00000005", "", from, to)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no result found")
+	}
+
+	// the simple scraper does not implement the HistoryGetter interface
+	_, ok = NewQuoteGetter(newTestScraper("localhost", server.URL)).(quotegetter.HistoryGetter)
+	assert.False(t, ok, "NewQuoteGetter: unexpected HistoryGetter interface")
+}
@@ -31,9 +31,13 @@ type Scraper interface {
 }
 
 // ParseInfoResult is ...
+//
+// PriceFormat is the hint used to parse the PriceStr:
+// the zero value guesses the format from the string.
 type ParseInfoResult struct {
 	IsinStr     string
 	PriceStr    string
+	PriceFormat NumberFormat
 	CurrencyStr string
 	DateStr     string
 	DateLayout  string
@@ -44,8 +48,13 @@ type quoteGetter struct {
 	Scraper
 }
 
-// NewQuoteGetter trasforms a Scraper to a quotegetter.QuoteGetter interface
+// NewQuoteGetter trasforms a Scraper to a quotegetter.QuoteGetter interface.
+// If the Scraper implements the HistoryScraper interface too,
+// the returned object implements the quotegetter.HistoryGetter interface.
 func NewQuoteGetter(scr Scraper) quotegetter.QuoteGetter {
+	if hscr, ok := scr.(HistoryScraper); ok {
+		return &historyGetter{quoteGetter{hscr}, hscr}
+	}
 	return &quoteGetter{scr}
 }
 
@@ -89,13 +98,26 @@ func getInfoFromDoc(docInfo *goquery.Document, isin, url string, scr Scraper) (*
 		return theError(err, errType)
 	}
 
+	return NewResult(pir, scr.Source(), isin, url)
+}
+
+// NewResult checks the isin of the ParseInfoResult, parses its price and date
+// and returns the corrisponding quotegetter.Result.
+// In case of error, the returned error is an *Error.
+func NewResult(pir *ParseInfoResult, source, isin, url string) (*quotegetter.Result, error) {
+
+	// aux function
+	theError := func(err error, typ ErrorType) (*quotegetter.Result, error) {
+		return nil, NewError(source, isin, url, typ, err, pir)
+	}
+
 	//check ISIN
 	if isin != pir.IsinStr {
 		return theError(ErrIsinMismatch, IsinMismatchError)
 	}
 
 	// parse price
-	vPrice, err := parsePrice(pir.PriceStr)
+	vPrice, err := parsePrice(pir.PriceStr, pir.PriceFormat)
 	if err != nil {
 		return theError(err, InvalidPriceError)
 	}
@@ -106,11 +128,18 @@ func getInfoFromDoc(docInfo *goquery.Document, isin, url string, scr Scraper) (*
 		return theError(err, InvalidDateError)
 	}
 
+	// parse currency: the price in a minor unit (es. pence) is scaled
+	currency, divisor, err := quotegetter.ParseCurrency(pir.CurrencyStr)
+	if err != nil {
+		return theError(err, InvalidCurrencyError)
+	}
+
 	r := &quotegetter.Result{
 		URL:      url,
-		Price:    vPrice,
+		Price:    vPrice / divisor,
+		PriceRaw: pir.PriceStr,
 		Date:     vDate,
-		Currency: quotegetter.NormalizeCurrency(pir.CurrencyStr),
+		Currency: currency,
 	}
 	return r, nil
 }
@@ -140,53 +169,9 @@ func getQuote(ctx context.Context, isin, url string, scr Scraper) (*quotegetter.
 	}
 
 	if url == "" {
-		// get the search page
-		req, err = scr.GetSearch(ctx, isin)
-
-		// reqSearch can be nil if the Info URL can be build from isin only
-		if req != nil && err == nil {
-			resp, err = quotegetter.DoHTTPRequest(scr.Client(), req)
-		}
-		if err != nil {
-			return theError(err, GetSearchError)
-		}
-
-		// create goquery document only if respSearch != nil
-		if resp != nil {
-			defer resp.Body.Close()
-
-			// set url to SearchURL for error reporting pourposes.
-			// it will be overwritten in case of success finding InfoURL.
-			url = resp.Request.URL.String()
-
-			// docSearch, err = goquery.NewDocumentFromResponse(respSearch)
-			doc, err = goquery.NewDocumentFromReader(resp.Body)
-			// err != nil is handled below
-		}
-
-		if err == nil {
-			// NOTE: docSearch can be nil
-			//       if the url can be build from isin only
-			url, err = scr.ParseSearch(doc, isin)
-
-			if resp != nil && strings.HasPrefix(url, "/") {
-				// prepend scheme://host from respSearch.Request.URL
-				u, err := neturl.Parse(url)
-				if err != nil {
-					return theError(err, ParseSearchError)
-				}
-
-				url = resp.Request.URL.ResolveReference(u).String()
-			}
-
-		}
-
-		if url == "" {
-			err = ErrEmptyInfoURL
-		}
-
+		url, err = getInfoURL(ctx, isin, scr)
 		if err != nil {
-			return theError(err, ParseSearchError)
+			return nil, err
 		}
 	}
 
@@ -220,6 +205,79 @@ func getQuote(ctx context.Context, isin, url string, scr Scraper) (*quotegetter.
 
 }
 
+// getInfoURL executes the search step of the scraper:
+// it gets and parses the search page to find the url of the info page.
+func getInfoURL(ctx context.Context, isin string, scr Scraper) (string, error) {
+	var (
+		url  string
+		resp *http.Response
+		doc  *goquery.Document
+	)
+
+	// aux function
+	theError := func(err error, typ ErrorType) (string, error) {
+		e := &Error{
+			source:  scr.Source(),
+			isin:    isin,
+			url:     url,
+			err:     err,
+			errType: typ,
+		}
+		return "", e
+	}
+
+	// get the search page
+	req, err := scr.GetSearch(ctx, isin)
+
+	// reqSearch can be nil if the Info URL can be build from isin only
+	if req != nil && err == nil {
+		resp, err = quotegetter.DoHTTPRequest(scr.Client(), req)
+	}
+	if err != nil {
+		return theError(err, GetSearchError)
+	}
+
+	// create goquery document only if respSearch != nil
+	if resp != nil {
+		defer resp.Body.Close()
+
+		// set url to SearchURL for error reporting pourposes.
+		// it will be overwritten in case of success finding InfoURL.
+		url = resp.Request.URL.String()
+
+		// docSearch, err = goquery.NewDocumentFromResponse(respSearch)
+		doc, err = goquery.NewDocumentFromReader(resp.Body)
+		// err != nil is handled below
+	}
+
+	if err == nil {
+		// NOTE: docSearch can be nil
+		//       if the url can be build from isin only
+		url, err = scr.ParseSearch(doc, isin)
+
+		if resp != nil && strings.HasPrefix(url, "/") {
+			// prepend scheme://host from respSearch.Request.URL
+			u, err := neturl.Parse(url)
+			if err != nil {
+				return theError(err, ParseSearchError)
+			}
+
+			url = resp.Request.URL.ResolveReference(u).String()
+		}
+
+	}
+
+	// the error of ParseSearch takes precedence over the empty url
+	if err == nil && url == "" {
+		err = ErrEmptyInfoURL
+	}
+
+	if err != nil {
+		return theError(err, ParseSearchError)
+	}
+	return url, nil
+}
+
 // ============================================================================
 // aux functions
 
@@ -241,14 +299,6 @@ func parseDate(str, layout string) (time.Time, error) {
 	return time.ParseInLocation(layout, str, time.Local)
 }
 
-func parsePrice(str string) (float32, error) {
-	if str == "" {
-		return 0.0, ErrPriceNotFound
-	}
-	price, err := strconv.ParseFloat(strings.Replace(str, ",", ".", 1), 32)
-	return float32(price), err
-}
-
 // SplitPriceCurrency is ...
 func SplitPriceCurrency(txt string, priceFirst bool) (priceStr string, currencyStr string, err error) {
 	// split price and currency (11.49 EUR)
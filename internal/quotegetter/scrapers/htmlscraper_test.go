@@ -19,7 +19,7 @@ import (
 
 type testCaseGetQuote struct {
 	title    string
-	price    float32
+	price    float64
 	currency string
 	date     time.Time
 	// err      error
@@ -107,6 +107,17 @@ var testCasesGetQuote = map[string]*testCaseGetQuote{
 		// err:   context.DeadlineExceeded,
 		errstr: "context deadline exceeded",
 	},
+	"ISIN00000015": {
+		title:    "ok, full precision",
+		price:    12345.678,
+		currency: "EUR",
+		date:     time.Date(2020, time.February, 23, 0, 0, 0, 0, time.UTC),
+	},
+	"ISIN00000016": {
+		title: "ko, parse-search-error",
+		// err:   ErrNoResultFound, not hidden by ErrEmptyInfoURL
+		errstr: "no result found",
+	},
 }
 
 type testScraper struct {
@@ -159,6 +170,9 @@ func (scr testScraper) ParseSearch(doc *goquery.Document, isin string) (string,
 	if strings.Contains(tc.title, "no-info-url") {
 		return "", nil
 	}
+	if strings.Contains(tc.title, "parse-search-error") {
+		return "", ErrNoResultFound
+	}
 	if strings.Contains(tc.title, "info-invalid-url") {
 		s := `/
 newline`
@@ -402,3 +416,44 @@ func Test_parseDate(t *testing.T) {
 		})
 	}
 }
+
+func TestNewResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		priceStr    string
+		currencyStr string
+		price       float64
+		currency    string
+		errType     ErrorType
+	}{
+		{"iso code", "12.34", "EUR", 12.34, "EUR", Success},
+		{"name", "12.34", "Euro", 12.34, "EUR", Success},
+		{"symbol", "12.34", "$", 12.34, "USD", Success},
+		{"pence", "1234", "GBp", 12.34, "GBP", Success},
+		{"pence gbx", "1234", "GBX", 12.34, "GBP", Success},
+		{"no currency", "12.34", "", 12.34, "", Success},
+		{"unknown currency", "12.34", "XYZ", 0, "", InvalidCurrencyError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pir := &ParseInfoResult{
+				IsinStr:     "ISIN",
+				PriceStr:    tt.priceStr,
+				CurrencyStr: tt.currencyStr,
+				DateStr:     "23/02/2020",
+				DateLayout:  "02/01/2006",
+			}
+			res, err := NewResult(pir, "source", "ISIN", "url")
+			if tt.errType != Success {
+				var serr *Error
+				require.ErrorAs(t, err, &serr)
+				assert.Equal(t, tt.errType, serr.Type())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.currency, res.Currency)
+			assert.InDelta(t, tt.price, res.Price, 1e-9)
+		})
+	}
+}
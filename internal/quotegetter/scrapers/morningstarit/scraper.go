@@ -2,8 +2,12 @@ package morningstarit
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/mmbros/quotes/internal/quotegetter"
@@ -114,6 +118,7 @@ func (s *scraper) ParseInfo(doc *goquery.Document, isin string) (*scrapers.Parse
 
 	r := new(scrapers.ParseInfoResult)
 	r.DateLayout = "02/01/2006"
+	r.PriceFormat = scrapers.NumberFormatComma
 	var txtPriceCurrency string
 
 	doc.Find("table.overviewKeyStatsTable td").EachWithBreak(func(i int, s *goquery.Selection) bool {
@@ -135,3 +140,60 @@ func (s *scraper) ParseInfo(doc *goquery.Document, isin string) (*scrapers.Parse
 
 	return r, errPrice
 }
+
+// GetHistory creates the http.Request to get the price history of the `isin`
+// in the [from, to] interval.
+// `url` is the url of the info page that contains the morningstar id of the fund.
+// The response will be parsed by ParseHistory to extract the quotes.
+func (s *scraper) GetHistory(ctx context.Context, isin, url string, from, to time.Time) (*http.Request, error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+	id := u.Query().Get("id")
+	if id == "" {
+		return nil, fmt.Errorf("morningstar id not found in url %q", url)
+	}
+
+	const layout = "2006-01-02"
+	url = fmt.Sprintf("https://tools.morningstar.it/api/rest.svc/timeseries_price/jbyiq3rhyf"+
+		"?idtype=Morningstar&frequency=daily&outputType=COMPACTJSON&startDate=%s&endDate=%s&id=%s]2]0]FOITA$$ALL",
+		from.Format(layout), to.Format(layout), id)
+	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+}
+
+// ParseHistory parses the price history returned by the timeseries api.
+func (s *scraper) ParseHistory(body []byte, isin string) ([]*scrapers.ParseInfoResult, error) {
+	/*
+		[[1598572800000,126.37],[1598832000000,126.07],[1598918400000,126.65]]
+
+		each item is the pair (unix timestamp in milliseconds, price)
+	*/
+
+	var items [][]json.Number
+
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, scrapers.ErrNoResultFound
+	}
+
+	pirs := make([]*scrapers.ParseInfoResult, 0, len(items))
+	for _, item := range items {
+		if len(item) != 2 {
+			return nil, fmt.Errorf("invalid history item %v", item)
+		}
+		msec, err := strconv.ParseInt(item[0].String(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		pirs = append(pirs, &scrapers.ParseInfoResult{
+			DateStr:     time.Unix(msec/1000, 0).UTC().Format("2006-01-02"),
+			DateLayout:  "2006-01-02",
+			PriceStr:    item[1].String(),
+			PriceFormat: scrapers.NumberFormatPoint,
+		})
+	}
+	return pirs, nil
+}
@@ -1,9 +1,11 @@
 package morningstarit
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
@@ -150,3 +152,85 @@ func TestParseInfo(t *testing.T) {
 		}
 	}
 }
+
+func TestGetHistory(t *testing.T) {
+	scr := &scraper{"morningstarit", nil}
+
+	from := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	req, err := scr.GetHistory(context.Background(), testingscraper.TestIsin,
+		"https://www.morningstar.it/it/funds/snapshot/snapshot.aspx?id=F000005GUM", from, to)
+	if err != nil {
+		t.Fatalf("GetHistory: unexpected error %q", err)
+	}
+	q := req.URL.Query()
+	if got := q.Get("id"); !strings.HasPrefix(got, "F000005GUM]") {
+		t.Errorf("GetHistory: id: found %q", got)
+	}
+	if got := q.Get("startDate"); got != "2020-08-01" {
+		t.Errorf("GetHistory: startDate: expected %q, found %q", "2020-08-01", got)
+	}
+	if got := q.Get("endDate"); got != "2020-08-31" {
+		t.Errorf("GetHistory: endDate: expected %q, found %q", "2020-08-31", got)
+	}
+
+	_, err = scr.GetHistory(context.Background(), testingscraper.TestIsin, testingscraper.TestInfoURL, from, to)
+	if err == nil {
+		t.Errorf("GetHistory: expected error for url without id, found <nil>")
+	}
+}
+
+func TestParseHistory(t *testing.T) {
+	scr := &scraper{"morningstarit", nil}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    []string // date price
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			body: `[[1598572800000,126.37],[1598832000000,126.07]]`,
+			want: []string{"2020-08-28 126.37", "2020-08-31 126.07"},
+		},
+		{
+			name:    "empty",
+			body:    `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid item",
+			body:    `[[1598572800000]]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			body:    `<html></html>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pirs, err := scr.ParseHistory([]byte(tt.body), testingscraper.TestIsin)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseHistory: expected error, found <nil>")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHistory: unexpected error %q", err)
+			}
+			got := make([]string, 0, len(pirs))
+			for _, pir := range pirs {
+				got = append(got, pir.DateStr+" "+pir.PriceStr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseHistory: expected %q, found %q", tt.want, got)
+			}
+		})
+	}
+}
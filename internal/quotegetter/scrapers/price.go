@@ -0,0 +1,213 @@
+package scrapers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NumberFormat is the format of the numbers of the scraped pages.
+// It is used as a hint to parse the price string.
+type NumberFormat int
+
+// NumberFormat enum
+const (
+	// NumberFormatAuto guesses the decimal separator from the string:
+	// if both "." and "," are present, the last one is the decimal separator;
+	// if only one of them is present once, it is the decimal separator.
+	NumberFormatAuto NumberFormat = iota
+
+	// NumberFormatPoint has "." as decimal separator and "," as thousands separator (1,234.56).
+	NumberFormatPoint
+
+	// NumberFormatComma has "," as decimal separator and "." as thousands separator (1.234,56).
+	NumberFormatComma
+)
+
+// isThousandsSpace returns true if r is a char used as thousands separator
+// regardless of the number format (spaces and apostrophes).
+func isThousandsSpace(r rune) bool {
+	switch r {
+	case ' ', '\u00a0', '\u2009', '\u202f', '\'', '\u2019':
+		return true
+	}
+	return false
+}
+
+// isSignSpace returns true if r is a char allowed between
+// the sign and the number (spaces and currency symbols).
+func isSignSpace(r rune) bool {
+	return unicode.IsSpace(r) || isThousandsSpace(r) || unicode.Is(unicode.Sc, r)
+}
+
+// extractNumber returns the number contained in the string,
+// without the surrounding text (currency symbols, percent sign, ...).
+// The returned number keeps decimal and thousands separators,
+// while spaces and apostrophes are removed.
+// It returns an error if the string does not contain exactly one number.
+func extractNumber(str string) (num string, negative bool, err error) {
+	runes := []rune(str)
+
+	// find the first digit
+	start := -1
+	for j, r := range runes {
+		if unicode.IsDigit(r) {
+			start = j
+			break
+		}
+	}
+	if start < 0 {
+		return "", false, fmt.Errorf("%w: %q", ErrInvalidPriceString, str)
+	}
+
+	// a leading decimal separator (i.e. ".5")
+	if start > 0 && (runes[start-1] == '.' || runes[start-1] == ',') {
+		start--
+	}
+
+	// find the end of the number
+	end := start
+	for end < len(runes) {
+		r := runes[end]
+		if unicode.IsDigit(r) || r == '.' || r == ',' {
+			end++
+			continue
+		}
+		// a space is part of the number only if followed by a digit
+		if isThousandsSpace(r) && end+1 < len(runes) && unicode.IsDigit(runes[end+1]) {
+			end++
+			continue
+		}
+		break
+	}
+
+	prefix := string(runes[:start])
+	suffix := string(runes[end:])
+
+	// the text around the number must not contain other digits
+	if strings.IndexFunc(suffix, unicode.IsDigit) >= 0 {
+		return "", false, fmt.Errorf("%w: %q", ErrInvalidPriceString, str)
+	}
+
+	// sign: a minus or the parentheses next to the number,
+	// but for spaces and currency symbols (es. "-€ 12", "(12.50 €)")
+	before := strings.TrimRightFunc(prefix, isSignSpace)
+	after := strings.TrimLeftFunc(suffix, isSignSpace)
+	if r, size := utf8.DecodeLastRuneInString(before); r == '-' || r == '\u2212' {
+		// a dash followed by a space is a separator of a label
+		// (es. "Close - 11,40"), unless it is the first char
+		label := strings.TrimSpace(before[:len(before)-size])
+		spaced := strings.IndexFunc(prefix[len(before):], unicode.IsSpace) == 0
+		negative = label == "" || !spaced
+	} else {
+		negative = r == '(' && strings.HasPrefix(after, ")")
+	}
+
+	var sb strings.Builder
+	for _, r := range runes[start:end] {
+		if !isThousandsSpace(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return strings.TrimRight(sb.String(), ".,"), negative, nil
+}
+
+// checkThousands checks the integer part of the number
+// is correctly grouped by the thousands separator.
+func checkThousands(intPart string, sep byte) bool {
+	groups := strings.Split(intPart, string(sep))
+	if len(groups) == 1 {
+		return true
+	}
+	if len(groups[0]) < 1 || len(groups[0]) > 3 {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePrice parses the price string according to the number format.
+// It handles thousands separators, spaces, currency symbols or codes
+// embedded in the string and negative values.
+func parsePrice(str string, format NumberFormat) (float64, error) {
+	if strings.TrimSpace(str) == "" {
+		return 0.0, ErrPriceNotFound
+	}
+
+	num, negative, err := extractNumber(str)
+	if err != nil {
+		return 0.0, err
+	}
+
+	// determine the decimal separator
+	var dec byte
+	switch format {
+	case NumberFormatPoint:
+		dec = '.'
+	case NumberFormatComma:
+		dec = ','
+	default:
+		iPoint := strings.LastIndexByte(num, '.')
+		iComma := strings.LastIndexByte(num, ',')
+		switch {
+		case iPoint >= 0 && iComma >= 0:
+			dec = '.'
+			if iComma > iPoint {
+				dec = ','
+			}
+		case iPoint >= 0 && strings.Count(num, ".") == 1:
+			dec = '.'
+		case iComma >= 0 && strings.Count(num, ",") == 1:
+			dec = ','
+		case iPoint >= 0:
+			dec = ','
+		default:
+			dec = '.'
+		}
+	}
+	thousands := byte(',')
+	if dec == ',' {
+		thousands = '.'
+	}
+
+	// split integer and fractional part
+	intPart, fracPart := num, ""
+	if strings.Count(num, string(dec)) > 1 {
+		return 0.0, fmt.Errorf("%w: %q", ErrInvalidPriceString, str)
+	}
+	if i := strings.IndexByte(num, dec); i >= 0 {
+		intPart, fracPart = num[:i], num[i+1:]
+	}
+	if strings.IndexByte(fracPart, thousands) >= 0 || !checkThousands(intPart, thousands) {
+		return 0.0, fmt.Errorf("%w: %q", ErrInvalidPriceString, str)
+	}
+
+	s := strings.ReplaceAll(intPart, string(thousands), "")
+	if fracPart != "" {
+		s += "." + fracPart
+	}
+	if negative {
+		s = "-" + s
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// ParseNumberFormat returns the NumberFormat corrisponding to the string:
+// "" or "auto", "point" or "dot", "comma".
+func ParseNumberFormat(s string) (NumberFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return NumberFormatAuto, nil
+	case "point", "dot":
+		return NumberFormatPoint, nil
+	case "comma":
+		return NumberFormatComma, nil
+	}
+	return NumberFormatAuto, fmt.Errorf("invalid number format %q", s)
+}
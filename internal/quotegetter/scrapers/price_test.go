@@ -0,0 +1,111 @@
+package scrapers
+
+import (
+	"testing"
+
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers/testingscraper"
+)
+
+func Test_parsePrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		str    string
+		format NumberFormat
+		want   float64
+		err    error
+	}{
+		// real page snippets
+		{"fondidocit", "11,400", NumberFormatComma, 11.4, nil},
+		{"morningstarit nav", "EUR 126,370", NumberFormatComma, 126.37, nil},
+		{"morningstarit fund size", "EUR 1.217,32", NumberFormatComma, 1217.32, nil},
+		{"morningstarit change", "-0,18%", NumberFormatComma, -0.18, nil},
+		{"fundsquarenet", "11.49 EUR", NumberFormatPoint, 11.49, nil},
+		{"fundsquarenet thousands", "12,345.678 USD", NumberFormatPoint, 12345.678, nil},
+		{"googlecrypto", "40401.127700000005", NumberFormatPoint, 40401.127700000005, nil},
+
+		// thousands separators
+		{"comma thousands", "1.234.567,89", NumberFormatComma, 1234567.89, nil},
+		{"point thousands", "1,234,567.89", NumberFormatPoint, 1234567.89, nil},
+		{"space thousands", "1 234 567,89 €", NumberFormatComma, 1234567.89, nil},
+		{"nbsp thousands", "1 234,5", NumberFormatComma, 1234.5, nil},
+		{"narrow nbsp thousands", "1 234,5", NumberFormatComma, 1234.5, nil},
+		{"apostrophe thousands", "CHF 1'234.50", NumberFormatPoint, 1234.5, nil},
+
+		// currency symbols
+		{"euro symbol before", "€ 1.234,56", NumberFormatComma, 1234.56, nil},
+		{"dollar symbol attached", "$1,234.56", NumberFormatPoint, 1234.56, nil},
+		{"pound symbol after", "78.12£", NumberFormatPoint, 78.12, nil},
+
+		// negative values
+		{"minus", "-12.5", NumberFormatPoint, -12.5, nil},
+		{"unicode minus", "−12,5", NumberFormatComma, -12.5, nil},
+		{"minus after currency", "EUR -1.234,5", NumberFormatComma, -1234.5, nil},
+		{"parentheses", "(12.50)", NumberFormatPoint, -12.5, nil},
+		{"plus", "+0,24%", NumberFormatComma, 0.24, nil},
+		{"minus before currency symbol", "-€ 12,5", NumberFormatComma, -12.5, nil},
+		{"minus spaced", "- 12.5", NumberFormatPoint, -12.5, nil},
+		{"parentheses with currency symbol", "(12.50 €)", NumberFormatPoint, -12.5, nil},
+
+		// labels
+		{"label with dash", "Close - 11,40 EUR", NumberFormatComma, 11.4, nil},
+		{"label with parentheses", "Price (EUR) 11,40", NumberFormatComma, 11.4, nil},
+		{"label with parentheses after", "Price 11,40 (EUR)", NumberFormatComma, 11.4, nil},
+		{"label with minus", "Change: -0,18%", NumberFormatComma, -0.18, nil},
+
+		// auto format
+		{"auto comma decimal", "126,370", NumberFormatAuto, 126.37, nil},
+		{"auto point decimal", "11.49", NumberFormatAuto, 11.49, nil},
+		{"auto italian", "1.234,56", NumberFormatAuto, 1234.56, nil},
+		{"auto english", "1,234.56", NumberFormatAuto, 1234.56, nil},
+		{"auto point thousands only", "1.234.567", NumberFormatAuto, 1234567, nil},
+		{"auto comma thousands only", "1,234,567", NumberFormatAuto, 1234567, nil},
+		{"auto integer", "100", NumberFormatAuto, 100, nil},
+		{"auto leading point", ".5", NumberFormatAuto, 0.5, nil},
+
+		// errors
+		{"empty", "", NumberFormatAuto, 0, ErrPriceNotFound},
+		{"spaces", "   ", NumberFormatAuto, 0, ErrPriceNotFound},
+		{"not available", "N/A", NumberFormatAuto, 0, ErrInvalidPriceString},
+		{"two numbers", "11.49 EUR 12", NumberFormatPoint, 0, ErrInvalidPriceString},
+		{"two decimal separators", "1,234,56", NumberFormatComma, 0, ErrInvalidPriceString},
+		{"wrong locale", "1.234,56", NumberFormatPoint, 0, ErrInvalidPriceString},
+		{"bad grouping", "12.34,5", NumberFormatComma, 0, ErrInvalidPriceString},
+		{"bad grouping first group", "1234.567,5", NumberFormatComma, 0, ErrInvalidPriceString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePrice(tt.str, tt.format)
+			if testingscraper.CheckError(t, "parsePrice", err, tt.err) {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parsePrice(%q): expected %v, found %v", tt.str, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseNumberFormat(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    NumberFormat
+		wantErr bool
+	}{
+		{"", NumberFormatAuto, false},
+		{"auto", NumberFormatAuto, false},
+		{"point", NumberFormatPoint, false},
+		{"Dot", NumberFormatPoint, false},
+		{"COMMA", NumberFormatComma, false},
+		{"space", NumberFormatAuto, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNumberFormat(tt.str)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNumberFormat(%q): unexpected error %v", tt.str, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseNumberFormat(%q): expected %v, found %v", tt.str, tt.want, got)
+		}
+	}
+}
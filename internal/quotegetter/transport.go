@@ -0,0 +1,110 @@
+package quotegetter
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// transport is the http.RoundTripper of the clients created by ClientConfig.
+//
+// Each attempt of a request:
+//   - waits for the limiters;
+//   - is executed with the timeout, that starts after the limiters wait;
+//   - is counted by the attempts counter of the context, if any.
+//
+// The failed attempts are retried according to the retry policy.
+type transport struct {
+	base     http.RoundTripper
+	retry    *RetryPolicy
+	limiters []*Limiter
+	timeout  time.Duration
+}
+
+// cancelBody cancels the context of the attempt when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// attempt executes a single attempt of the request.
+func (t *transport) attempt(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for _, l := range t.limiters {
+		if err := l.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	incAttempts(ctx)
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	actx, cancel := context.WithTimeout(ctx, t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(actx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{resp.Body, cancel}
+	return resp, nil
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	maxAttempts := t.retry.maxAttempts()
+
+	// a request with a body can be retried only if the body can be rewound
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		// the request must not be modified: the retries use a clone
+		// of the request, with a new body
+		areq := req
+		if attempt > 1 {
+			areq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				areq.Body = body
+			}
+		}
+
+		resp, err := t.attempt(areq)
+
+		retry := attempt < maxAttempts && ctx.Err() == nil &&
+			(err != nil || t.retry.isRetryableStatus(resp.StatusCode))
+		if !retry {
+			return resp, err
+		}
+
+		d := t.retry.delay(attempt, resp)
+		if resp != nil {
+			// drain the body to reuse the connection
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
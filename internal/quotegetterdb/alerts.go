@@ -0,0 +1,83 @@
+package quotegetterdb
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AlertRecord is a triggered alert rule of an isin, already notified.
+type AlertRecord struct {
+	Isin     string
+	Rule     string
+	Notified time.Time
+}
+
+// migrateCreateTableAlerts creates the alerts table of the notified alert rules.
+func migrateCreateTableAlerts(tx *sql.Tx, d dialect) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS alerts(
+isin TEXT NOT NULL,
+rule TEXT NOT NULL,
+notified ` + d.timestampType() + ` NOT NULL,
+PRIMARY KEY (isin, rule)
+);
+`)
+	return err
+}
+
+// SelectAlerts selects the notified alert rules of the isins.
+func (qdb *QuoteDatabase) SelectAlerts(isins ...string) ([]*AlertRecord, error) {
+	query := "SELECT isin, rule, notified FROM alerts"
+	var args []any
+	if len(isins) > 0 {
+		var cond string
+		cond, args = sqlIn("isin", isins)
+		query += " WHERE " + cond
+	}
+	rows, err := qdb.db.Query(qdb.dialect.rebind(query+" ORDER BY isin, rule"), args...)
+	if err != nil {
+		return nil, newError("select alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*AlertRecord
+	for rows.Next() {
+		r := &AlertRecord{}
+		if err = rows.Scan(&r.Isin, &r.Rule, &r.Notified); err != nil {
+			return nil, newError("select alerts: %w", err)
+		}
+		result = append(result, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, newError("select alerts: %w", err)
+	}
+	return result, nil
+}
+
+// SetAlerts replaces the notified alert rules of the isins with the records,
+// in a single transaction. The records of the other isins are not changed.
+func (qdb *QuoteDatabase) SetAlerts(isins []string, records []*AlertRecord) error {
+	tx, err := qdb.db.Begin()
+	if err != nil {
+		return newError("set alerts: %w", err)
+	}
+	if len(isins) > 0 {
+		cond, args := sqlIn("isin", isins)
+		_, err = tx.Exec(qdb.dialect.rebind("DELETE FROM alerts WHERE "+cond), args...)
+	}
+	for _, r := range records {
+		if err != nil {
+			break
+		}
+		_, err = tx.Exec(qdb.dialect.rebind("INSERT INTO alerts(isin, rule, notified) VALUES (?, ?, ?)"),
+			r.Isin, r.Rule, r.Notified)
+	}
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		return newError("set alerts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package quotegetterdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlerts(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	t0 := time.Date(2026, time.October, 16, 18, 30, 0, 0, time.UTC)
+	err := qdb.SetAlerts([]string{"isin1", "isin2"}, []*AlertRecord{
+		{Isin: "isin1", Rule: "above", Notified: t0},
+		{Isin: "isin1", Rule: "change", Notified: t0},
+		{Isin: "isin2", Rule: "missing", Notified: t0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the records of isin1 are replaced
+	t1 := t0.Add(time.Hour)
+	err = qdb.SetAlerts([]string{"isin1"}, []*AlertRecord{
+		{Isin: "isin1", Rule: "below", Notified: t1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := qdb.SelectAlerts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, found %d", len(records))
+	}
+	if r := records[0]; r.Isin != "isin1" || r.Rule != "below" || !r.Notified.Equal(t1) {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r := records[1]; r.Isin != "isin2" || r.Rule != "missing" {
+		t.Errorf("unexpected record %+v", r)
+	}
+
+	records, err = qdb.SelectAlerts("isin2", "isin3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Isin != "isin2" {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
@@ -5,16 +5,21 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Import go-sqlite3 library
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
 	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/taskengine"
 )
 
 // QuoteDatabase handles the database that store and retrieve quote informations.
 type QuoteDatabase struct {
-	dns string
-	db  *sql.DB
+	dns     string
+	db      *sql.DB
+	dialect dialect
 }
 
 // QuoteRecord is the record stored in the quote database.
@@ -24,10 +29,19 @@ type QuoteRecord struct {
 	Source    string
 	Timestamp time.Time
 	Date      time.Time
-	Price     float32
+	Price     float64
+	PriceRaw  string
 	Currency  string
 	URL       string
 	ErrMsg    string
+	RunID     int64
+	Instance  int
+	Status    string
+	TimeStart time.Time
+	TimeEnd   time.Time
+	ErrType   string
+	Consensus string
+	Best      bool
 }
 
 // func (qr *QuoteRecord) String() string {
@@ -86,12 +100,20 @@ func ToNullString(s string) sql.NullString {
 }
 
 // ToNullTime invalidates a sql.NullTime if IsZero, validates otherwise
-// func ToNullTime(t time.Time) sql.NullTime {
-// 	return sql.NullTime{
-// 		Time:  t,
-// 		Valid: !t.IsZero(),
-// 	}
-// }
+func ToNullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{
+		Time:  t,
+		Valid: !t.IsZero(),
+	}
+}
+
+// ToNullInt64 invalidates a sql.NullInt64 if 0, validates otherwise
+func ToNullInt64(n int64) sql.NullInt64 {
+	return sql.NullInt64{
+		Int64: n,
+		Valid: n != 0,
+	}
+}
 
 // ToNullFloat64 invalidates a sql.NullFloat64 if 0, validates otherwise
 func ToNullFloat64(f float64) sql.NullFloat64 {
@@ -119,8 +141,54 @@ func extractDir(dns string) string {
 }
 */
 
-// Open the quote database. Try to create the database if not exists.
+// ErrPendingMigrations is returned by Open and Create
+// if the schema of the database must be migrated.
+var ErrPendingMigrations = errors.New(`pending schema migrations: run the "db migrate" command`)
+
+// Open opens the quote database, without modifying it.
+// It returns an error wrapping ErrPendingMigrations if the schema of the
+// database is not the latest one (see Migrate), es. if the database is empty.
 func Open(dns string) (*QuoteDatabase, error) {
+	return open(dns, false)
+}
+
+// Create opens the quote database, as Open, but the schema of an empty
+// database is created. The existing databases are never migrated.
+// It is used by the commands that save the quotes.
+func Create(dns string) (*QuoteDatabase, error) {
+	return open(dns, true)
+}
+
+func open(dns string, create bool) (*QuoteDatabase, error) {
+	qdb, err := OpenNoMigrate(dns)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := qdb.PendingMigrations()
+	if err == nil && len(pending) > 0 {
+		empty := false
+		if create {
+			empty, err = qdb.isEmpty()
+		}
+		if err == nil {
+			if empty {
+				_, _, err = qdb.Migrate(false)
+			} else {
+				err = newError("quotes database %q schema version %d: %w",
+					Redacted(dns), pending[0].Version-1, ErrPendingMigrations)
+			}
+		}
+	}
+	if err != nil {
+		qdb.Close()
+		return nil, err
+	}
+	return qdb, nil
+}
+
+// OpenNoMigrate opens the quote database without applying
+// the pending schema migrations.
+func OpenNoMigrate(dns string) (*QuoteDatabase, error) {
 	/*
 		folder := extractDir(dns)
 		if folder != "" {
@@ -129,23 +197,16 @@ func Open(dns string) (*QuoteDatabase, error) {
 			}
 		}
 	*/
-	db, err := sql.Open("sqlite3", dns)
+	d := newDialect(dns)
+	db, err := sql.Open(d.driver(), dns)
 	if err == nil {
 		err = db.Ping()
 	}
 	if err != nil {
-		return nil, newError("open quotes database %q: %w", dns, err)
+		return nil, newError("open quotes database %q: %w", Redacted(dns), err)
 	}
 
-	qdb := &QuoteDatabase{dns, db}
-
-	err = qdb.initDatabase()
-	if err != nil {
-		qdb.Close()
-		return nil, err
-	}
-
-	return qdb, nil
+	return &QuoteDatabase{dns, db, d}, nil
 }
 
 // Close the quote database
@@ -156,74 +217,6 @@ func (qdb *QuoteDatabase) Close() error {
 	return qdb.db.Close()
 }
 
-func (qdb *QuoteDatabase) initDatabase() error {
-	if e := qdb.createTableQuotes(); e != nil {
-		return e
-	}
-	// if e := qdb.createViewQuotes(); e != nil {
-	// 	return e
-	// }
-	return nil
-}
-
-func (qdb *QuoteDatabase) createTableQuotes() error {
-	/*
-		crea un unique index sui campi (isin, source, datestamp, date)
-		- datestamp e' il timestamp con la sola data, senza orario
-		- date e' reso not null per evitare di far fallire il controllo di unique
-
-		in caso di insert con gli stessi valori di (isin, source, datestamp, date)
-		il nuovo record sostituisce il vecchio mediante la clausola
-		   INSERT OR REPLACE INTO quotes
-
-		In questo modo, a parita' di isin, source e datastamp,
-		sara' presente un solo record per ogni data
-		Ad esempio sara' possibile avere:
-		  ISIN          SOURCE     DATASTAMP   DATE
-		  isin00001234  source.it  2020-10-01  2020-09-30
-		  isin00001234  source.it  2020-10-01  2020-09-29
-		  isin00001234  source.it  2020-10-01  0001-01-01  (zero date)
-		ma non
-		  ISIN          SOURCE     DATASTAMP   DATE
-		  isin00001234  source.it  2020-10-01  2020-09-30
-		  isin00001234  source.it  2020-10-01  2020-09-30
-		e non
-		  ISIN          SOURCE     DATASTAMP   DATE
-		  isin00001234  source.it  2020-10-01  0001-01-01  (zero date)
-		  isin00001234  source.it  2020-10-01  0001-01-01  (zero date)
-	*/
-
-	// create table if not exists
-	sql := `CREATE TABLE IF NOT EXISTS quotes(
-id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
-isin TEXT NOT NULL,
-source TEXT NOT NULL,
-datestamp DATETIME NOT NULL,
-timestamp DATETIME NOT NULL,
-date DATE NOT NULL,
-price DOUBLE,
-currency TEXT,
-url TEXT,
-errmsg TEXT
-);
-`
-
-	_, err := qdb.db.Exec(sql)
-	if err != nil {
-		return newError("create table 'quotes': %w", err)
-	}
-
-	// create index if not exists
-	sql = `CREATE UNIQUE INDEX IF NOT EXISTS idx_quotes_isin_source_dates 
-ON quotes (isin, source, datestamp, date);`
-	_, err = qdb.db.Exec(sql)
-	if err != nil {
-		return newError("create index 'idx_quotes_isin_source_dates': %w", err)
-	}
-
-	return nil
-}
-
 // func (qdb *QuoteDatabase) createViewQuotes() error {
 
 // 	// create table if not exists
@@ -243,21 +236,26 @@ ON quotes (isin, source, datestamp, date);`
 // 	return nil
 // }
 
-// InsertQuotes insert the quotes in the quotes database.
+// InsertQuotes insert the quotes in the quotes database, in a single transaction.
 func (qdb *QuoteDatabase) InsertQuotesRecords(items ...*QuoteRecord) error {
-	sql := `INSERT OR REPLACE INTO quotes(
-datestamp,
-timestamp,
-isin,
-source,
-date,
-price,
-currency,
-url,
-errmsg
-) values(?, ?, ?, ?, ?, ?, ?, ?, ?)
-`
-	stmt, err := qdb.db.Prepare(sql)
+	tx, err := qdb.db.Begin()
+	if err != nil {
+		return newError("insert quote: %w", err)
+	}
+	if err = qdb.insertQuotesRecords(tx, items); err == nil {
+		err = tx.Commit()
+		if err != nil {
+			err = newError("insert quote: commit: %w", err)
+		}
+	} else {
+		tx.Rollback()
+	}
+	return err
+}
+
+// insertQuotesRecords inserts the quotes in the transaction.
+func (qdb *QuoteDatabase) insertQuotesRecords(tx *sql.Tx, items []*QuoteRecord) error {
+	stmt, err := tx.Prepare(qdb.dialect.insertQuote())
 	if err != nil {
 		return newError("insert quote: prepare: %w", err)
 	}
@@ -276,10 +274,19 @@ errmsg
 
 		_, err = stmt.Exec(datestamp, timestamp, i.Isin, i.Source,
 			i.Date, // ToNullTime(i.Date),
-			ToNullFloat64(float64(i.Price)),
+			ToNullFloat64(i.Price),
+			ToNullString(i.PriceRaw),
 			ToNullString(i.Currency),
 			ToNullString(i.URL),
-			ToNullString(i.ErrMsg))
+			ToNullString(i.ErrMsg),
+			ToNullInt64(i.RunID),
+			ToNullInt64(int64(i.Instance)),
+			ToNullString(i.Status),
+			ToNullTime(i.TimeStart),
+			ToNullTime(i.TimeEnd),
+			ToNullString(i.ErrType),
+			ToNullString(i.Consensus),
+			i.Best)
 		if err != nil {
 			return newError("insert quote: execute: %w", err)
 		}
@@ -318,67 +325,371 @@ ORDER BY isin, source, date desc
 }
 */
 
-/*
+// SelectLastQuotes selects the last successful quote of each isin:
+// the one with the most recent date, preferring the best quote of the consensus,
+// then the most recent timestamp. If isins is empty, all the isins are selected.
+// The quotes are ordered by isin.
+func (qdb *QuoteDatabase) SelectLastQuotes(isins ...string) ([]*QuoteRecord, error) {
+	records, err := qdb.SelectQuotes(&QuoteFilter{
+		Isins:  isins,
+		Status: SuccessStatus,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return LastQuotes(records), nil
+}
 
-// SelectLastQuotes is ...
-func (qdb *QuoteDatabase) SelectLastQuotes() ([]*QuoteRecord, error) {
-
-	sqlSelect := `select q.id, q.timestamp, q.isin, q.source,
-q.date, q.price, q.currency, q.url, q.errmsg
-from quotes q
-where q.id in
-(
-select id
-from quotes
-where isin = q.isin
-and source = q.source
-order by timestamp DESC
-limit 2
+// LastQuotes returns the last quote of each isin of the records,
+// as defined by SelectLastQuotes. The quotes are ordered by isin.
+func LastQuotes(records []*QuoteRecord) []*QuoteRecord {
+	last := map[string]*QuoteRecord{}
+	for _, r := range records {
+		prev, ok := last[r.Isin]
+		if ok && !r.newerThan(prev) {
+			continue
+		}
+		last[r.Isin] = r
+	}
+
+	result := make([]*QuoteRecord, 0, len(last))
+	for _, r := range last {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Isin < result[j].Isin })
+	return result
+}
+
+// newerThan reports whether the quote is more recent than the other one,
+// as defined by SelectLastQuotes.
+func (r *QuoteRecord) newerThan(other *QuoteRecord) bool {
+	if da, db := r.day(), other.day(); da != db {
+		return da > db
+	}
+	if r.Best != other.Best {
+		return r.Best
+	}
+	return r.Timestamp.After(other.Timestamp)
+}
+
+// SelectLastSuccessQuotes selects the last successful quote
+// of each isin and source, ordered by isin and source.
+func (qdb *QuoteDatabase) SelectLastSuccessQuotes() ([]*QuoteRecord, error) {
+
+	sqlSelect := `SELECT q.id, q.timestamp, q.isin, q.source,
+q.date, q.price, q.price_raw, q.currency, q.url, q.errmsg,
+q.run_id, q.instance, q.status, q.time_start, q.time_end, q.errtype,
+q.consensus, q.best
+FROM quotes q
+WHERE q.id = (
+SELECT id
+FROM quotes
+WHERE isin = q.isin
+AND source = q.source
+AND errmsg IS NULL
+ORDER BY timestamp DESC, id DESC
+LIMIT 1
+)
+ORDER BY q.isin, q.source
+`
+	return qdb.selectQuotes("select last success quotes", sqlSelect)
+}
+
+// QuoteStatus selects the quotes by status.
+type QuoteStatus int
+
+// QuoteStatus enum
+const (
+	AnyStatus QuoteStatus = iota
+	SuccessStatus
+	ErrorStatus
 )
-order by q.isin, q.timestamp desc, q.source
+
+// QuoteFilter defines the quotes selected by SelectQuotes.
+//
+// Empty Isins and Sources select all the isins and sources.
+// From and To are the interval of days of the quotes (not bounded if zero):
+// the date of the quote is used for the successful quotes,
+// and the timestamp for the errors.
+// If Last is true, only the last quote (by timestamp) of each isin and source is selected.
+// If Best is true, only the best quotes of the consensus of each run are selected.
+type QuoteFilter struct {
+	Isins   []string
+	Sources []string
+	From    time.Time
+	To      time.Time
+	Status  QuoteStatus
+	Last    bool
+	Best    bool
+}
+
+// day returns the day of the record used by the QuoteFilter.
+func (r *QuoteRecord) day() string {
+	if r.Date.IsZero() {
+		return r.Timestamp.Format("2006-01-02")
+	}
+	return r.Date.Format("2006-01-02")
+}
+
+// sqlIn returns the "column IN (?, ...)" condition and its args.
+func sqlIn(column string, values []string) (string, []any) {
+	args := make([]any, len(values))
+	for j, v := range values {
+		args[j] = v
+	}
+	return column + " IN (?" + strings.Repeat(", ?", len(values)-1) + ")", args
+}
+
+// layoutDay is the layout of the days compared by the filters.
+const layoutDay = "2006-01-02"
+
+// sqlDayRange returns the conditions, and their args, that select a superset
+// of the rows with any of the columns in the [from, to] interval of days
+// (not bounded if zero). The interval is extended by one day on each side,
+// because the times saved with different time zones are not comparable
+// as strings: the exact interval must be checked on the selected rows.
+func sqlDayRange(from, to time.Time, columns ...string) ([]string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	bound := func(op string, t time.Time) {
+		conds := make([]string, len(columns))
+		for j, c := range columns {
+			conds[j] = c + " " + op + " ?"
+			args = append(args, t.Format(layoutDay))
+		}
+		where = append(where, "("+strings.Join(conds, " OR ")+")")
+	}
+	if !from.IsZero() {
+		bound(">=", from.AddDate(0, 0, -1))
+	}
+	if !to.IsZero() {
+		bound("<", to.AddDate(0, 0, 2))
+	}
+	return where, args
+}
+
+// SelectQuotes selects the quotes of the filter.
+// The quotes are ordered by day and isin, then by timestamp descending.
+// A nil filter selects all the quotes.
+func (qdb *QuoteDatabase) SelectQuotes(filter *QuoteFilter) ([]*QuoteRecord, error) {
+	if filter == nil {
+		filter = &QuoteFilter{}
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	if len(filter.Isins) > 0 {
+		cond, a := sqlIn("isin", filter.Isins)
+		where = append(where, cond)
+		args = append(args, a...)
+	}
+	if len(filter.Sources) > 0 {
+		cond, a := sqlIn("source", filter.Sources)
+		where = append(where, cond)
+		args = append(args, a...)
+	}
+	switch filter.Status {
+	case SuccessStatus:
+		where = append(where, "errmsg IS NULL")
+	case ErrorStatus:
+		where = append(where, "errmsg IS NOT NULL")
+	}
+	if filter.Best {
+		where = append(where, "best = ?")
+		args = append(args, true)
+	}
+	// the day of a quote is its date, or its timestamp if the date is not defined
+	conds, a := sqlDayRange(filter.From, filter.To, "date", "timestamp")
+	where = append(where, conds...)
+	args = append(args, a...)
+
+	sqlSelect := `SELECT id, timestamp, isin, source,
+date, price, price_raw, currency, url, errmsg,
+run_id, instance, status, time_start, time_end, errtype,
+consensus, best
+FROM quotes
 `
-	rows, err := qdb.db.Query(sqlSelect)
+	if len(where) > 0 {
+		sqlSelect += "WHERE " + strings.Join(where, "\nAND ") + "\n"
+	}
+
+	records, err := qdb.selectQuotes("select quotes", sqlSelect, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: the sql query selects the dates of the interval extended by one day,
+	// because the dates saved with different time zones are not comparable as strings:
+	// the dates are filtered exactly and sorted here, as calendar days,
+	// each one in its own location.
+	var fromDay, toDay string
+	if !filter.From.IsZero() {
+		fromDay = filter.From.Format(layoutDay)
+	}
+	if !filter.To.IsZero() {
+		toDay = filter.To.Format(layoutDay)
+	}
+
+	type key struct{ isin, source string }
+	last := map[key]*QuoteRecord{}
+
+	filtered := records[:0]
+	for _, r := range records {
+		day := r.day()
+		if (fromDay != "" && day < fromDay) || (toDay != "" && day > toDay) {
+			continue
+		}
+		if filter.Last {
+			k := key{r.Isin, r.Source}
+			if prev, ok := last[k]; ok && !r.Timestamp.After(prev.Timestamp) {
+				continue
+			}
+			last[k] = r
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	if filter.Last {
+		for _, r := range last {
+			filtered = append(filtered, r)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		if da, db := a.day(), b.day(); da != db {
+			return da < db
+		}
+		if a.Isin != b.Isin {
+			return a.Isin < b.Isin
+		}
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.After(b.Timestamp)
+		}
+		return a.Source < b.Source
+	})
+	return filtered, nil
+}
+
+// SelectSuccessQuotes selects the successful quotes of the isins
+// with date in the [from, to] interval of days.
+// If isins is empty, all the isins are selected;
+// if from or to are zero, the interval is not bounded.
+// The quotes are ordered by date and isin, then by timestamp descending.
+func (qdb *QuoteDatabase) SelectSuccessQuotes(isins []string, from, to time.Time) ([]*QuoteRecord, error) {
+	return qdb.SelectQuotes(&QuoteFilter{
+		Isins:  isins,
+		From:   from,
+		To:     to,
+		Status: SuccessStatus,
+	})
+}
+
+// selectQuotes executes the select query of the quotes.
+// The query must return the columns:
+// id, timestamp, isin, source, date, price, price_raw, currency, url, errmsg,
+// run_id, instance, status, time_start, time_end, errtype, consensus and best.
+func (qdb *QuoteDatabase) selectQuotes(label, sqlSelect string, args ...any) ([]*QuoteRecord, error) {
+	rows, err := qdb.db.Query(qdb.dialect.rebind(sqlSelect), args...)
 	if err != nil {
-		return nil, newError("Select last quotes", err)
+		return nil, newError("%s: %w", label, err)
 	}
 	defer rows.Close()
 
 	var result []*QuoteRecord
 	for rows.Next() {
 		var (
-			currency, url, errmsg sql.NullString
-			price                 sql.NullFloat64
-			// date                  sql.NullTime
+			priceRaw, currency, url, errmsg, status, errtype, consensus sql.NullString
+			price                                                       sql.NullFloat64
+			runID, instance                                             sql.NullInt64
+			timeStart, timeEnd                                          sql.NullTime
+			best                                                        sql.NullBool
 		)
 		r := &QuoteRecord{}
 		err = rows.Scan(&r.ID, &r.Timestamp, &r.Isin, &r.Source,
-			&r.Date, &price, &currency, &url, &errmsg)
+			&r.Date, &price, &priceRaw, &currency, &url, &errmsg,
+			&runID, &instance, &status, &timeStart, &timeEnd, &errtype,
+			&consensus, &best)
 		if err != nil {
-			return nil, newError("Select last quotes", err)
-		}
-		// if date.Valid {
-		// 	r.date = date.Time
-		// }
-		if price.Valid {
-			r.Price = float32(price.Float64)
-		}
-		if currency.Valid {
-			r.Currency = currency.String
-		}
-		if url.Valid {
-			r.URL = url.String
-		}
-		if errmsg.Valid {
-			r.ErrMsg = errmsg.String
+			return nil, newError("%s: %w", label, err)
 		}
+		r.Price = price.Float64
+		r.PriceRaw = priceRaw.String
+		r.Currency = currency.String
+		r.URL = url.String
+		r.ErrMsg = errmsg.String
+		r.RunID = runID.Int64
+		r.Instance = int(instance.Int64)
+		r.Status = status.String
+		r.TimeStart = timeStart.Time
+		r.TimeEnd = timeEnd.Time
+		r.ErrType = errtype.String
+		r.Consensus = consensus.String
+		r.Best = best.Bool
 
 		result = append(result, r)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, newError("%s: %w", label, err)
+	}
 	return result, nil
 }
-*/
 
+// Result returns the quotes.Result corresponding to the record.
+func (r *QuoteRecord) Result() *quotes.Result {
+	res := &quotes.Result{
+		Isin:      r.Isin,
+		Source:    r.Source,
+		URL:       r.URL,
+		Price:     r.Price,
+		PriceRaw:  r.PriceRaw,
+		Currency:  r.Currency,
+		Instance:  r.Instance,
+		TimeStart: r.Timestamp,
+		TimeEnd:   r.Timestamp,
+		Status:    taskengine.EventSuccess,
+		Consensus: r.Consensus,
+		Best:      r.Best,
+	}
+	// the timings are saved since schema version 4
+	if !r.TimeStart.IsZero() && !r.TimeEnd.IsZero() {
+		res.TimeStart = r.TimeStart
+		res.TimeEnd = r.TimeEnd
+	}
+	if !r.Date.IsZero() {
+		date := r.Date
+		res.Date = &date
+	}
+	if r.ErrMsg != "" {
+		res.Err = quotes.NewErrorJsonizable(errors.New(r.ErrMsg))
+		res.Status = taskengine.EventError
+	}
+	return res
+}
+
+// errorType returns the scrapers.ErrorType of the error,
+// or an empty string if err is not a scrapers.Error.
+func errorType(err error) string {
+	var serr *scrapers.Error
+	if errors.As(err, &serr) {
+		return serr.Type().String()
+	}
+	return ""
+}
+
+// InsertQuotesResults inserts the results in the quotes database.
+// The canceled results are skipped.
 func (qdb *QuoteDatabase) InsertQuotesResults(results ...*quotes.Result) error {
+	return qdb.InsertQuotesRecords(resultsRecords(0, results)...)
+}
+
+// resultsRecords returns the quote records of the results of the run.
+// The canceled results are skipped. runID is 0 if the run is not defined.
+func resultsRecords(runID int64, results []*quotes.Result) []*QuoteRecord {
 	qrecords := []*QuoteRecord{}
 
 	// assert := func(b bool, label string) {
@@ -406,17 +717,26 @@ func (qdb *QuoteDatabase) InsertQuotesResults(results ...*quotes.Result) error {
 		}
 
 		qr := &QuoteRecord{
-			Isin:     r.Isin,
-			Source:   r.Source,
-			Price:    r.Price,
-			Currency: r.Currency,
-			URL:      r.URL,
+			Isin:      r.Isin,
+			Source:    r.Source,
+			Price:     r.Price,
+			PriceRaw:  r.PriceRaw,
+			Currency:  r.Currency,
+			URL:       r.URL,
+			RunID:     runID,
+			Instance:  r.Instance,
+			Status:    r.Status.String(),
+			TimeStart: r.TimeStart,
+			TimeEnd:   r.TimeEnd,
+			Consensus: r.Consensus,
+			Best:      r.Best,
 		}
 		if r.Date != nil {
 			qr.Date = *r.Date
 		}
 		if r.Err != nil {
 			qr.ErrMsg = r.Err.Error()
+			qr.ErrType = errorType(r.Err)
 
 		}
 		// isin and source are mandatory
@@ -427,21 +747,72 @@ func (qdb *QuoteDatabase) InsertQuotesResults(results ...*quotes.Result) error {
 
 	}
 
-	// save to database
-	return qdb.InsertQuotesRecords(qrecords...)
+	return qrecords
 }
 
-func DBInsert(dbpath string, results []*quotes.Result) error {
+// DBInsert saves the results in the database.
+// If run is not nil, the run is saved too, and linked to the results.
+func DBInsert(dbpath string, run *Run, results []*quotes.Result) error {
 	if len(dbpath) == 0 {
 		return nil
 	}
 
 	// save to database
-	db, err := Open(dbpath)
+	db, err := Create(dbpath)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	return db.InsertQuotesResults(results...)
+	if run == nil {
+		return db.InsertQuotesResults(results...)
+	}
+	return db.InsertRun(run, results)
+}
+
+// DBSetLastURLs sets the URLs of the SourceIsins items
+// to the urls of the last successful quotes saved in the database,
+// so that the search step of the sources can be skipped.
+func DBSetLastURLs(dbpath string, sis []*quotes.SourceIsins) error {
+	if len(dbpath) == 0 {
+		return nil
+	}
+
+	db, err := Create(dbpath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	records, err := db.SelectLastSuccessQuotes()
+	if err != nil {
+		return err
+	}
+
+	// map from source to isin to url
+	urls := map[string]map[string]string{}
+	for _, r := range records {
+		if r.URL == "" {
+			continue
+		}
+		m := urls[r.Source]
+		if m == nil {
+			m = map[string]string{}
+			urls[r.Source] = m
+		}
+		m[r.Isin] = r.URL
+	}
+
+	for _, si := range sis {
+		m := urls[si.Source]
+		for _, isin := range si.Isins {
+			if url, ok := m[isin]; ok {
+				if si.URLs == nil {
+					si.URLs = map[string]string{}
+				}
+				si.URLs[isin] = url
+			}
+		}
+	}
+	return nil
 }
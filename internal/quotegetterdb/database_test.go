@@ -1,12 +1,15 @@
 package quotegetterdb
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/taskengine"
 )
 
 const (
@@ -88,7 +91,7 @@ const dbpath = ":memory:"
 
 func mustOpenDB() *QuoteDatabase {
 
-	qdb, err := Open(dbpath)
+	qdb, err := Create(dbpath)
 	if err != nil {
 		panic(err)
 	}
@@ -98,7 +101,7 @@ func mustOpenDB() *QuoteDatabase {
 func TestOpen(t *testing.T) {
 
 	// create the database
-	qdb, err := Open(dbpath)
+	qdb, err := Create(dbpath)
 	if err != nil {
 		t.Errorf("open database: unexpected error: %v", err)
 	}
@@ -122,27 +125,49 @@ func TestInsertQuotes(t *testing.T) {
 	}
 }
 
-/*
 func TestSelectLastQuotes(t *testing.T) {
 	qdb := mustOpenDB()
 	defer qdb.Close()
 
-	err := qdb.InsertQuotes(records...)
+	err := qdb.InsertQuotesRecords(records...)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	// isin1: the most recent date
 	res, err := qdb.SelectLastQuotes()
 	if err != nil {
 		t.Fatal(err)
 	}
-	for j, r := range res {
+	if len(res) != 1 {
+		t.Fatalf("expected 1 quote, found %d", len(res))
+	}
+	if r := res[0]; r.Isin != isin1 || r.Source != source2 || r.Price != 10.22 {
+		t.Errorf("expected the quote of %s, found %s %s %v", source2, r.Isin, r.Source, r.Price)
+	}
 
-		t.Logf("[%d] %v\n", j, r)
+	// isin2: the best quote of the same date, even if not the most recent one
+	date := time.Date(2020, 03, 01, 0, 0, 0, 0, loc)
+	err = qdb.InsertQuotesRecords(
+		&QuoteRecord{Isin: isin2, Source: source1, Price: 20.1, Currency: "EUR", Date: date,
+			Timestamp: time.Date(2020, 03, 01, 10, 0, 0, 0, loc), Best: true},
+		&QuoteRecord{Isin: isin2, Source: source2, Price: 20.2, Currency: "EUR", Date: date,
+			Timestamp: time.Date(2020, 03, 01, 11, 0, 0, 0, loc)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = qdb.SelectLastQuotes(isin2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected 1 quote, found %d", len(res))
+	}
+	if r := res[0]; r.Isin != isin2 || r.Source != source1 || r.Price != 20.1 {
+		t.Errorf("expected the best quote of %s, found %s %s %v", source1, r.Isin, r.Source, r.Price)
 	}
-	t.Fail()
 }
-*/
 
 /*
 func TestExtractPath(t *testing.T) {
@@ -193,8 +218,253 @@ func TestDBInsert(t *testing.T) {
 		Err:      errors.New("isin not found"),
 	}
 
-	err := DBInsert(dbpath, []*quotes.Result{res1, res2})
+	err := DBInsert(dbpath, nil, []*quotes.Result{res1, res2})
 	if err != nil {
 		t.Error(err)
 	}
 }
+
+func TestMigratePriceRaw(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	// create a database with the previous version of the quotes table
+	db, err := sql.Open("sqlite3", dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE quotes(
+id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+isin TEXT NOT NULL,
+source TEXT NOT NULL,
+datestamp DATETIME NOT NULL,
+timestamp DATETIME NOT NULL,
+date DATE NOT NULL,
+price DOUBLE,
+currency TEXT,
+url TEXT,
+errmsg TEXT
+);`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qdb, err := OpenNoMigrate(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qdb.Close()
+	if _, _, err = qdb.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := qdb.hasColumn("quotes", "price_raw")
+	if err != nil || !ok {
+		t.Fatalf("price_raw column: found %v (err %v), expected true", ok, err)
+	}
+
+	// the full precision price and the raw string are saved
+	rec := &QuoteRecord{
+		Isin:     isin1,
+		Source:   source1,
+		Price:    12345.678,
+		PriceRaw: "12.345,678",
+		Currency: "EUR",
+		Date:     time.Date(2020, 01, 01, 0, 0, 0, 0, loc),
+	}
+	if err := qdb.InsertQuotesRecords(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		price    float64
+		priceRaw string
+	)
+	err = qdb.db.QueryRow("SELECT price, price_raw FROM quotes").Scan(&price, &priceRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price != rec.Price || priceRaw != rec.PriceRaw {
+		t.Errorf("expected (%v, %q), found (%v, %q)", rec.Price, rec.PriceRaw, price, priceRaw)
+	}
+}
+
+func TestSelectLastSuccessQuotes(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	err := qdb.InsertQuotesRecords(records...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := qdb.SelectLastSuccessQuotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// isin2 has no successful quotes
+	want := []*QuoteRecord{records[2], records[3]}
+	if len(res) != len(want) {
+		t.Fatalf("expected %d records, found %d", len(want), len(res))
+	}
+	for j, r := range res {
+		w := want[j]
+		if r.Isin != w.Isin || r.Source != w.Source || r.Price != w.Price || r.URL != w.URL || !r.Date.Equal(w.Date) {
+			t.Errorf("[%d] expected %v, found %v", j, w, r)
+		}
+	}
+}
+
+func TestDBSetLastURLs(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	qdb, err := Create(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = qdb.InsertQuotesRecords(records...)
+	qdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sis := []*quotes.SourceIsins{
+		{Source: source1, Isins: []string{isin1, isin2}},
+		{Source: source2, Isins: []string{isin2}},
+	}
+	if err := DBSetLastURLs(dbpath, sis); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]string{
+		{isin1: testURL(source1, isin1)},
+		nil,
+	}
+	for j, si := range sis {
+		if fmt.Sprint(si.URLs) != fmt.Sprint(want[j]) {
+			t.Errorf("%s: expected %v, found %v", si.Source, want[j], si.URLs)
+		}
+	}
+}
+
+func TestSelectSuccessQuotes(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	err := qdb.InsertQuotesRecords(records...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		isins    []string
+		from, to time.Time
+		want     []*QuoteRecord
+	}{
+		{"all", nil, time.Time{}, time.Time{}, []*QuoteRecord{records[0], records[2], records[3]}},
+		{"isin2", []string{isin2}, time.Time{}, time.Time{}, nil},
+		{"from", nil, time.Date(2020, 01, 02, 0, 0, 0, 0, loc), time.Time{}, []*QuoteRecord{records[2], records[3]}},
+		{"from to", []string{isin1}, time.Date(2020, 01, 02, 0, 0, 0, 0, loc), time.Date(2020, 01, 31, 0, 0, 0, 0, loc), []*QuoteRecord{records[2]}},
+	}
+	for _, tt := range tests {
+		res, err := qdb.SelectSuccessQuotes(tt.isins, tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(tt.want) {
+			t.Errorf("%s: expected %d records, found %d", tt.name, len(tt.want), len(res))
+			continue
+		}
+		for j, r := range res {
+			w := tt.want[j]
+			if r.Isin != w.Isin || r.Source != w.Source || r.Price != w.Price || !r.Date.Equal(w.Date) {
+				t.Errorf("%s: [%d] expected %v, found %v", tt.name, j, w, r)
+			}
+		}
+	}
+}
+
+func TestSelectQuotes(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	err := qdb.InsertQuotesRecords(records...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter *QuoteFilter
+		want   []*QuoteRecord
+	}{
+		{"nil", nil, append(records[:6:6], records[7], records[6])},
+		{"isin2", &QuoteFilter{Isins: []string{isin2}}, []*QuoteRecord{records[7]}},
+		{"source2 errors", &QuoteFilter{Sources: []string{source2}, Status: ErrorStatus}, records[4:7]},
+		{"from to", &QuoteFilter{
+			From: time.Date(2020, 01, 02, 0, 0, 0, 0, loc),
+			To:   time.Date(2020, 02, 01, 0, 0, 0, 0, loc),
+		}, records[1:4]},
+		{"last", &QuoteFilter{Last: true}, []*QuoteRecord{records[2], records[7], records[6]}},
+		{"last to", &QuoteFilter{Last: true, To: time.Date(2020, 02, 02, 0, 0, 0, 0, loc)}, []*QuoteRecord{records[2], records[4]}},
+		{"last success", &QuoteFilter{Isins: []string{isin1}, Last: true, Status: SuccessStatus}, []*QuoteRecord{records[2], records[3]}},
+	}
+	for _, tt := range tests {
+		res, err := qdb.SelectQuotes(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(tt.want) {
+			t.Errorf("%s: expected %d records, found %d", tt.name, len(tt.want), len(res))
+			continue
+		}
+		for j, r := range res {
+			w := tt.want[j]
+			if r.Isin != w.Isin || r.Source != w.Source || r.ErrMsg != w.ErrMsg || !r.Timestamp.Equal(w.Timestamp) {
+				t.Errorf("%s: [%d] expected %v, found %v", tt.name, j, w, r)
+			}
+		}
+	}
+}
+
+func TestSelectQuotesTimeZones(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	// the days of the quotes in their own locations differ from the days in UTC
+	east := time.Date(2020, 03, 10, 0, 30, 0, 0, time.FixedZone("east", 14*3600))
+	west := time.Date(2020, 03, 9, 22, 30, 0, 0, time.FixedZone("west", -12*3600))
+	err := qdb.InsertQuotesRecords(
+		&QuoteRecord{Isin: isin1, Source: source1, Date: east, Timestamp: east, Price: 1},
+		&QuoteRecord{Isin: isin1, Source: source2, Date: west, Timestamp: west, Price: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for day, source := range map[int]string{9: source2, 10: source1} {
+		d := time.Date(2020, 03, day, 0, 0, 0, 0, time.UTC)
+		res, err := qdb.SelectQuotes(&QuoteFilter{From: d, To: d})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 1 || res[0].Source != source {
+			t.Errorf("day %d: expected the quote of %s, found %v", day, source, res)
+		}
+	}
+}
+
+func TestQuoteRecordResult(t *testing.T) {
+	res := records[0].Result()
+	if res.Isin != isin1 || res.Price != 10.1 || res.Currency != "USD" || res.Err != nil ||
+		res.Date == nil || !res.Date.Equal(records[0].Date) || res.Status != taskengine.EventSuccess {
+		t.Errorf("unexpected result %v", res)
+	}
+
+	res = records[1].Result()
+	if res.Err == nil || res.Err.Error() != "Isin not found" || res.Date != nil || res.Status != taskengine.EventError {
+		t.Errorf("unexpected result %v", res)
+	}
+}
@@ -0,0 +1,266 @@
+package quotegetterdb
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq" // Import PostgreSQL driver
+)
+
+// dialect handles the differences between the supported databases.
+// The queries of the package are written with the ? placeholders,
+// converted by the rebind function of the dialect.
+type dialect interface {
+	// driver returns the name of the database/sql driver.
+	driver() string
+
+	// rebind converts the ? placeholders of the query.
+	rebind(query string) string
+
+	// createTableSchemaVersion returns the statement that creates the schema_version table.
+	createTableSchemaVersion() string
+
+	// createTableQuotes returns the statement that creates the quotes table
+	// of the first version of the schema.
+	createTableQuotes() string
+
+	// idColumn returns the definition of the autoincrement primary key id column.
+	idColumn() string
+
+	// timestampType returns the type of the timestamp columns.
+	timestampType() string
+
+	// insertQuote returns the statement that inserts a quote,
+	// replacing the quote with the same isin, source, datestamp and date.
+	insertQuote() string
+
+	// hasColumn returns the query that counts the columns of a table with a name.
+	// The arguments of the query are the table and the column.
+	hasColumn() string
+
+	// hasTable returns the query that counts the tables with a name.
+	// The argument of the query is the table.
+	hasTable() string
+
+	// countTables returns the query that counts the tables other than schema_version.
+	countTables() string
+
+	// backup saves a copy of the database, if supported.
+	// It returns the path of the backup, or an empty string if not saved.
+	backup(db *sql.DB, dns string, version int) (string, error)
+}
+
+// newDialect returns the dialect of the database:
+// the postgres:// and postgresql:// urls are PostgreSQL databases,
+// otherwise the dns is a sqlite3 database.
+func newDialect(dns string) dialect {
+	lower := strings.ToLower(dns)
+	if strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") {
+		return postgresDialect{}
+	}
+	return sqliteDialect{}
+}
+
+// Redacted returns the dns with the password of the url replaced by "xxxxx",
+// to be shown in the output.
+func Redacted(dns string) string {
+	if _, ok := newDialect(dns).(postgresDialect); !ok {
+		return dns
+	}
+	u, err := url.Parse(dns)
+	if err != nil {
+		return dns
+	}
+	return u.Redacted()
+}
+
+// insertColumns are the columns of the insert statement of the quotes.
+const insertColumns = `datestamp,
+timestamp,
+isin,
+source,
+date,
+price,
+price_raw,
+currency,
+url,
+errmsg,
+run_id,
+instance,
+status,
+time_start,
+time_end,
+errtype,
+consensus,
+best`
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) driver() string { return "sqlite3" }
+
+func (sqliteDialect) rebind(query string) string { return query }
+
+func (sqliteDialect) createTableSchemaVersion() string {
+	return `CREATE TABLE IF NOT EXISTS schema_version(
+version INTEGER NOT NULL PRIMARY KEY,
+description TEXT NOT NULL,
+applied DATETIME NOT NULL
+);
+`
+}
+
+func (sqliteDialect) createTableQuotes() string {
+	return `CREATE TABLE IF NOT EXISTS quotes(
+id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+isin TEXT NOT NULL,
+source TEXT NOT NULL,
+datestamp DATETIME NOT NULL,
+timestamp DATETIME NOT NULL,
+date DATE NOT NULL,
+price DOUBLE,
+currency TEXT,
+url TEXT,
+errmsg TEXT
+);
+`
+}
+
+func (sqliteDialect) idColumn() string {
+	return "id integer NOT NULL PRIMARY KEY AUTOINCREMENT"
+}
+
+func (sqliteDialect) timestampType() string { return "DATETIME" }
+
+func (sqliteDialect) insertQuote() string {
+	return `INSERT OR REPLACE INTO quotes(
+` + insertColumns + `
+) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+}
+
+func (sqliteDialect) hasColumn() string {
+	return "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?"
+}
+
+func (sqliteDialect) hasTable() string {
+	return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+}
+
+func (sqliteDialect) countTables() string {
+	return `SELECT COUNT(*) FROM sqlite_master
+WHERE type = 'table' AND name NOT IN ('schema_version', 'sqlite_sequence')`
+}
+
+// backup saves a copy of the database in the same folder,
+// with the schema version and the current time appended to the name.
+// The in-memory databases are not saved.
+func (sqliteDialect) backup(db *sql.DB, dns string, version int) (string, error) {
+	pathname := filePath(dns)
+	if pathname == "" {
+		return "", nil
+	}
+	backup := fmt.Sprintf("%s.v%d-%s.bak", pathname, version, time.Now().Format("20060102-150405"))
+	if _, err := db.Exec("VACUUM INTO ?", backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) driver() string { return "postgres" }
+
+// rebind converts the ? placeholders to the $1, $2, ... placeholders.
+// NOTE: the queries must not contain ? characters other than the placeholders.
+func (postgresDialect) rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (postgresDialect) createTableSchemaVersion() string {
+	return `CREATE TABLE IF NOT EXISTS schema_version(
+version INTEGER NOT NULL PRIMARY KEY,
+description TEXT NOT NULL,
+applied TIMESTAMPTZ NOT NULL
+);
+`
+}
+
+func (postgresDialect) createTableQuotes() string {
+	return `CREATE TABLE IF NOT EXISTS quotes(
+id SERIAL PRIMARY KEY,
+isin TEXT NOT NULL,
+source TEXT NOT NULL,
+datestamp TIMESTAMPTZ NOT NULL,
+timestamp TIMESTAMPTZ NOT NULL,
+date DATE NOT NULL,
+price DOUBLE PRECISION,
+currency TEXT,
+url TEXT,
+errmsg TEXT
+);
+`
+}
+
+func (postgresDialect) idColumn() string { return "id SERIAL PRIMARY KEY" }
+
+func (postgresDialect) timestampType() string { return "TIMESTAMPTZ" }
+
+func (postgresDialect) insertQuote() string {
+	return `INSERT INTO quotes(
+` + insertColumns + `
+) values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+ON CONFLICT (isin, source, datestamp, date) DO UPDATE SET
+timestamp = EXCLUDED.timestamp,
+price = EXCLUDED.price,
+price_raw = EXCLUDED.price_raw,
+currency = EXCLUDED.currency,
+url = EXCLUDED.url,
+errmsg = EXCLUDED.errmsg,
+run_id = EXCLUDED.run_id,
+instance = EXCLUDED.instance,
+status = EXCLUDED.status,
+time_start = EXCLUDED.time_start,
+time_end = EXCLUDED.time_end,
+errtype = EXCLUDED.errtype,
+consensus = EXCLUDED.consensus,
+best = EXCLUDED.best
+`
+}
+
+func (postgresDialect) hasColumn() string {
+	return `SELECT COUNT(*) FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`
+}
+
+func (postgresDialect) hasTable() string {
+	return `SELECT COUNT(*) FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_name = $1`
+}
+
+func (postgresDialect) countTables() string {
+	return `SELECT COUNT(*) FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_name <> 'schema_version'`
+}
+
+// backup is not supported: the PostgreSQL databases must be saved with pg_dump.
+func (postgresDialect) backup(*sql.DB, string, int) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,96 @@
+package quotegetterdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mmbros/quotes/internal/fx"
+)
+
+// migrateCreateTableFXRates creates the fx_rates table of the exchange rates.
+// Each rate is the value of 1 unit of the base currency in the currency.
+func migrateCreateTableFXRates(tx *sql.Tx, d dialect) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS fx_rates(
+date DATE NOT NULL,
+base TEXT NOT NULL,
+currency TEXT NOT NULL,
+rate DOUBLE PRECISION NOT NULL,
+source TEXT,
+timestamp ` + d.timestampType() + ` NOT NULL
+);
+`)
+	if err == nil {
+		_, err = tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_fx_rates_date_base_currency
+ON fx_rates (date, base, currency);`)
+	}
+	return err
+}
+
+// InsertRates inserts the exchange rates retrieved by the source,
+// replacing the rates of the same date, base and currency.
+func (qdb *QuoteDatabase) InsertRates(source string, rates ...*fx.Rates) error {
+	// NOTE: the upsert syntax is supported by both sqlite3 and PostgreSQL.
+	stmt, err := qdb.db.Prepare(qdb.dialect.rebind(`INSERT INTO fx_rates(
+date, base, currency, rate, source, timestamp
+) values(?, ?, ?, ?, ?, ?)
+ON CONFLICT (date, base, currency) DO UPDATE SET
+rate = excluded.rate,
+source = excluded.source,
+timestamp = excluded.timestamp
+`))
+	if err != nil {
+		return newError("insert rates: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	timestamp := time.Now()
+	for _, r := range rates {
+		for currency, rate := range r.Rates {
+			_, err = stmt.Exec(r.Date, r.Base, currency, rate, ToNullString(source), timestamp)
+			if err != nil {
+				return newError("insert rates: execute: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// SelectRates selects all the exchange rates, grouped by date and base currency
+// and sorted by date.
+func (qdb *QuoteDatabase) SelectRates() ([]*fx.Rates, error) {
+	rows, err := qdb.db.Query(`SELECT date, base, currency, rate
+FROM fx_rates
+ORDER BY date, base, currency
+`)
+	if err != nil {
+		return nil, newError("select rates: %w", err)
+	}
+	defer rows.Close()
+
+	type key struct{ day, base string }
+	index := map[key]*fx.Rates{}
+
+	var result []*fx.Rates
+	for rows.Next() {
+		var (
+			date           time.Time
+			base, currency string
+			rate           float64
+		)
+		if err = rows.Scan(&date, &base, &currency, &rate); err != nil {
+			return nil, newError("select rates: %w", err)
+		}
+		k := key{date.Format("2006-01-02"), base}
+		r := index[k]
+		if r == nil {
+			r = &fx.Rates{Date: date, Base: base, Rates: map[string]float64{}}
+			index[k] = r
+			result = append(result, r)
+		}
+		r.Rates[currency] = rate
+	}
+	if err = rows.Err(); err != nil {
+		return nil, newError("select rates: %w", err)
+	}
+	return result, nil
+}
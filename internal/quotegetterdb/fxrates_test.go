@@ -0,0 +1,43 @@
+package quotegetterdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/fx"
+)
+
+func TestRates(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	day1 := time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+	err := qdb.InsertRates("ecb",
+		&fx.Rates{Date: day2, Base: "EUR", Rates: map[string]float64{"USD": 1.1, "GBP": 0.8}},
+		&fx.Rates{Date: day1, Base: "EUR", Rates: map[string]float64{"USD": 1.2}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the rate of the same date, base and currency is replaced
+	err = qdb.InsertRates("ecb", &fx.Rates{Date: day2, Base: "EUR", Rates: map[string]float64{"USD": 1.15}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := qdb.SelectRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 days, found %d", len(rates))
+	}
+	if r := rates[0]; !r.Date.Equal(day1) || r.Base != "EUR" || len(r.Rates) != 1 || r.Rates["USD"] != 1.2 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+	if r := rates[1]; !r.Date.Equal(day2) || r.Rates["USD"] != 1.15 || r.Rates["GBP"] != 0.8 {
+		t.Errorf("unexpected rates %+v", r)
+	}
+}
@@ -0,0 +1,274 @@
+package quotegetterdb
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Migration is an ordered change of the schema of the quote database.
+//
+// The applied migrations are saved in the schema_version table:
+// the version of the database is the greatest applied version
+// (0 for a new database, or a database created before the migrations).
+// The up functions must be idempotent, so that the databases
+// created before the migrations can be migrated too.
+type Migration struct {
+	Version     int
+	Description string
+	up          func(tx *sql.Tx, d dialect) error
+}
+
+// migrations are the schema migrations, ordered by version.
+var migrations = []*Migration{
+	{1, "create table quotes", migrateCreateTableQuotes},
+	{2, "add column quotes.price_raw", migrateAddPriceRaw},
+	{3, "create table runs", migrateCreateTableRuns},
+	{4, "add columns quotes.run_id, instance, status, time_start and time_end", migrateAddQuotesRunColumns},
+	{5, "add column quotes.errtype", migrateAddErrType},
+	{6, "add columns quotes.consensus and best", migrateAddConsensus},
+	{7, "create table fx_rates", migrateCreateTableFXRates},
+	{8, "create table alerts", migrateCreateTableAlerts},
+}
+
+// LatestVersion returns the schema version of a fully migrated database.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// queryRower is implemented by both sql.DB and sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// tableHasColumn checks if the table has the column.
+func tableHasColumn(q queryRower, d dialect, table, column string) (bool, error) {
+	var n int
+	err := q.QueryRow(d.hasColumn(), table, column).Scan(&n)
+	return n > 0, err
+}
+
+// hasColumn checks if the table has the column.
+func (qdb *QuoteDatabase) hasColumn(table, column string) (bool, error) {
+	return tableHasColumn(qdb.db, qdb.dialect, table, column)
+}
+
+func migrateCreateTableQuotes(tx *sql.Tx, d dialect) error {
+	/*
+		crea un unique index sui campi (isin, source, datestamp, date)
+		- datestamp e' il timestamp con la sola data, senza orario
+		- date e' reso not null per evitare di far fallire il controllo di unique
+
+		in caso di insert con gli stessi valori di (isin, source, datestamp, date)
+		il nuovo record sostituisce il vecchio mediante la clausola
+		   INSERT OR REPLACE INTO quotes
+
+		In questo modo, a parita' di isin, source e datastamp,
+		sara' presente un solo record per ogni data
+		Ad esempio sara' possibile avere:
+		  ISIN          SOURCE     DATASTAMP   DATE
+		  isin00001234  source.it  2020-10-01  2020-09-30
+		  isin00001234  source.it  2020-10-01  2020-09-29
+		  isin00001234  source.it  2020-10-01  0001-01-01  (zero date)
+		ma non
+		  ISIN          SOURCE     DATASTAMP   DATE
+		  isin00001234  source.it  2020-10-01  2020-09-30
+		  isin00001234  source.it  2020-10-01  2020-09-30
+		e non
+		  ISIN          SOURCE     DATASTAMP   DATE
+		  isin00001234  source.it  2020-10-01  0001-01-01  (zero date)
+		  isin00001234  source.it  2020-10-01  0001-01-01  (zero date)
+	*/
+	// NOTE: in PostgreSQL, the replace is done by the ON CONFLICT clause of the insert.
+
+	// create table if not exists
+	_, err := tx.Exec(d.createTableQuotes())
+	if err != nil {
+		return err
+	}
+
+	// create index if not exists
+	_, err = tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_quotes_isin_source_dates
+ON quotes (isin, source, datestamp, date);`)
+	return err
+}
+
+// migrateAddPriceRaw adds the price_raw column,
+// already present in the databases created by previous versions.
+func migrateAddPriceRaw(tx *sql.Tx, d dialect) error {
+	ok, err := tableHasColumn(tx, d, "quotes", "price_raw")
+	if err == nil && !ok {
+		_, err = tx.Exec("ALTER TABLE quotes ADD COLUMN price_raw TEXT")
+	}
+	return err
+}
+
+// migrateAddErrType adds the errtype column, with the scrapers.ErrorType of the errors.
+func migrateAddErrType(tx *sql.Tx, d dialect) error {
+	ok, err := tableHasColumn(tx, d, "quotes", "errtype")
+	if err == nil && !ok {
+		_, err = tx.Exec("ALTER TABLE quotes ADD COLUMN errtype TEXT")
+	}
+	return err
+}
+
+// migrateAddConsensus adds the consensus and best columns,
+// with the result of the comparison of the prices of the sources.
+func migrateAddConsensus(tx *sql.Tx, d dialect) error {
+	columns := []struct{ name, typ string }{
+		{"consensus", "TEXT"},
+		{"best", "BOOLEAN"},
+	}
+	for _, c := range columns {
+		ok, err := tableHasColumn(tx, d, "quotes", c.name)
+		if err == nil && !ok {
+			_, err = tx.Exec("ALTER TABLE quotes ADD COLUMN " + c.name + " " + c.typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTableSchemaVersion creates the schema_version table if not exists.
+func (qdb *QuoteDatabase) createTableSchemaVersion() error {
+	_, err := qdb.db.Exec(qdb.dialect.createTableSchemaVersion())
+	if err != nil {
+		return newError("create table 'schema_version': %w", err)
+	}
+	return nil
+}
+
+// hasTable checks if the database has the table.
+func (qdb *QuoteDatabase) hasTable(table string) (bool, error) {
+	var n int
+	err := qdb.db.QueryRow(qdb.dialect.hasTable(), table).Scan(&n)
+	return n > 0, err
+}
+
+// Version returns the schema version of the database.
+// The database is not modified.
+func (qdb *QuoteDatabase) Version() (int, error) {
+	ok, err := qdb.hasTable("schema_version")
+	if err != nil {
+		return 0, newError("check table 'schema_version': %w", err)
+	}
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	err = qdb.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, newError("select schema version: %w", err)
+	}
+	return version, nil
+}
+
+// PendingMigrations returns the migrations not yet applied to the database.
+// It returns an error if the database has been created by a newer version
+// of the program.
+func (qdb *QuoteDatabase) PendingMigrations() ([]*Migration, error) {
+	version, err := qdb.Version()
+	if err != nil {
+		return nil, err
+	}
+	if latest := LatestVersion(); version > latest {
+		return nil, newError("quotes database schema version %d is newer than the supported version %d", version, latest)
+	}
+	var pending []*Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// isEmpty checks if the database has no table other than schema_version.
+func (qdb *QuoteDatabase) isEmpty() (bool, error) {
+	var n int
+	err := qdb.db.QueryRow(qdb.dialect.countTables()).Scan(&n)
+	return n == 0, err
+}
+
+// filePath returns the path of the database file,
+// or an empty string for an in-memory database.
+func filePath(dns string) string {
+	pathname := dns
+	if strings.Contains(pathname, ":memory:") || strings.Contains(pathname, "mode=memory") {
+		return ""
+	}
+	pathname = strings.TrimPrefix(pathname, "file:")
+	if i := strings.IndexRune(pathname, '?'); i >= 0 {
+		pathname = pathname[:i]
+	}
+	return pathname
+}
+
+// backup saves a copy of the database, if supported by the dialect.
+// It returns the path of the backup, or an empty string if not saved.
+func (qdb *QuoteDatabase) backup(version int) (string, error) {
+	backup, err := qdb.dialect.backup(qdb.db, qdb.dns, version)
+	if err != nil {
+		return "", newError("backup quotes database: %w", err)
+	}
+	return backup, nil
+}
+
+// Migrate applies the pending migrations, each one in its own transaction.
+// If backup is true and the database is not empty, a copy of the database
+// is saved before the migrations: its path is returned.
+// NOTE: only the sqlite3 databases are saved.
+// It returns the applied migrations.
+func (qdb *QuoteDatabase) Migrate(backup bool) (applied []*Migration, backupPath string, err error) {
+	pending, err := qdb.PendingMigrations()
+	if err != nil || len(pending) == 0 {
+		return nil, "", err
+	}
+
+	if backup {
+		empty, err := qdb.isEmpty()
+		if err != nil {
+			return nil, "", newError("check empty quotes database: %w", err)
+		}
+		if !empty {
+			version := pending[0].Version - 1
+			if backupPath, err = qdb.backup(version); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if err = qdb.createTableSchemaVersion(); err != nil {
+		return nil, backupPath, err
+	}
+	for _, m := range pending {
+		if err = qdb.apply(m); err != nil {
+			return applied, backupPath, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, backupPath, nil
+}
+
+// apply applies the migration and saves its version in the schema_version table.
+func (qdb *QuoteDatabase) apply(m *Migration) error {
+	tx, err := qdb.db.Begin()
+	if err == nil {
+		err = m.up(tx, qdb.dialect)
+		if err == nil {
+			_, err = tx.Exec(qdb.dialect.rebind("INSERT INTO schema_version(version, description, applied) VALUES (?, ?, ?)"),
+				m.Version, m.Description, time.Now())
+		}
+		if err == nil {
+			err = tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}
+	if err != nil {
+		return newError("migrate quotes database to version %d (%s): %w", m.Version, m.Description, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package quotegetterdb
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateNewDatabase(t *testing.T) {
+	dir := t.TempDir()
+
+	qdb, err := Create(filepath.Join(dir, "quotes.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qdb.Close()
+
+	version, err := qdb.Version()
+	if err != nil || version != LatestVersion() {
+		t.Errorf("version: expected %d, found %d (err %v)", LatestVersion(), version, err)
+	}
+
+	// no backup of a new database
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the database file, found %d files", len(entries))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	// create a database of a version without migrations
+	db, err := sql.Open("sqlite3", dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE quotes(
+id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+isin TEXT NOT NULL,
+source TEXT NOT NULL,
+datestamp DATETIME NOT NULL,
+timestamp DATETIME NOT NULL,
+date DATE NOT NULL,
+price DOUBLE,
+currency TEXT,
+url TEXT,
+errmsg TEXT
+);
+INSERT INTO quotes (isin, source, datestamp, timestamp, date, price, currency)
+VALUES ('isin1', 'source1', '2020-01-01', '2020-01-01 10:00:00', '2020-01-01', 10.1, 'EUR');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the database is not migrated by Open and Create
+	for _, open := range []func(string) (*QuoteDatabase, error){Open, Create} {
+		qdb, err := open(dbpath)
+		if err == nil {
+			qdb.Close()
+			t.Fatal("expected error, found no error")
+		}
+		if !errors.Is(err, ErrPendingMigrations) {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+
+	qdb, err := OpenNoMigrate(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qdb.Close()
+
+	// Version does not create the schema_version table
+	if version, err := qdb.Version(); err != nil || version != 0 {
+		t.Errorf("version: expected 0, found %d (err %v)", version, err)
+	}
+	if ok, err := qdb.hasTable("schema_version"); err != nil || ok {
+		t.Errorf("expected no schema_version table (err %v)", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dbpath)); len(entries) != 1 {
+		t.Errorf("expected only the database file, found %d files", len(entries))
+	}
+
+	pending, err := qdb.PendingMigrations()
+	if err != nil || len(pending) != len(migrations) {
+		t.Fatalf("pending migrations: expected %d, found %d (err %v)", len(migrations), len(pending), err)
+	}
+
+	applied, backup, err := qdb.Migrate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("applied migrations: expected %d, found %d", len(migrations), len(applied))
+	}
+	if !strings.HasPrefix(backup, dbpath+".v0-") {
+		t.Errorf("unexpected backup path %q", backup)
+	}
+
+	// the backup has the records of the database
+	bdb, err := sql.Open("sqlite3", backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bdb.Close()
+	var n int
+	if err := bdb.QueryRow("SELECT COUNT(*) FROM quotes").Scan(&n); err != nil || n != 1 {
+		t.Errorf("backup records: expected 1, found %d (err %v)", n, err)
+	}
+
+	if version, err := qdb.Version(); err != nil || version != LatestVersion() {
+		t.Errorf("version: expected %d, found %d (err %v)", LatestVersion(), version, err)
+	}
+
+	// nothing to do
+	applied, backup, err = qdb.Migrate(true)
+	if err != nil || applied != nil || backup != "" {
+		t.Errorf("expected no migrations, found %v, %q (err %v)", applied, backup, err)
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "quotes.sqlite3")
+
+	qdb, err := Create(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = qdb.db.Exec("INSERT INTO schema_version(version, description, applied) VALUES (999, 'future', CURRENT_TIMESTAMP)")
+	qdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qdb, err = Open(dbpath)
+	if err == nil {
+		qdb.Close()
+		t.Fatal("expected error, found no error")
+	}
+	if !strings.Contains(err.Error(), "newer than the supported version") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	testCases := []struct {
+		dns      string
+		expected string
+	}{
+		{"/tmp/finanze/db.sqlite3", "/tmp/finanze/db.sqlite3"},
+		{"file:/tmp/finanze/db.sqlite3", "/tmp/finanze/db.sqlite3"},
+		{"file:/tmp/finanze/db.sqlite3?cache=shared", "/tmp/finanze/db.sqlite3"},
+		{":memory:", ""},
+		{"file::memory:?cache=shared", ""},
+		{"file:/tmp/finanze/db.sqlite3?mode=memory&cache=shared", ""},
+	}
+
+	for _, tc := range testCases {
+		if res := filePath(tc.dns); res != tc.expected {
+			t.Errorf("filePath(%q): got %q, expected %q", tc.dns, res, tc.expected)
+		}
+	}
+}
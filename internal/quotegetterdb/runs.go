@@ -0,0 +1,169 @@
+package quotegetterdb
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotes"
+)
+
+// Run is an execution of the program that saves the quotes in the database.
+// The quotes of the run are linked to the run by the run_id column.
+type Run struct {
+	ID          int64
+	TimeStart   time.Time
+	TimeEnd     time.Time
+	Command     string // es. get or history
+	Config      string // path of the config file
+	Mode        string
+	Host        string
+	NumSuccess  int
+	NumError    int
+	NumCanceled int
+}
+
+// migrateCreateTableRuns creates the runs table.
+func migrateCreateTableRuns(tx *sql.Tx, d dialect) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS runs(
+` + d.idColumn() + `,
+time_start ` + d.timestampType() + ` NOT NULL,
+time_end ` + d.timestampType() + ` NOT NULL,
+command TEXT NOT NULL,
+config TEXT,
+mode TEXT,
+host TEXT,
+num_success INTEGER NOT NULL,
+num_error INTEGER NOT NULL,
+num_canceled INTEGER NOT NULL
+);
+`)
+	return err
+}
+
+// migrateAddQuotesRunColumns adds to the quotes table the run of the quote,
+// the instance and status of the task, and the timings of the request.
+func migrateAddQuotesRunColumns(tx *sql.Tx, d dialect) error {
+	columns := []struct{ name, typ string }{
+		{"run_id", "INTEGER"},
+		{"instance", "INTEGER"},
+		{"status", "TEXT"},
+		{"time_start", d.timestampType()},
+		{"time_end", d.timestampType()},
+	}
+	for _, c := range columns {
+		ok, err := tableHasColumn(tx, d, "quotes", c.name)
+		if err == nil && !ok {
+			_, err = tx.Exec("ALTER TABLE quotes ADD COLUMN " + c.name + " " + c.typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_quotes_run_id ON quotes (run_id)")
+	return err
+}
+
+// InsertRun inserts the run and its results in the quotes database,
+// in a single transaction, setting the ID of the run.
+// The times and the counts of the run are computed by the results, if not defined.
+// The canceled results are counted, but not inserted.
+func (qdb *QuoteDatabase) InsertRun(run *Run, results []*quotes.Result) error {
+	if stats := quotes.NewStats(results); stats != nil {
+		if run.TimeStart.IsZero() {
+			run.TimeStart = stats.TimeStart
+		}
+		if run.TimeEnd.IsZero() {
+			run.TimeEnd = stats.TimeEnd
+		}
+		if run.NumSuccess+run.NumError+run.NumCanceled == 0 {
+			// each result is counted once by worker
+			for _, s := range stats.Worker {
+				run.NumSuccess += s.NumSuccess
+				run.NumError += s.NumError
+				run.NumCanceled += s.NumCanceled
+			}
+		}
+	}
+	if run.TimeStart.IsZero() {
+		run.TimeStart = time.Now()
+	}
+	if run.TimeEnd.IsZero() {
+		run.TimeEnd = run.TimeStart
+	}
+
+	// the run and its quotes are saved in a single transaction
+	tx, err := qdb.db.Begin()
+	if err != nil {
+		return newError("insert run: %w", err)
+	}
+
+	sqlInsert := `INSERT INTO runs(
+time_start, time_end, command, config, mode, host,
+num_success, num_error, num_canceled
+) values(?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id`
+	var id int64
+	err = tx.QueryRow(qdb.dialect.rebind(sqlInsert),
+		run.TimeStart, run.TimeEnd, run.Command,
+		ToNullString(run.Config), ToNullString(run.Mode), ToNullString(run.Host),
+		run.NumSuccess, run.NumError, run.NumCanceled).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return newError("insert run: %w", err)
+	}
+
+	if err = qdb.insertQuotesRecords(tx, resultsRecords(id, results)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return newError("insert run: commit: %w", err)
+	}
+	run.ID = id
+	return nil
+}
+
+// SelectRuns selects the runs started in the [from, to) interval,
+// ordered by start time. If from or to are zero, the interval is not bounded.
+func (qdb *QuoteDatabase) SelectRuns(from, to time.Time) ([]*Run, error) {
+	sqlSelect := `SELECT id, time_start, time_end, command, config, mode, host,
+num_success, num_error, num_canceled
+FROM runs
+`
+	where, args := sqlDayRange(from, to, "time_start")
+	if len(where) > 0 {
+		sqlSelect += "WHERE " + strings.Join(where, "\nAND ") + "\n"
+	}
+	sqlSelect += "ORDER BY time_start, id\n"
+
+	rows, err := qdb.db.Query(qdb.dialect.rebind(sqlSelect), args...)
+	if err != nil {
+		return nil, newError("select runs: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*Run
+	for rows.Next() {
+		var config, mode, host sql.NullString
+		r := &Run{}
+		err = rows.Scan(&r.ID, &r.TimeStart, &r.TimeEnd, &r.Command, &config, &mode, &host,
+			&r.NumSuccess, &r.NumError, &r.NumCanceled)
+		if err != nil {
+			return nil, newError("select runs: %w", err)
+		}
+		// NOTE: the sql query selects the times of the interval extended by one day
+		// (see sqlDayRange): the exact interval is checked here.
+		if (!from.IsZero() && r.TimeStart.Before(from)) || (!to.IsZero() && !r.TimeStart.Before(to)) {
+			continue
+		}
+		r.Config = config.String
+		r.Mode = mode.String
+		r.Host = host.String
+		result = append(result, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, newError("select runs: %w", err)
+	}
+	return result, nil
+}
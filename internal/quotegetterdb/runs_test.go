@@ -0,0 +1,120 @@
+package quotegetterdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+	"github.com/mmbros/quotes/internal/quotes"
+	"github.com/mmbros/taskengine"
+)
+
+func TestInsertRun(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	t0 := time.Date(2026, time.October, 16, 18, 0, 0, 0, time.UTC)
+	date := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+	results := []*quotes.Result{
+		{
+			Isin: isin1, Source: source1, Instance: 2,
+			Price: 10.1, Currency: "EUR", Date: &date,
+			TimeStart: t0, TimeEnd: t0.Add(1500 * time.Millisecond),
+			Status: taskengine.EventSuccess, Consensus: quotes.ConsensusAgree, Best: true,
+		},
+		{
+			Isin: isin2, Source: source1, Instance: 1,
+			Err:       quotes.NewErrorJsonizable(scrapers.NewError(source1, isin2, "", scrapers.IsinNotFoundError, errors.New("isin not found"), nil)),
+			TimeStart: t0.Add(time.Second), TimeEnd: t0.Add(3 * time.Second),
+			Status: taskengine.EventError,
+		},
+		{
+			Isin: isin1, Source: source2, Instance: 1,
+			Err:       context.Canceled,
+			TimeStart: t0, TimeEnd: t0.Add(2 * time.Second),
+			Status: taskengine.EventCanceled,
+		},
+	}
+
+	run := &Run{Command: "get", Mode: "1", Host: "host1"}
+	if err := qdb.InsertRun(run, results); err != nil {
+		t.Fatal(err)
+	}
+	if run.ID == 0 || run.NumSuccess != 1 || run.NumError != 1 || run.NumCanceled != 1 {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if !run.TimeStart.Equal(t0) || !run.TimeEnd.Equal(t0.Add(3*time.Second)) {
+		t.Errorf("run times: expected [%v, %v], found [%v, %v]", t0, t0.Add(3*time.Second), run.TimeStart, run.TimeEnd)
+	}
+
+	runs, err := qdb.SelectRuns(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID || runs[0].Host != "host1" || runs[0].Config != "" || runs[0].NumError != 1 {
+		t.Errorf("unexpected runs %+v", runs)
+	}
+	if runs, _ = qdb.SelectRuns(t0.Add(time.Hour), time.Time{}); len(runs) != 0 {
+		t.Errorf("expected no runs, found %d", len(runs))
+	}
+
+	// the canceled result is not inserted
+	records, err := qdb.SelectQuotes(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, found %d", len(records))
+	}
+	for _, r := range records {
+		if r.RunID != run.ID {
+			t.Errorf("%s: expected run %d, found %d", r.Isin, run.ID, r.RunID)
+		}
+	}
+	r := records[0]
+	if r.Isin != isin1 || r.Instance != 2 || r.Status != "success" ||
+		!r.TimeStart.Equal(t0) || !r.TimeEnd.Equal(t0.Add(1500*time.Millisecond)) {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r.Consensus != quotes.ConsensusAgree || !r.Best {
+		t.Errorf("consensus: expected (%q, true), found (%q, %v)", quotes.ConsensusAgree, r.Consensus, r.Best)
+	}
+	if best, _ := qdb.SelectQuotes(&QuoteFilter{Best: true}); len(best) != 1 || best[0].ID != r.ID {
+		t.Errorf("expected the best record %d, found %v", r.ID, best)
+	}
+	if r := records[1]; r.ErrType != "IsinNotFoundError" {
+		t.Errorf("errtype: expected %q, found %q", "IsinNotFoundError", r.ErrType)
+	}
+	if res := r.Result(); res.Instance != 2 || res.TimeEnd.Sub(res.TimeStart) != 1500*time.Millisecond || !res.Best {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestInsertRunRollback(t *testing.T) {
+	qdb := mustOpenDB()
+	defer qdb.Close()
+
+	// the quotes cannot be inserted
+	if _, err := qdb.db.Exec("DROP TABLE quotes"); err != nil {
+		t.Fatal(err)
+	}
+	run := &Run{Command: "get"}
+	if err := qdb.InsertRun(run, []*quotes.Result{{Isin: isin1, Source: source1}}); err == nil {
+		t.Fatal("expected error, found nil")
+	}
+	if run.ID != 0 {
+		t.Errorf("expected no run id, found %d", run.ID)
+	}
+
+	// the run is not saved
+	runs, err := qdb.SelectRuns(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("expected no runs, found %d", len(runs))
+	}
+}
@@ -0,0 +1,217 @@
+package quotes
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Consensus values of the successful results.
+const (
+	ConsensusAgree   = "agree"   // the price agrees with the median price
+	ConsensusOutlier = "outlier" // the price deviates from the median price
+	ConsensusStale   = "stale"   // the date of the price is not the most recent one
+)
+
+// DefaultTolerance is the max relative deviation of a price
+// from the median price used if not defined.
+const DefaultTolerance = 0.02
+
+// ConsensusOptions are the options of the Consensus function.
+//
+// Tolerance is the max relative deviation of a price from the median price
+// (es. 0.02 is 2%): if not positive, DefaultTolerance is used.
+// If MaxAge is not nil, the prices with a date older than MaxAge
+// with respect to Now (time.Now() if zero) are stale too.
+type ConsensusOptions struct {
+	Tolerance float64
+	MaxAge    *MaxAge
+	Now       time.Time
+}
+
+// Consensus compares the prices of the successful results of each isin,
+// and sets the Consensus and Best fields of the results.
+//
+// The reference currency of an isin is the most common currency
+// of its prices: the prices in other currencies are not comparable
+// and are outliers. A price is stale if its date is before the most recent
+// date of the isin, or older than opts.MaxAge. The median price is computed
+// on the prices not stale: a price is an outlier if it deviates
+// from the median by more than the tolerance.
+//
+// The best result of each isin is the one not stale with the price
+// nearest to the median, preferring the ones that agree with the median.
+// In case of ties, the result retrieved first is the best.
+func Consensus(results []*Result, opts *ConsensusOptions) {
+	if opts == nil {
+		opts = &ConsensusOptions{}
+	}
+	tolerance := opts.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// successful results by isin, in the order of the results
+	var isins []string
+	groups := map[string][]*Result{}
+	for _, r := range results {
+		r.Consensus = ""
+		r.Best = false
+		if r.Err != nil || r.Price == 0 {
+			continue
+		}
+		if _, ok := groups[r.Isin]; !ok {
+			isins = append(isins, r.Isin)
+		}
+		groups[r.Isin] = append(groups[r.Isin], r)
+	}
+
+	for _, isin := range isins {
+		consensus(groups[isin], tolerance, opts.MaxAge, now)
+	}
+}
+
+// consensus sets the Consensus and Best fields of the successful results of an isin.
+func consensus(group []*Result, tolerance float64, maxAge *MaxAge, now time.Time) {
+	const layoutDay = "2006-01-02"
+	day := func(r *Result) string {
+		if r.Date == nil || r.Date.IsZero() {
+			return ""
+		}
+		return r.Date.Format(layoutDay)
+	}
+
+	currency := referenceCurrency(group)
+
+	// most recent day of the prices in the reference currency
+	var lastDay string
+	for _, r := range group {
+		if d := day(r); r.Currency == currency && d > lastDay {
+			lastDay = d
+		}
+	}
+
+	var fresh []*Result
+	for _, r := range group {
+		switch {
+		case r.Currency != currency:
+			r.Consensus = ConsensusOutlier
+		case day(r) < lastDay, r.Date != nil && maxAge.IsStale(*r.Date, now):
+			r.Consensus = ConsensusStale
+		default:
+			fresh = append(fresh, r)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	prices := make([]float64, len(fresh))
+	for j, r := range fresh {
+		prices[j] = r.Price
+	}
+	m := median(prices)
+
+	var best *Result
+	for _, r := range fresh {
+		r.Consensus = ConsensusAgree
+		if math.Abs(r.Price-m) > tolerance*math.Abs(m) {
+			r.Consensus = ConsensusOutlier
+		}
+		if best == nil || betterThan(r, best, m) {
+			best = r
+		}
+	}
+	best.Best = true
+}
+
+// betterThan checks if the result a is a better choice than b,
+// given the median price m.
+func betterThan(a, b *Result, m float64) bool {
+	if agreeA, agreeB := a.Consensus == ConsensusAgree, b.Consensus == ConsensusAgree; agreeA != agreeB {
+		return agreeA
+	}
+	if da, db := math.Abs(a.Price-m), math.Abs(b.Price-m); da != db {
+		return da < db
+	}
+	return a.TimeEnd.Before(b.TimeEnd)
+}
+
+// referenceCurrency returns the most common currency of the results.
+// In case of ties, the first in alphabetical order is returned.
+func referenceCurrency(group []*Result) string {
+	count := map[string]int{}
+	for _, r := range group {
+		count[r.Currency]++
+	}
+	var (
+		currency string
+		n        int
+	)
+	for c, k := range count {
+		if k > n || (k == n && c < currency) {
+			currency, n = c, k
+		}
+	}
+	return currency
+}
+
+// median returns the median of the values.
+// With an even number of values, the mean of the two middle values is returned.
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Best returns the best result of each isin, in the order of the results.
+// Consensus must be called before.
+func Best(results []*Result) []*Result {
+	var best []*Result
+	for _, r := range results {
+		if r.Best {
+			best = append(best, r)
+		}
+	}
+	return best
+}
+
+// Last returns the last successful result of each isin:
+// the one with the most recent date, preferring the best quote of the consensus.
+func Last(results []*Result) map[string]*Result {
+	day := func(r *Result) string {
+		if r.Date == nil {
+			return ""
+		}
+		return r.Date.Format("2006-01-02")
+	}
+
+	last := map[string]*Result{}
+	for _, r := range results {
+		if r.Err != nil || r.Price == 0 {
+			continue
+		}
+		prev := last[r.Isin]
+		if prev != nil {
+			if dr, dp := day(r), day(prev); dr != dp {
+				if dr < dp {
+					continue
+				}
+			} else if !r.Best || prev.Best {
+				continue
+			}
+		}
+		last[r.Isin] = r
+	}
+	return last
+}
@@ -0,0 +1,152 @@
+package quotes
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsensus(t *testing.T) {
+	T0 := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	D := func(days int) *time.Time {
+		d := time.Date(2026, 10, 16+days, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	type item struct {
+		source    string
+		price     float64
+		currency  string
+		date      *time.Time
+		err       error
+		consensus string
+		best      bool
+	}
+
+	tests := []struct {
+		name  string
+		opts  *ConsensusOptions
+		items []item
+	}{
+		{
+			name: "single",
+			items: []item{
+				{"s1", 10, "EUR", D(0), nil, ConsensusAgree, true},
+			},
+		},
+		{
+			name: "outlier",
+			items: []item{
+				{"s1", 10.0, "EUR", D(0), nil, ConsensusAgree, false},
+				{"s2", 12.0, "EUR", D(0), nil, ConsensusOutlier, false},
+				{"s3", 10.1, "EUR", D(0), nil, ConsensusAgree, true},
+				{"s4", 0, "", nil, errors.New("not found"), "", false},
+			},
+		},
+		{
+			name: "tolerance",
+			opts: &ConsensusOptions{Tolerance: 0.25},
+			items: []item{
+				{"s1", 10.0, "EUR", D(0), nil, ConsensusAgree, false},
+				{"s2", 12.0, "EUR", D(0), nil, ConsensusAgree, false},
+				{"s3", 10.1, "EUR", D(0), nil, ConsensusAgree, true},
+			},
+		},
+		{
+			name: "stale",
+			items: []item{
+				{"s1", 9.0, "EUR", D(-1), nil, ConsensusStale, false},
+				{"s2", 10.0, "EUR", D(0), nil, ConsensusAgree, true},
+				{"s3", 10.0, "EUR", D(0), nil, ConsensusAgree, false},
+			},
+		},
+		{
+			name: "max age",
+			opts: &ConsensusOptions{MaxAge: &MaxAge{Days: 2}, Now: T0},
+			items: []item{
+				{"s1", 10.0, "EUR", D(-3), nil, ConsensusStale, false},
+				{"s2", 10.0, "EUR", D(-3), nil, ConsensusStale, false},
+			},
+		},
+		{
+			name: "currency",
+			items: []item{
+				{"s1", 11.0, "USD", D(0), nil, ConsensusOutlier, false},
+				{"s2", 10.0, "EUR", D(0), nil, ConsensusAgree, true},
+				{"s3", 10.0, "EUR", D(0), nil, ConsensusAgree, false},
+			},
+		},
+		{
+			name: "disagree",
+			items: []item{
+				{"s1", 10.0, "EUR", D(0), nil, ConsensusOutlier, true},
+				{"s2", 12.0, "EUR", D(0), nil, ConsensusOutlier, false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		results := make([]*Result, len(tt.items))
+		for j, it := range tt.items {
+			results[j] = &Result{
+				Isin:     "isin1",
+				Source:   it.source,
+				Price:    it.price,
+				Currency: it.currency,
+				Date:     it.date,
+				Err:      it.err,
+				TimeEnd:  T0.Add(time.Duration(j) * time.Second),
+			}
+		}
+		Consensus(results, tt.opts)
+
+		for j, it := range tt.items {
+			r := results[j]
+			if r.Consensus != it.consensus || r.Best != it.best {
+				t.Errorf("%s: source %s: got (%q, %v), expected (%q, %v)",
+					tt.name, it.source, r.Consensus, r.Best, it.consensus, it.best)
+			}
+		}
+	}
+}
+
+func TestConsensusIsins(t *testing.T) {
+	results := []*Result{
+		{Isin: "isin1", Source: "s1", Price: 10, Currency: "EUR"},
+		{Isin: "isin2", Source: "s1", Price: 20, Currency: "EUR"},
+		{Isin: "isin1", Source: "s2", Price: 10, Currency: "EUR"},
+		{Isin: "isin3", Source: "s1", Err: errors.New("not found")},
+	}
+	Consensus(results, nil)
+
+	best := Best(results)
+	if len(best) != 2 || best[0] != results[0] || best[1] != results[1] {
+		t.Errorf("unexpected best results: %v", jsonString(best))
+	}
+}
+
+func TestLast(t *testing.T) {
+	D := func(day int) *time.Time {
+		d := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	results := []*Result{
+		{Isin: "isin1", Source: "s1", Price: 10, Date: D(16)},
+		{Isin: "isin1", Source: "s2", Price: 11, Date: D(16), Best: true},
+		{Isin: "isin1", Source: "s3", Price: 12, Date: D(16)},
+		{Isin: "isin1", Source: "s4", Price: 9, Date: D(15), Best: true},
+		{Isin: "isin2", Source: "s1", Price: 20, Date: D(14)},
+		{Isin: "isin2", Source: "s2", Err: errors.New("not found"), Date: D(15)},
+		{Isin: "isin3", Source: "s1", Err: errors.New("not found")},
+	}
+
+	last := Last(results)
+	if len(last) != 2 {
+		t.Fatalf("expected 2 isins, found %d", len(last))
+	}
+	if r := last["isin1"]; r.Source != "s2" {
+		t.Errorf("isin1: expected the best quote of the last date, found %s", r.Source)
+	}
+	if r := last["isin2"]; r.Source != "s1" {
+		t.Errorf("isin2: expected the successful quote, found %s", r.Source)
+	}
+}
@@ -0,0 +1,31 @@
+package quotes
+
+import "time"
+
+// Converter converts an amount from a currency to another one at a date.
+// It returns false if the exchange rate is not known.
+type Converter interface {
+	Convert(amount float64, from, to string, date time.Time) (float64, bool)
+}
+
+// ConvertPrices sets the price of the successful results converted
+// to the currency, with the exchange rate of the date of the price
+// (or of the request, if the date is not known).
+// The results without a known exchange rate are not converted.
+func ConvertPrices(results []*Result, currency string, conv Converter) {
+	for _, r := range results {
+		r.ConvertedPrice = 0
+		r.ConvertedCurrency = ""
+		if r.Err != nil || r.Price == 0 || r.Currency == "" {
+			continue
+		}
+		date := r.TimeEnd
+		if r.Date != nil && !r.Date.IsZero() {
+			date = *r.Date
+		}
+		if price, ok := conv.Convert(r.Price, r.Currency, currency, date); ok {
+			r.ConvertedPrice = price
+			r.ConvertedCurrency = currency
+		}
+	}
+}
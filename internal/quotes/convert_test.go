@@ -0,0 +1,45 @@
+package quotes
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fixedConverter converts the amounts with fixed rates to EUR.
+type fixedConverter map[string]float64
+
+func (c fixedConverter) Convert(amount float64, from, to string, date time.Time) (float64, bool) {
+	rate, ok := c[from]
+	if !ok || to != "EUR" {
+		return 0, false
+	}
+	return amount * rate, true
+}
+
+func TestConvertPrices(t *testing.T) {
+	date := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	results := []*Result{
+		{Isin: "isin1", Price: 10, Currency: "USD", Date: &date},
+		{Isin: "isin2", Price: 10, Currency: "EUR", Date: &date},
+		{Isin: "isin3", Price: 10, Currency: "JPY", Date: &date},
+		{Isin: "isin4", Err: errors.New("not found")},
+	}
+	ConvertPrices(results, "EUR", fixedConverter{"USD": 0.5, "EUR": 1})
+
+	expected := []struct {
+		price    float64
+		currency string
+	}{
+		{5, "EUR"},
+		{10, "EUR"},
+		{0, ""},
+		{0, ""},
+	}
+	for j, r := range results {
+		if r.ConvertedPrice != expected[j].price || r.ConvertedCurrency != expected[j].currency {
+			t.Errorf("%s: got (%v, %q), expected (%v, %q)", r.Isin,
+				r.ConvertedPrice, r.ConvertedCurrency, expected[j].price, expected[j].currency)
+		}
+	}
+}
@@ -7,6 +7,11 @@ type ErrorJsonizable struct {
 	err error
 }
 
+// NewErrorJsonizable returns a new ErrorJsonizable that wraps err.
+func NewErrorJsonizable(err error) *ErrorJsonizable {
+	return &ErrorJsonizable{err}
+}
+
 func (e *ErrorJsonizable) Error() string {
 	if e == nil || e.err == nil {
 		return ""
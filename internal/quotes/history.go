@@ -0,0 +1,190 @@
+package quotes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/taskengine"
+)
+
+// GetHistory retrieves the price history in the [from, to] interval
+// of the isins specified by the SourceIsins object.
+//
+// Only the sources implementing the quotegetter.HistoryGetter interface are used.
+// The sources of each isin are tried in the order of preference of the isin
+// defined by order (es. the sources of the isins of the config file):
+// the sources not in order are tried after, in alphabetical order.
+// The history of the isin is taken from the first source that succeeds.
+// The known info url of the isin (see SourceIsins.URLs) is used,
+// so that the search step of the source is skipped.
+//
+// The isins are retrieved concurrently, with at most Workers requests
+// at a time for each source. The results are ordered by isin, and an error
+// result is returned after the histories for each isin without history.
+//
+// If the context is done (es. canceled or deadline exceeded),
+// the results collected so far are returned.
+func GetHistory(ctx context.Context, availableSources quotegetter.Sources, items []*SourceIsins, order map[string][]string, from, to time.Time) ([]*Result, error) {
+
+	// check input
+	if err := checkListOfSourceIsins(availableSources, items); err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid history interval: from %s to %s",
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	quoteGetter, err := initQuoteGetters(availableSources, items)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sources of each isin, and the requests limit of each source
+	bySource := map[string]*SourceIsins{}
+	sourcesOf := map[string][]string{}
+	limits := map[string]chan struct{}{}
+	var isins []string
+	for _, item := range items {
+		bySource[item.Source] = item
+		for _, isin := range item.Isins {
+			if _, ok := sourcesOf[isin]; !ok {
+				isins = append(isins, isin)
+			}
+			sourcesOf[isin] = append(sourcesOf[isin], item.Source)
+		}
+		workers := item.Workers
+		if workers < 1 {
+			workers = 1
+		}
+		limits[item.Source] = make(chan struct{}, workers)
+	}
+	sort.Strings(isins)
+
+	// the results of each isin
+	isinResults := make([][]*Result, len(isins))
+	var wg sync.WaitGroup
+	for j, isin := range isins {
+		wg.Add(1)
+		go func(j int, isin string) {
+			defer wg.Done()
+
+			var errResult *Result
+		loop:
+			for _, source := range sortSources(sourcesOf[isin], order[isin]) {
+				if ctx.Err() != nil {
+					break
+				}
+				result := &Result{
+					Isin:      isin,
+					Source:    source,
+					TimeStart: time.Now(),
+				}
+
+				hg, ok := quoteGetter[source].(quotegetter.HistoryGetter)
+				if !ok {
+					// keep the error of a source with history, if any
+					if errResult == nil {
+						result.TimeEnd = result.TimeStart
+						result.Err = &ErrorJsonizable{fmt.Errorf("source %q does not support history", source)}
+						result.Status = taskengine.EventError
+						errResult = result
+					}
+					continue
+				}
+
+				// wait for a free worker of the source
+				select {
+				case limits[source] <- struct{}{}:
+				case <-ctx.Done():
+					break loop
+				}
+				result.TimeStart = time.Now()
+				url := bySource[source].URLs[isin]
+				hres, err := hg.GetHistory(ctx, isin, url, from, to)
+				if err != nil && url != "" && ctx.Err() == nil {
+					// the known info url is no longer valid:
+					// retry with the search step
+					hres, err = hg.GetHistory(ctx, isin, "", from, to)
+				}
+				<-limits[source]
+
+				result.TimeEnd = time.Now()
+				if err != nil {
+					result.Status = taskengine.EventError
+					if ctx.Err() != nil {
+						err = &canceledError{err}
+						result.Status = taskengine.EventCanceled
+					}
+					result.Err = &ErrorJsonizable{err}
+					errResult = result
+					continue
+				}
+
+				res := make([]*Result, 0, len(hres))
+				for _, r := range hres {
+					date := r.Date
+					res = append(res, &Result{
+						Isin:      isin,
+						Source:    source,
+						URL:       r.URL,
+						Price:     r.Price,
+						PriceRaw:  r.PriceRaw,
+						Currency:  r.Currency,
+						Date:      &date,
+						TimeStart: result.TimeStart,
+						TimeEnd:   result.TimeEnd,
+						Status:    taskengine.EventSuccess,
+					})
+				}
+				isinResults[j] = res
+				return
+			}
+			if errResult != nil {
+				isinResults[j] = []*Result{errResult}
+			}
+		}(j, isin)
+	}
+	wg.Wait()
+
+	// the histories, then the errors, in isins order
+	results := []*Result{}
+	var errs []*Result
+	for _, res := range isinResults {
+		if len(res) == 1 && res[0].Err != nil {
+			errs = append(errs, res[0])
+			continue
+		}
+		results = append(results, res...)
+	}
+	return append(results, errs...), nil
+}
+
+// sortSources returns the sources in the order of preference:
+// the ones in order first, then the others in alphabetical order.
+func sortSources(sources, order []string) []string {
+	rank := map[string]int{}
+	for j, s := range order {
+		if _, ok := rank[s]; !ok {
+			rank[s] = j
+		}
+	}
+	sorted := make([]string, len(sources))
+	copy(sorted, sources)
+	sort.Slice(sorted, func(i, j int) bool {
+		ri, oki := rank[sorted[i]]
+		rj, okj := rank[sorted[j]]
+		if oki != okj {
+			return oki
+		}
+		if oki {
+			return ri < rj
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
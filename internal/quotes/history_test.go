@@ -0,0 +1,192 @@
+package quotes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/taskengine"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyHistoryGetter struct {
+	dummyQuoteGetter
+}
+
+func newDummyHistoryGetter(source string, client *http.Client) quotegetter.QuoteGetter {
+	return &dummyHistoryGetter{dummyQuoteGetter{source, client}}
+}
+
+// running and maxRunning are the current and the max number
+// of concurrent calls of dummyHistoryGetter.GetHistory.
+var running, maxRunning int32
+
+func (qg *dummyHistoryGetter) GetHistory(ctx context.Context, isin, url string, from, to time.Time) ([]*quotegetter.Result, error) {
+	n := atomic.AddInt32(&running, 1)
+	defer atomic.AddInt32(&running, -1)
+	for {
+		m := atomic.LoadInt32(&maxRunning)
+		if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if !strings.HasPrefix(isin, "isin1") {
+		return nil, fmt.Errorf("dummyHistoryGetter: %s, %s: not implemented", qg.source, isin)
+	}
+	if url == "invalid" {
+		return nil, fmt.Errorf("dummyHistoryGetter: %s, %s: invalid url", qg.source, isin)
+	}
+	if url == "" {
+		url = "search"
+	}
+	var res []*quotegetter.Result
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		res = append(res, &quotegetter.Result{
+			URL:      url,
+			Date:     d,
+			Currency: "EUR",
+			Price:    12.35,
+		})
+	}
+	return res, nil
+}
+
+func TestGetHistory(t *testing.T) {
+	availableSources := quotegetter.Sources{
+		"source1": newDummyQuoteGetter,
+		"source2": newDummyHistoryGetter,
+	}
+
+	sis := []*SourceIsins{
+		{
+			Source:  "source1",
+			Workers: 1,
+			Isins:   []string{"isin1", "isin3"},
+		},
+		{
+			Source:  "source2",
+			Workers: 1,
+			Isins:   []string{"isin1", "isin2"},
+		},
+	}
+
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	res, err := GetHistory(context.Background(), availableSources, sis, nil, from, to)
+	if !assert.NoError(t, err) || !assert.Len(t, res, 5) {
+		return
+	}
+
+	// isin1 from source2
+	for j := 0; j < 3; j++ {
+		assert.Equal(t, "isin1", res[j].Isin)
+		assert.Equal(t, "source2", res[j].Source)
+		assert.Equal(t, taskengine.EventSuccess, res[j].Status)
+		assert.Equal(t, from.AddDate(0, 0, j), *res[j].Date)
+	}
+
+	// isin2: error of source2
+	assert.Equal(t, "isin2", res[3].Isin)
+	assert.Contains(t, res[3].Err.Error(), "not implemented")
+
+	// isin3: no source with history
+	assert.Equal(t, "isin3", res[4].Isin)
+	assert.Contains(t, res[4].Err.Error(), "does not support history")
+
+	// invalid interval
+	_, err = GetHistory(context.Background(), availableSources, sis, nil, to, from)
+	assert.Error(t, err)
+}
+
+func TestGetHistoryOrder(t *testing.T) {
+	availableSources := quotegetter.Sources{
+		"source2": newDummyHistoryGetter,
+		"source3": newDummyHistoryGetter,
+	}
+	sis := []*SourceIsins{
+		{Source: "source2", Workers: 1, Isins: []string{"isin1", "isin10"}},
+		{Source: "source3", Workers: 1, Isins: []string{"isin1", "isin10"}, URLs: map[string]string{"isin1": "known", "isin10": "invalid"}},
+	}
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	source := func(res []*Result, isin string) string {
+		for _, r := range res {
+			if r.Isin == isin {
+				return r.Source + " " + r.URL
+			}
+		}
+		return ""
+	}
+
+	// alphabetical order
+	res, err := GetHistory(context.Background(), availableSources, sis, nil, from, from)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "source2 search", source(res, "isin1"))
+	}
+
+	// order of preference of the isins, with the known url
+	order := map[string][]string{"isin1": {"source3", "source2"}, "isin10": {"source3"}}
+	res, err = GetHistory(context.Background(), availableSources, sis, order, from, from)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "source3 known", source(res, "isin1"))
+		// the invalid url is retried with the search step
+		assert.Equal(t, "source3 search", source(res, "isin10"))
+	}
+}
+
+func TestGetHistoryWorkers(t *testing.T) {
+	availableSources := quotegetter.Sources{
+		"source2": newDummyHistoryGetter,
+	}
+	isins := []string{"isin10", "isin11", "isin12", "isin13", "isin14", "isin15"}
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, workers := range []int32{1, 3} {
+		atomic.StoreInt32(&maxRunning, 0)
+		sis := []*SourceIsins{{Source: "source2", Workers: int(workers), Isins: isins}}
+		res, err := GetHistory(context.Background(), availableSources, sis, nil, from, from)
+		if assert.NoError(t, err) && assert.Len(t, res, len(isins)) {
+			for j, r := range res {
+				assert.Equal(t, isins[j], r.Isin)
+			}
+		}
+		assert.Equal(t, workers, atomic.LoadInt32(&maxRunning), "workers %d", workers)
+	}
+}
+
+func TestGetHistoryCanceled(t *testing.T) {
+	availableSources := quotegetter.Sources{
+		"source2": newDummyHistoryGetter,
+	}
+	sis := []*SourceIsins{
+		{
+			Source:  "source2",
+			Workers: 1,
+			Isins:   []string{"isin1", "isin2"},
+		},
+	}
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := GetHistory(ctx, availableSources, sis, nil, from, from)
+	if assert.NoError(t, err) {
+		assert.Empty(t, res)
+	}
+}
+
+func TestSortSources(t *testing.T) {
+	sources := []string{"s3", "s1", "s4", "s2"}
+	assert.Equal(t, []string{"s1", "s2", "s3", "s4"}, sortSources(sources, nil))
+	assert.Equal(t, []string{"s4", "s2", "s1", "s3"}, sortSources(sources, []string{"s4", "s9", "s2"}))
+	assert.Equal(t, []string{"s3", "s1", "s4", "s2"}, sources)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/mmbros/quotes/internal/progress"
@@ -12,30 +12,60 @@ import (
 	"github.com/mmbros/taskengine"
 )
 
-// SourceIsins struct represents the isins to get from a specific source
+// SourceIsins struct represents the isins to get from a specific source.
+// Retry is the retry policy of the http requests of the source (nil for no retry).
+// RateLimit is the rate limit of the http requests of the source, while
+// ProxyRateLimit is the rate limit shared by all the sources using the same proxy host.
+// Timeout is the timeout of each http request of the source
+// (quotegetter.DefaultTimeout if not defined).
+// Cache is the on-disk cache of the http responses of the source (nil for no cache).
+// URLs is the known url of the info page of the isins (es. the url of the
+// last success): the search step is skipped if the info page is still valid.
+// MaxAges is the max age of the date of the quotes of the isins:
+// an older quote is an error of type scrapers.StaleQuoteError.
 type SourceIsins struct {
-	Source  string   `json:"source,omitempty"`
-	Workers int      `json:"workers,omitempty"`
-	Proxy   string   `json:"proxy,omitempty"`
-	Isins   []string `json:"isins,omitempty"`
+	Source         string                   `json:"source,omitempty"`
+	Workers        int                      `json:"workers,omitempty"`
+	Proxy          string                   `json:"proxy,omitempty"`
+	Timeout        time.Duration            `json:"timeout,omitempty"`
+	Retry          *quotegetter.RetryPolicy `json:"retry,omitempty"`
+	RateLimit      *quotegetter.RateLimit   `json:"rate_limit,omitempty"`
+	ProxyRateLimit *quotegetter.RateLimit   `json:"proxy_rate_limit,omitempty"`
+	Cache          *quotegetter.Cache       `json:"cache,omitempty"`
+	Isins          []string                 `json:"isins,omitempty"`
+	URLs           map[string]string        `json:"urls,omitempty"`
+	MaxAges        map[string]*MaxAge       `json:"max_ages,omitempty"`
 }
 
 // Result contains the result informations of the retrieved quote.
 //
 // Result.Date field is a pointer in order to omit zero dates.
 // see https://stackoverflow.com/questions/32643815/json-omitempty-with-time-time-field
+//
+// Result.Attempts is the number of http requests made to get the quote,
+// retries included.
+//
+// Result.Consensus and Result.Best are set by the Consensus function,
+// Result.ConvertedPrice and Result.ConvertedCurrency by the ConvertPrices function.
 type Result struct {
 	Isin      string               `json:"isin,omitempty"`
 	Source    string               `json:"source,omitempty"`
 	Instance  int                  `json:"instance"`
 	URL       string               `json:"url,omitempty"`
-	Price     float32              `json:"price,omitempty"`
+	Price     float64              `json:"price,omitempty"`
+	PriceRaw  string               `json:"price_raw,omitempty"`
 	Currency  string               `json:"currency,omitempty"`
 	Date      *time.Time           `json:"date,omitempty"` // need a pointer to omit zero date
 	TimeStart time.Time            `json:"time_start"`
 	TimeEnd   time.Time            `json:"time_end"`
 	Err       error                `json:"error,omitempty"`
 	Status    taskengine.EventType `json:"status"`
+	Attempts  int                  `json:"attempts,omitempty"`
+	Consensus string               `json:"consensus,omitempty"`
+	Best      bool                 `json:"best,omitempty"`
+
+	ConvertedPrice    float64 `json:"converted_price,omitempty"`
+	ConvertedCurrency string  `json:"converted_currency,omitempty"`
 }
 
 // workerTask struct contains the info for retrieve the quote by a source.
@@ -51,9 +81,21 @@ type workerTask struct {
 // quotegetter.GetQuote methods to implements the taskengine.Result interface.
 type workerResult struct {
 	*quotegetter.Result
-	Err error
+	Err      error
+	Attempts int
+}
+
+// canceledError is the error of a task interrupted by the end of the context
+// (es. run deadline exceeded or SIGINT).
+// It is handled as a canceled task, regardless of the original error.
+type canceledError struct {
+	err error
 }
 
+func (e *canceledError) Error() string        { return e.err.Error() }
+func (e *canceledError) Unwrap() error        { return e.err }
+func (e *canceledError) Is(target error) bool { return target == context.Canceled }
+
 // TaskID method of the taskengine.Task interface
 func (t *workerTask) TaskID() taskengine.TaskID {
 	return taskengine.TaskID(t.isin)
@@ -76,14 +118,17 @@ func (r *workerResult) Error() error {
 
 // Get retrieves the quotes specified by the SourceIsins object.
 // The mode parameters specified the taskengine mode of execution.
-func Get(availableSources quotegetter.Sources, items []*SourceIsins, mode taskengine.Mode, wProgress io.Writer) ([]*Result, error) {
+//
+// If the context is done (es. canceled or deadline exceeded),
+// the pending tasks are canceled and the results collected so far are returned.
+func Get(ctx context.Context, availableSources quotegetter.Sources, items []*SourceIsins, mode taskengine.Mode, wProgress io.Writer) ([]*Result, error) {
 
 	// saveResult return true if the event is a result that have to be saved
 	// according to the taskengine.Mode argument.
 	saveResult := taskengine.FilterEventFunc(mode)
 
 	// Init the chan that will receive the events (containing the results).
-	eventc, err := getEventsChan(availableSources, items)
+	eventc, err := getEventsChan(ctx, availableSources, items)
 	if err != nil {
 		return nil, err
 	}
@@ -125,10 +170,14 @@ func Get(availableSources quotegetter.Sources, items []*SourceIsins, mode tasken
 				TimeEnd:   event.TimeEnd,
 				Status:    etype,
 			}
+			if wres, ok := event.Result.(*workerResult); ok {
+				result.Attempts = wres.Attempts
+			}
 
 			if etype == taskengine.EventSuccess {
 				wres := event.Result.(*workerResult)
 				result.Price = wres.Price
+				result.PriceRaw = wres.PriceRaw
 				result.Currency = wres.Currency
 				result.URL = wres.URL
 				result.Date = &wres.Date
@@ -150,23 +199,42 @@ func Get(availableSources quotegetter.Sources, items []*SourceIsins, mode tasken
 }
 
 // initQuoteGetters initializes the quotegetter func of each source.
+// Each source has its own http.Client, with the proxy, the retry policy,
+// the rate limit and the cache of the source.
+// The sources using the same proxy host share the same proxy limiter.
 func initQuoteGetters(availableSources quotegetter.Sources, src []*SourceIsins) (map[string]quotegetter.QuoteGetter, error) {
 	quoteGetter := make(map[string]quotegetter.QuoteGetter)
 
-	// map that returns the http.Client of each different proxy
-	proxyClient := map[string]*http.Client{}
+	// map that returns the limiter of each proxy host
+	proxyLimiter := map[string]*quotegetter.Limiter{}
 
 	for _, s := range src {
 
-		// Get the client corrisponding to the proxy.
-		// Create a new proxy client if needed.
-		client, ok := proxyClient[s.Proxy]
-		if !ok {
-			client, err := quotegetter.DefaultClient(s.Proxy)
+		limiters := []*quotegetter.Limiter{quotegetter.NewLimiter(s.RateLimit)}
+
+		if s.Proxy != "" && s.ProxyRateLimit != nil {
+			u, err := url.Parse(s.Proxy)
 			if err != nil {
 				return nil, err
 			}
-			proxyClient[s.Proxy] = client
+			l, ok := proxyLimiter[u.Host]
+			if !ok {
+				l = quotegetter.NewLimiter(s.ProxyRateLimit)
+				proxyLimiter[u.Host] = l
+			}
+			limiters = append(limiters, l)
+		}
+
+		cc := &quotegetter.ClientConfig{
+			Proxy:    s.Proxy,
+			Timeout:  s.Timeout,
+			Retry:    s.Retry,
+			Limiters: limiters,
+			Cache:    s.Cache,
+		}
+		client, err := cc.NewClient()
+		if err != nil {
+			return nil, err
 		}
 
 		// Build the quotegetter func of the source.
@@ -186,7 +254,7 @@ func initQuoteGetters(availableSources quotegetter.Sources, src []*SourceIsins)
 // the sources as workers and isins an tasks.
 // It returns the event chan that will receive the start / completed events
 // of each execution of quote retrival.
-func getEventsChan(availableSources quotegetter.Sources, items []*SourceIsins) (chan *taskengine.Event, error) {
+func getEventsChan(ctx context.Context, availableSources quotegetter.Sources, items []*SourceIsins) (chan *taskengine.Event, error) {
 
 	// check input
 	if err := checkListOfSourceIsins(availableSources, items); err != nil {
@@ -207,13 +275,27 @@ func getEventsChan(availableSources quotegetter.Sources, items []*SourceIsins) (
 	for _, item := range items {
 
 		qg := quoteGetter[item.Source]
+		source, maxAges := item.Source, item.MaxAges
 
 		// work function of the source
 		wfn := func(ctx context.Context, worker *taskengine.Worker, inst int, task taskengine.Task) taskengine.Result {
 			//  from taskengine.Task to taskGetQuote
 			t := task.(*workerTask)
+			ctx, attempts := quotegetter.WithAttemptsCounter(ctx)
 			r, err := qg.GetQuote(ctx, t.isin, t.url)
-			return &workerResult{r, err}
+			if err != nil && t.url != "" && ctx.Err() == nil {
+				// the known info url is no longer valid:
+				// retry with the search step
+				r, err = qg.GetQuote(ctx, t.isin, "")
+			}
+			if err != nil && ctx.Err() != nil {
+				err = &canceledError{err}
+			}
+			if err == nil {
+				// a stale quote is an error, so that the other sources are tried
+				err = checkStale(source, t.isin, r, maxAges[t.isin], time.Now())
+			}
+			return &workerResult{r, err, attempts()}
 		}
 
 		// worker
@@ -229,7 +311,7 @@ func getEventsChan(availableSources quotegetter.Sources, items []*SourceIsins) (
 		for _, isin := range item.Isins {
 			ts = append(ts, &workerTask{
 				isin: isin,
-				url:  "",
+				url:  item.URLs[isin],
 			})
 		}
 		wts[w.WorkerID] = ts
@@ -240,7 +322,7 @@ func getEventsChan(availableSources quotegetter.Sources, items []*SourceIsins) (
 	if err != nil {
 		return nil, err
 	}
-	return eng.ExecuteEvents(context.Background())
+	return eng.ExecuteEvents(ctx)
 }
 
 // checkListOfSourceIsins checks the validity of the given SourceIsins items.
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -165,10 +167,198 @@ func TestGetResults(t *testing.T) {
 			Isins:   []string{"isin1", "isin2"},
 		},
 	}
-	res, err := Get(availableSources, sis, taskengine.AllResults, nil)
+	res, err := Get(context.Background(), availableSources, sis, taskengine.AllResults, nil)
 	if assert.NoError(t, err) {
 		assert.Equal(t, 3, len(res))
 		// t.Fatalf("res %v", jsonString(res))
 	}
 
 }
+
+// httpQuoteGetter gets the quote from the url of the test server
+type httpQuoteGetter struct {
+	dummyQuoteGetter
+	url string
+}
+
+func (qg *httpQuoteGetter) GetQuote(ctx context.Context, isin, url string) (*quotegetter.Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, qg.url+"?isin="+isin, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := quotegetter.DoHTTPRequest(qg.client, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return &quotegetter.Result{Date: time.Now(), Currency: "EUR", Price: 1}, nil
+}
+
+func TestGetAttempts(t *testing.T) {
+	// the first request of each isin fails with 503
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isin := r.URL.Query().Get("isin")
+		mu.Lock()
+		defer mu.Unlock()
+		if !seen[isin] {
+			seen[isin] = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	newGetter := func(source string, client *http.Client) quotegetter.QuoteGetter {
+		return &httpQuoteGetter{dummyQuoteGetter{source, client}, server.URL}
+	}
+	availableSources := quotegetter.Sources{
+		"retry":   newGetter,
+		"noretry": newGetter,
+	}
+	sis := []*SourceIsins{
+		{
+			Source:  "retry",
+			Workers: 1,
+			Retry:   &quotegetter.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+			Isins:   []string{"isin1"},
+		},
+		{
+			Source:  "noretry",
+			Workers: 1,
+			Isins:   []string{"isin2"},
+		},
+	}
+	res, err := Get(context.Background(), availableSources, sis, taskengine.AllResults, nil)
+	if assert.NoError(t, err) && assert.Len(t, res, 2) {
+		for _, r := range res {
+			switch r.Source {
+			case "retry":
+				assert.Equal(t, taskengine.EventSuccess, r.Status)
+				assert.Equal(t, 2, r.Attempts)
+			case "noretry":
+				assert.Equal(t, taskengine.EventError, r.Status)
+				assert.Equal(t, 1, r.Attempts)
+			}
+		}
+	}
+}
+
+func TestGetRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	newGetter := func(source string, client *http.Client) quotegetter.QuoteGetter {
+		return &httpQuoteGetter{dummyQuoteGetter{source, client}, server.URL}
+	}
+	availableSources := quotegetter.Sources{"source1": newGetter}
+
+	sis := []*SourceIsins{
+		{
+			Source:    "source1",
+			Workers:   3,
+			RateLimit: &quotegetter.RateLimit{MinDelay: 30 * time.Millisecond},
+			Isins:     []string{"isin1", "isin2", "isin3"},
+		},
+	}
+	start := time.Now()
+	res, err := Get(context.Background(), availableSources, sis, taskengine.AllResults, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, res, 3)
+		// workers run concurrently, but the requests are delayed by the limiter
+		assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+	}
+}
+
+// searchQuoteGetter is a quote getter with a search step:
+// the info url of each isin is "/info/<isin>", while any other url fails.
+type searchQuoteGetter struct {
+	dummyQuoteGetter
+	mu       sync.Mutex
+	searches int
+}
+
+func (qg *searchQuoteGetter) GetQuote(ctx context.Context, isin, url string) (*quotegetter.Result, error) {
+	if url == "" {
+		qg.mu.Lock()
+		qg.searches++
+		qg.mu.Unlock()
+		url = "/info/" + isin
+	}
+	if url != "/info/"+isin {
+		return nil, fmt.Errorf("invalid info url %q", url)
+	}
+	return &quotegetter.Result{URL: url, Date: time.Now(), Currency: "EUR", Price: 1}, nil
+}
+
+func TestGetURLs(t *testing.T) {
+	qg := &searchQuoteGetter{}
+	availableSources := quotegetter.Sources{
+		"source1": func(source string, client *http.Client) quotegetter.QuoteGetter {
+			qg.source, qg.client = source, client
+			return qg
+		},
+	}
+	sis := []*SourceIsins{
+		{
+			Source:  "source1",
+			Workers: 1,
+			Isins:   []string{"isin1", "isin2", "isin3"},
+			URLs: map[string]string{
+				"isin1": "/info/isin1", // valid
+				"isin2": "/info/old",   // no longer valid
+			},
+		},
+	}
+	res, err := Get(context.Background(), availableSources, sis, taskengine.AllResults, nil)
+	if assert.NoError(t, err) && assert.Len(t, res, 3) {
+		for _, r := range res {
+			assert.Equal(t, taskengine.EventSuccess, r.Status, r.Isin)
+			assert.Equal(t, "/info/"+r.Isin, r.URL, r.Isin)
+		}
+	}
+	// only isin2 (fallback) and isin3 (no url) made the search
+	assert.Equal(t, 2, qg.searches)
+}
+
+func TestGetCanceled(t *testing.T) {
+	// the isins other than "fast" wait until the request is canceled
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("isin") != "fast" {
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	newGetter := func(source string, client *http.Client) quotegetter.QuoteGetter {
+		return &httpQuoteGetter{dummyQuoteGetter{source, client}, server.URL}
+	}
+	availableSources := quotegetter.Sources{"source1": newGetter}
+
+	sis := []*SourceIsins{
+		{
+			Source:  "source1",
+			Workers: 1,
+			Isins:   []string{"fast", "slow1", "slow2"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	res, err := Get(ctx, availableSources, sis, taskengine.AllResults, nil)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "elapsed")
+
+	// the partial results are returned
+	if assert.NoError(t, err) && assert.Len(t, res, 3) {
+		for _, r := range res {
+			if r.Isin == "fast" {
+				assert.Equal(t, taskengine.EventSuccess, r.Status, r.Isin)
+				continue
+			}
+			assert.Equal(t, taskengine.EventCanceled, r.Status, r.Isin)
+			assert.ErrorIs(t, r.Err, context.Canceled, r.Isin)
+		}
+	}
+}
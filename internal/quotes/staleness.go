@@ -0,0 +1,74 @@
+package quotes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+)
+
+// MaxAge is the max age of the date of a quote, in days.
+// If BusinessDays is true, only the days from monday to friday are counted.
+type MaxAge struct {
+	Days         int  `json:"days"`
+	BusinessDays bool `json:"business_days,omitempty"`
+}
+
+// String returns the representation of the max age, es. "3 business days".
+func (m *MaxAge) String() string {
+	unit := "days"
+	if m.BusinessDays {
+		unit = "business days"
+	}
+	return fmt.Sprintf("%d %s", m.Days, unit)
+}
+
+// Age returns the age of the date at the time now, in days:
+// the number of days, or business days, after the date up to the day of now.
+// The days are calendar days, each one in its own location.
+// A date after now has a negative age.
+func (m *MaxAge) Age(date, now time.Time) int {
+	// the calendar days, as UTC midnights, to avoid the daylight saving time issues
+	day := func(t time.Time) time.Time {
+		y, mo, d := t.Date()
+		return time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)
+	}
+	from, to := day(date), day(now)
+
+	if !m.BusinessDays {
+		return int(to.Sub(from).Hours() / 24)
+	}
+
+	sign := 1
+	if to.Before(from) {
+		from, to = to, from
+		sign = -1
+	}
+	age := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			age++
+		}
+	}
+	return sign * age
+}
+
+// IsStale checks if the date is older than the max age at the time now.
+// A nil max age, or a zero date, is never stale.
+func (m *MaxAge) IsStale(date, now time.Time) bool {
+	if m == nil || date.IsZero() {
+		return false
+	}
+	return m.Age(date, now) > m.Days
+}
+
+// checkStale returns a scrapers.Error of type scrapers.StaleQuoteError
+// if the date of the quote is older than the max age, nil otherwise.
+func checkStale(source, isin string, r *quotegetter.Result, maxAge *MaxAge, now time.Time) error {
+	if r == nil || !maxAge.IsStale(r.Date, now) {
+		return nil
+	}
+	err := fmt.Errorf("%w: date %s is older than %s", scrapers.ErrStaleQuote, r.Date.Format("2006-01-02"), maxAge)
+	return scrapers.NewError(source, isin, r.URL, scrapers.StaleQuoteError, err, nil)
+}
@@ -0,0 +1,94 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetter"
+	"github.com/mmbros/quotes/internal/quotegetter/scrapers"
+	"github.com/mmbros/taskengine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxAge(t *testing.T) {
+	// friday 16 october 2026
+	friday := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	day := func(days int) time.Time { return friday.AddDate(0, 0, days) }
+
+	tests := []struct {
+		name   string
+		maxAge *MaxAge
+		date   time.Time
+		now    time.Time
+		age    int
+		stale  bool
+	}{
+		{"same day", &MaxAge{Days: 0}, friday, friday, 0, false},
+		{"days", &MaxAge{Days: 2}, day(-3), friday, 3, true},
+		{"days not stale", &MaxAge{Days: 3}, day(-3), friday, 3, false},
+		{"future", &MaxAge{Days: 0}, day(1), friday, -1, false},
+		{"weekend", &MaxAge{Days: 1, BusinessDays: true}, friday, day(3), 1, false},
+		{"weekend calendar", &MaxAge{Days: 1}, friday, day(3), 3, true},
+		{"business days", &MaxAge{Days: 1, BusinessDays: true}, friday, day(4), 2, true},
+		{"business future", &MaxAge{Days: 0, BusinessDays: true}, day(3), friday, -1, false},
+		{"zero date", &MaxAge{Days: 0}, time.Time{}, friday, 0, false},
+		{"nil", nil, day(-100), friday, 0, false},
+	}
+	for _, tt := range tests {
+		if tt.maxAge != nil && !tt.date.IsZero() {
+			if age := tt.maxAge.Age(tt.date, tt.now); age != tt.age {
+				t.Errorf("%s: age: got %d, expected %d", tt.name, age, tt.age)
+			}
+		}
+		if stale := tt.maxAge.IsStale(tt.date, tt.now); stale != tt.stale {
+			t.Errorf("%s: stale: got %v, expected %v", tt.name, stale, tt.stale)
+		}
+	}
+}
+
+// datedQuoteGetter returns the quotes with the date of days ago.
+type datedQuoteGetter struct {
+	dummyQuoteGetter
+	days int
+}
+
+func (qg *datedQuoteGetter) GetQuote(ctx context.Context, isin, url string) (*quotegetter.Result, error) {
+	return &quotegetter.Result{Date: time.Now().AddDate(0, 0, -qg.days), Currency: "EUR", Price: 1}, nil
+}
+
+func TestGetStale(t *testing.T) {
+	newGetter := func(days int) quotegetter.NewQuoteGetterFunc {
+		return func(source string, client *http.Client) quotegetter.QuoteGetter {
+			return &datedQuoteGetter{dummyQuoteGetter{source, client}, days}
+		}
+	}
+	availableSources := quotegetter.Sources{
+		"old":    newGetter(30),
+		"recent": newGetter(0),
+	}
+	maxAges := map[string]*MaxAge{"isin1": {Days: 5}}
+	sis := []*SourceIsins{
+		{Source: "old", Workers: 1, Isins: []string{"isin1"}, MaxAges: maxAges},
+		{Source: "recent", Workers: 1, Isins: []string{"isin1"}, MaxAges: maxAges},
+	}
+
+	res, err := Get(context.Background(), availableSources, sis, taskengine.AllResults, nil)
+	if assert.NoError(t, err) && assert.Len(t, res, 2) {
+		for _, r := range res {
+			switch r.Source {
+			case "old":
+				assert.Equal(t, taskengine.EventError, r.Status)
+				var serr *scrapers.Error
+				if assert.True(t, errors.As(r.Err, &serr)) {
+					assert.Equal(t, scrapers.StaleQuoteError, serr.Type())
+				}
+				assert.ErrorIs(t, r.Err, scrapers.ErrStaleQuote)
+			case "recent":
+				assert.Equal(t, taskengine.EventSuccess, r.Status)
+			}
+		}
+	}
+}
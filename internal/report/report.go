@@ -0,0 +1,280 @@
+// Package report computes the reliability of the sources
+// from the quotes saved in the database.
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+)
+
+// Stat are the statistics of the requests of a source,
+// or of an isin and a source.
+type Stat struct {
+	Requests  int
+	Success   int
+	latencies []time.Duration
+	errtypes  map[string]int
+}
+
+func (s *Stat) add(r *quotegetterdb.QuoteRecord) {
+	s.Requests++
+	if r.ErrMsg == "" {
+		s.Success++
+	} else {
+		errtype := r.ErrType
+		if errtype == "" {
+			errtype = "unknown"
+		}
+		if s.errtypes == nil {
+			s.errtypes = map[string]int{}
+		}
+		s.errtypes[errtype]++
+	}
+	// the timings are saved since schema version 4
+	if !r.TimeStart.IsZero() && !r.TimeEnd.IsZero() {
+		s.latencies = append(s.latencies, r.TimeEnd.Sub(r.TimeStart))
+	}
+}
+
+// SuccessRate returns the ratio of the successful requests.
+func (s *Stat) SuccessRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Success) / float64(s.Requests)
+}
+
+// Latency returns the p-th percentile (0 < p <= 100) of the latency
+// of the requests, with the nearest-rank method.
+// It returns false if the latencies are not known.
+func (s *Stat) Latency(p float64) (time.Duration, bool) {
+	n := len(s.latencies)
+	if n == 0 {
+		return 0, false
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(float64(n)*p/100)) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= n {
+		rank = n - 1
+	}
+	return sorted[rank], true
+}
+
+// TopErrType returns the most common error type and its count.
+// In case of ties, the first in alphabetical order is returned.
+func (s *Stat) TopErrType() (string, int) {
+	var (
+		top   string
+		count int
+	)
+	for errtype, n := range s.errtypes {
+		if n > count || (n == count && errtype < top) {
+			top, count = errtype, n
+		}
+	}
+	return top, count
+}
+
+// SourceStat are the statistics of a source.
+type SourceStat struct {
+	Source string
+	Stat
+	Isins        int // isins requested to the source
+	IsinsSuccess int // isins with at least a successful request
+}
+
+// IsinStat are the statistics of the sources of an isin.
+type IsinStat struct {
+	Isin    string
+	Sources map[string]*Stat
+}
+
+// Report is the reliability report of the sources.
+type Report struct {
+	Sources []*SourceStat // ordered by source
+	Isins   []*IsinStat   // ordered by isin
+}
+
+// New returns the report of the quotes.
+func New(records []*quotegetterdb.QuoteRecord) *Report {
+	sources := map[string]*SourceStat{}
+	isins := map[string]*IsinStat{}
+
+	for _, r := range records {
+		ss := sources[r.Source]
+		if ss == nil {
+			ss = &SourceStat{Source: r.Source}
+			sources[r.Source] = ss
+		}
+		ss.add(r)
+
+		is := isins[r.Isin]
+		if is == nil {
+			is = &IsinStat{Isin: r.Isin, Sources: map[string]*Stat{}}
+			isins[r.Isin] = is
+		}
+		st := is.Sources[r.Source]
+		if st == nil {
+			st = &Stat{}
+			is.Sources[r.Source] = st
+		}
+		st.add(r)
+	}
+
+	rep := &Report{}
+	for _, is := range isins {
+		rep.Isins = append(rep.Isins, is)
+		for source, st := range is.Sources {
+			ss := sources[source]
+			ss.Isins++
+			if st.Success > 0 {
+				ss.IsinsSuccess++
+			}
+		}
+	}
+	for _, ss := range sources {
+		rep.Sources = append(rep.Sources, ss)
+	}
+	sort.Slice(rep.Sources, func(i, j int) bool { return rep.Sources[i].Source < rep.Sources[j].Source })
+	sort.Slice(rep.Isins, func(i, j int) bool { return rep.Isins[i].Isin < rep.Isins[j].Isin })
+	return rep
+}
+
+// SuggestedSources returns the sources of the isin ordered by
+// success rate (descending), then by median latency (unknown latencies last).
+func (is *IsinStat) SuggestedSources() []string {
+	type item struct {
+		source string
+		rate   float64
+		median time.Duration
+		known  bool
+	}
+	items := make([]*item, 0, len(is.Sources))
+	for source, st := range is.Sources {
+		median, known := st.Latency(50)
+		items = append(items, &item{source, st.SuccessRate(), median, known})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.rate != b.rate {
+			return a.rate > b.rate
+		}
+		if a.known != b.known {
+			return a.known
+		}
+		if a.median != b.median {
+			return a.median < b.median
+		}
+		return a.source < b.source
+	})
+
+	res := make([]string, len(items))
+	for j, it := range items {
+		res[j] = it.source
+	}
+	return res
+}
+
+func formatRate(st *Stat) string {
+	return strconv.FormatFloat(100*st.SuccessRate(), 'f', 1, 64) + "%"
+}
+
+func formatLatency(st *Stat, p float64) string {
+	d, ok := st.Latency(p)
+	if !ok {
+		return "-"
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+}
+
+// WriteSources writes the table of the statistics of the sources.
+func (rep *Report) WriteSources(w io.Writer) error {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleLight)
+	tw.AppendHeader(table.Row{"SOURCE", "REQUESTS", "SUCCESS", "MEDIAN", "P95", "TOP ERROR", "ISINS"})
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Name: "REQUESTS", Align: text.AlignRight},
+		{Name: "SUCCESS", Align: text.AlignRight},
+		{Name: "MEDIAN", Align: text.AlignRight},
+		{Name: "P95", Align: text.AlignRight},
+		{Name: "ISINS", Align: text.AlignRight},
+	})
+	for _, ss := range rep.Sources {
+		topErr := "-"
+		if errtype, n := ss.TopErrType(); n > 0 {
+			topErr = fmt.Sprintf("%s (%d)", errtype, n)
+		}
+		tw.AppendRow(table.Row{
+			ss.Source,
+			ss.Requests,
+			formatRate(&ss.Stat),
+			formatLatency(&ss.Stat, 50),
+			formatLatency(&ss.Stat, 95),
+			topErr,
+			fmt.Sprintf("%d/%d", ss.IsinsSuccess, ss.Isins),
+		})
+	}
+	_, err := io.WriteString(w, tw.Render()+"\n")
+	return err
+}
+
+// WriteCoverage writes the table of the success rate of each isin and source.
+func (rep *Report) WriteCoverage(w io.Writer) error {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleLight)
+
+	header := table.Row{"ISIN"}
+	configs := []table.ColumnConfig{}
+	for _, ss := range rep.Sources {
+		header = append(header, ss.Source)
+		configs = append(configs, table.ColumnConfig{Name: ss.Source, Align: text.AlignRight})
+	}
+	tw.AppendHeader(header)
+	tw.SetColumnConfigs(configs)
+	// keep the case of the source names
+	tw.Style().Format.Header = text.FormatDefault
+
+	for _, is := range rep.Isins {
+		row := table.Row{is.Isin}
+		for _, ss := range rep.Sources {
+			if st := is.Sources[ss.Source]; st != nil {
+				row = append(row, fmt.Sprintf("%d/%d", st.Success, st.Requests))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		tw.AppendRow(row)
+	}
+	_, err := io.WriteString(w, tw.Render()+"\n")
+	return err
+}
+
+// WriteSuggestion writes the isins section of the config file (yaml format),
+// a map from isin to its params, with the suggested order of the sources of each isin.
+func (rep *Report) WriteSuggestion(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "isins:"); err != nil {
+		return err
+	}
+	for _, is := range rep.Isins {
+		_, err := fmt.Fprintf(w, "  %s:\n    sources: [%s]\n", is.Isin, strings.Join(is.SuggestedSources(), ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
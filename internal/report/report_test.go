@@ -0,0 +1,113 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mmbros/quotes/internal/quotegetterdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecords() []*quotegetterdb.QuoteRecord {
+	t0 := time.Date(2026, time.October, 16, 18, 0, 0, 0, time.UTC)
+	rec := func(isin, source string, ms int, errtype string) *quotegetterdb.QuoteRecord {
+		r := &quotegetterdb.QuoteRecord{
+			Isin:      isin,
+			Source:    source,
+			TimeStart: t0,
+			TimeEnd:   t0.Add(time.Duration(ms) * time.Millisecond),
+		}
+		if errtype != "" {
+			r.ErrMsg = "error"
+			r.ErrType = errtype
+		}
+		return r
+	}
+	return []*quotegetterdb.QuoteRecord{
+		rec("isin1", "source1", 1000, ""),
+		rec("isin1", "source1", 2000, ""),
+		rec("isin1", "source1", 3000, "GetInfoError"),
+		rec("isin1", "source2", 500, ""),
+		rec("isin1", "source2", 700, "ParseInfoError"),
+		rec("isin2", "source1", 4000, "IsinNotFoundError"),
+		rec("isin2", "source1", 5000, "IsinNotFoundError"),
+		rec("isin2", "source2", 600, ""),
+		// without timings and error type
+		{Isin: "isin3", Source: "source2", ErrMsg: "error"},
+	}
+}
+
+func TestNew(t *testing.T) {
+	rep := New(testRecords())
+
+	if assert.Len(t, rep.Sources, 2) {
+		s1, s2 := rep.Sources[0], rep.Sources[1]
+		assert.Equal(t, "source1", s1.Source)
+		assert.Equal(t, 5, s1.Requests)
+		assert.Equal(t, 2, s1.Success)
+		assert.Equal(t, 2, s1.Isins)
+		assert.Equal(t, 1, s1.IsinsSuccess)
+
+		median, ok := s1.Latency(50)
+		assert.True(t, ok)
+		assert.Equal(t, 3*time.Second, median)
+		p95, _ := s1.Latency(95)
+		assert.Equal(t, 5*time.Second, p95)
+
+		errtype, n := s1.TopErrType()
+		assert.Equal(t, "IsinNotFoundError", errtype)
+		assert.Equal(t, 2, n)
+
+		assert.Equal(t, 3, s2.Isins)
+		assert.Equal(t, 2, s2.IsinsSuccess)
+		errtype, n = s2.TopErrType()
+		assert.Equal(t, "ParseInfoError", errtype)
+		assert.Equal(t, 1, n)
+	}
+
+	if assert.Len(t, rep.Isins, 3) {
+		assert.Equal(t, []string{"source1", "source2"}, rep.Isins[0].SuggestedSources())
+		assert.Equal(t, []string{"source2", "source1"}, rep.Isins[1].SuggestedSources())
+		_, ok := rep.Isins[2].Sources["source2"].Latency(50)
+		assert.False(t, ok)
+	}
+}
+
+func TestWriteSuggestion(t *testing.T) {
+	var buf bytes.Buffer
+	if assert.NoError(t, New(testRecords()).WriteSuggestion(&buf)) {
+		assert.Equal(t, `isins:
+  isin1:
+    sources: [source1, source2]
+  isin2:
+    sources: [source2, source1]
+  isin3:
+    sources: [source2]
+`, buf.String())
+	}
+}
+
+func TestWriteTables(t *testing.T) {
+	var buf bytes.Buffer
+	if assert.NoError(t, New(testRecords()).WriteSources(&buf)) {
+		assert.Equal(t, `┌─────────┬──────────┬─────────┬────────┬────────┬───────────────────────┬───────┐
+│ SOURCE  │ REQUESTS │ SUCCESS │ MEDIAN │ P95    │ TOP ERROR             │ ISINS │
+├─────────┼──────────┼─────────┼────────┼────────┼───────────────────────┼───────┤
+│ source1 │        5 │   40.0% │ 3.000s │ 5.000s │ IsinNotFoundError (2) │   1/2 │
+│ source2 │        4 │   50.0% │ 0.600s │ 0.700s │ ParseInfoError (1)    │   2/3 │
+└─────────┴──────────┴─────────┴────────┴────────┴───────────────────────┴───────┘
+`, buf.String())
+	}
+	buf.Reset()
+	if assert.NoError(t, New(testRecords()).WriteCoverage(&buf)) {
+		assert.Equal(t, `┌───────┬─────────┬─────────┐
+│ ISIN  │ source1 │ source2 │
+├───────┼─────────┼─────────┤
+│ isin1 │     2/3 │     1/2 │
+│ isin2 │     0/2 │     1/1 │
+│ isin3 │       - │     0/1 │
+└───────┴─────────┴─────────┘
+`, buf.String())
+	}
+}
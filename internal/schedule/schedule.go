@@ -0,0 +1,264 @@
+// Package schedule parses the cron expressions and computes
+// their activation times in a time zone.
+//
+// A cron expression has five fields separated by spaces:
+//
+//	minute        0-59
+//	hour          0-23
+//	day of month  1-31
+//	month         1-12 or jan-dec
+//	day of week   0-7 or sun-sat (0 and 7 are sunday)
+//
+// Each field is a comma separated list of values, ranges (1-5) and steps
+// (*/15, 0-30/10 or 10/5, from 10 to the max value); "*" is any value.
+// As in cron, if both the day of month and the day of week are restricted
+// (i.e. not "*"), the time matches if either field matches.
+//
+// The predefined schedules @yearly (@annually), @monthly, @weekly, @daily
+// (@midnight) and @hourly are also accepted.
+package schedule
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr string
+	loc  *time.Location
+
+	minute, hour, dom, month, dow uint64 // bit sets of the allowed values
+
+	domStar, dowStar bool
+}
+
+// field is the definition of a field of the cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names of the values, starting from min
+}
+
+var fields = [...]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var predefined = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses the cron expression. The activation times are computed
+// in the location loc; if nil, the local time zone is used.
+func Parse(expr string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if spec, ok = predefined[strings.ToLower(spec)]; !ok {
+			return nil, fmt.Errorf("invalid cron expression %q: unknown predefined schedule", expr)
+		}
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields, found %d", expr, len(fields), len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+	for j, part := range parts {
+		set, err := fields[j].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		sets[j] = set
+	}
+
+	s := &Schedule{
+		expr:    expr,
+		loc:     loc,
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}
+	// 7 is sunday, as 0
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// value parses a single value of the field, as a number or a name.
+func (f *field) value(s string) (int, error) {
+	for j, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + j, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q: accepted values are %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// parse returns the bit set of the values of the comma separated list.
+func (f *field) parse(list string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(list, ",") {
+		rng, step := item, 1
+		if idx := strings.IndexByte(item, '/'); idx >= 0 {
+			rng = item[:idx]
+			var err error
+			if step, err = strconv.Atoi(item[idx+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, item[idx+1:])
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rng == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rng, "-"):
+			idx := strings.IndexByte(rng, '-')
+			var err error
+			if lo, err = f.value(rng[:idx]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(rng[idx+1:]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				// 10/5: from 10 to the max value
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// String returns the cron expression of the schedule.
+func (s *Schedule) String() string { return s.expr }
+
+// Location returns the time zone of the schedule.
+func (s *Schedule) Location() *time.Location { return s.loc }
+
+func has(set uint64, v int) bool { return set&(1<<uint(v)) != 0 }
+
+// matchDay checks the day of month and the day of week of t.
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// maxYears is the max number of years searched by Next.
+const maxYears = 5
+
+// dstShifts are the shifts of the clocks of the daylight saving changes.
+var dstShifts = [...]time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour}
+
+// firstOccurrence returns the first occurrence of the wall clock time of t,
+// if the time is repeated by a daylight saving change.
+// Otherwise, it returns t and false.
+func firstOccurrence(t time.Time) (time.Time, bool) {
+	y, m, d := t.Date()
+	h, min, _ := t.Clock()
+	for _, shift := range dstShifts {
+		e := t.Add(-shift)
+		ey, em, ed := e.Date()
+		eh, emin, _ := e.Clock()
+		if ey == y && em == m && ed == d && eh == h && emin == min {
+			return e, true
+		}
+	}
+	return t, false
+}
+
+// Next returns the first activation time of the schedule after t,
+// in the time zone of the schedule. It returns the zero time if no
+// activation time is found in the next years (es. "0 0 30 2 *").
+//
+// The times skipped by a daylight saving change are not activated,
+// and the times repeated by the change are activated once,
+// at their first occurrence.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	start := t
+	end := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(end) {
+		// a repeated time is checked at its first occurrence:
+		// if the first occurrence is before the start, the time is skipped
+		if e, ok := firstOccurrence(t); ok {
+			if e.Before(start) {
+				t = t.Add(time.Minute)
+				continue
+			}
+			t = e
+		}
+
+		y, m, d := t.Date()
+		if !has(s.month, int(m)) {
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, s.loc)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			// the next allowed minute of the hour, if any
+			next := s.minute >> uint(t.Minute()+1) << uint(t.Minute()+1)
+			if next == 0 {
+				t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, s.loc)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(next)-t.Minute()) * time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"30 18 * * 1-5",
+		"0,15,30,45 9-17 * * mon-fri",
+		"*/10 * 1,15 jan-jun/2 *",
+		"5/20 0 * * 7",
+		"@daily",
+		"@Hourly",
+	} {
+		_, err := Parse(expr, time.UTC)
+		assert.NoError(t, err, expr)
+	}
+
+	for expr, msg := range map[string]string{
+		"":              "expected 5 fields, found 0",
+		"* * * *":       "expected 5 fields, found 4",
+		"60 * * * *":    `invalid minute "60": accepted values are 0-59`,
+		"* 24 * * *":    `invalid hour "24"`,
+		"* * 0 * *":     `invalid day of month "0"`,
+		"* * * dic *":   `invalid month "dic"`,
+		"* * * * 8":     `invalid day of week "8"`,
+		"*/0 * * * *":   `invalid minute step "0"`,
+		"* 18-9 * * *":  `invalid hour range "18-9"`,
+		"@every 1h":     "unknown predefined schedule",
+		"30 18 * * x-5": `invalid day of week "x"`,
+	} {
+		_, err := Parse(expr, time.UTC)
+		if assert.Error(t, err, expr) {
+			assert.Contains(t, err.Error(), msg, expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	require.NoError(t, err)
+
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02 15:04", s, rome)
+		require.NoError(t, err)
+		return d
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want []string
+	}{
+		{
+			// weekdays at 18:30: friday, then monday
+			expr: "30 18 * * 1-5",
+			from: "2026-10-16 18:30",
+			want: []string{"2026-10-19 18:30", "2026-10-20 18:30"},
+		},
+		{
+			expr: "30 18 * * mon-fri",
+			from: "2026-10-16 12:00",
+			want: []string{"2026-10-16 18:30", "2026-10-19 18:30"},
+		},
+		{
+			expr: "*/20 9-10 * * *",
+			from: "2026-10-16 10:30",
+			want: []string{"2026-10-16 10:40", "2026-10-17 09:00", "2026-10-17 09:20"},
+		},
+		{
+			expr: "0 0 1 */6 *",
+			from: "2026-10-16 00:00",
+			want: []string{"2027-01-01 00:00", "2027-07-01 00:00"},
+		},
+		{
+			// either the day of month or the day of week
+			expr: "0 12 13 * 5",
+			from: "2026-11-09 00:00",
+			want: []string{"2026-11-13 12:00", "2026-11-20 12:00", "2026-11-27 12:00", "2026-12-04 12:00"},
+		},
+		{
+			// sunday as 7
+			expr: "0 8 * * 7",
+			from: "2026-10-16 00:00",
+			want: []string{"2026-10-18 08:00"},
+		},
+		{
+			expr: "0 0 29 2 *",
+			from: "2026-10-16 00:00",
+			want: []string{"2028-02-29 00:00"},
+		},
+		{
+			// 2:30 does not exist on 2026-03-29
+			expr: "30 2 * * *",
+			from: "2026-03-28 12:00",
+			want: []string{"2026-03-30 02:30"},
+		},
+		{
+			// 2:30 is repeated on 2026-10-25
+			expr: "30 2 * * *",
+			from: "2026-10-25 00:00",
+			want: []string{"2026-10-25 02:30", "2026-10-26 02:30"},
+		},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr, rome)
+		require.NoError(t, err, tt.expr)
+
+		got := date(tt.from)
+		for _, w := range tt.want {
+			got = s.Next(got)
+			assert.Equal(t, w, got.Format("2006-01-02 15:04"), tt.expr)
+			assert.Equal(t, rome, got.Location(), tt.expr)
+		}
+	}
+
+	// the times repeated by the daylight saving change are activated once,
+	// at their first occurrence
+	dst := []struct {
+		expr string
+		from string
+		want []string
+	}{
+		{
+			expr: "30 2 * * *",
+			from: "2026-10-25T00:00:00+02:00",
+			want: []string{"2026-10-25T02:30:00+02:00", "2026-10-26T02:30:00+01:00"},
+		},
+		{
+			// the repeated hour is not activated again
+			expr: "* 2 * * *",
+			from: "2026-10-25T02:58:00+02:00",
+			want: []string{"2026-10-25T02:59:00+02:00", "2026-10-26T02:00:00+01:00"},
+		},
+		{
+			// from the repeated hour, the times not repeated are activated
+			expr: "*/30 * * * *",
+			from: "2026-10-25T02:10:00+01:00",
+			want: []string{"2026-10-25T03:00:00+01:00", "2026-10-25T03:30:00+01:00"},
+		},
+		{
+			expr: "*/30 * * * *",
+			from: "2026-10-25T01:50:00+02:00",
+			want: []string{"2026-10-25T02:00:00+02:00", "2026-10-25T02:30:00+02:00", "2026-10-25T03:00:00+01:00"},
+		},
+	}
+	for _, tt := range dst {
+		s, err := Parse(tt.expr, rome)
+		require.NoError(t, err, tt.expr)
+
+		got, err := time.Parse(time.RFC3339, tt.from)
+		require.NoError(t, err)
+		for _, w := range tt.want {
+			got = s.Next(got)
+			assert.Equal(t, w, got.Format(time.RFC3339), tt.expr)
+		}
+	}
+
+	// no activation time
+	s, err := Parse("0 0 30 2 *", rome)
+	require.NoError(t, err)
+	assert.True(t, s.Next(date("2026-10-16 00:00")).IsZero())
+}
@@ -62,14 +62,24 @@ func handlerContent() http.Handler {
 	return http.FileServer(http.FS(root))
 }
 
-func Run(serverAddressPort, jsonDataFolder string, recursive bool) error {
+// NewHandler returns the handler of the web page with the graphs
+// of the json files of the folder.
+func NewHandler(jsonDataFolder string, recursive bool) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.Handle("/", handlerContent())
+
+	mux.HandleFunc("/data", handlerIndexJson(jsonDataFolder, recursive))
+	mux.Handle("/data/", http.StripPrefix("/data", http.FileServer(http.Dir(jsonDataFolder))))
 
-	http.Handle("/", handlerContent())
+	return mux
+}
+
+func Run(serverAddressPort, jsonDataFolder string, recursive bool) error {
 
-	http.HandleFunc("/data", handlerIndexJson(jsonDataFolder, recursive))
-	http.Handle("/data/", http.StripPrefix("/data", http.FileServer(http.Dir(jsonDataFolder))))
+	handler := NewHandler(jsonDataFolder, recursive)
 
 	fmt.Printf("server listening to %s\n", serverAddressPort)
-	err := http.ListenAndServe(serverAddressPort, nil)
+	err := http.ListenAndServe(serverAddressPort, handler)
 	return err
 }